@@ -58,3 +58,19 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Name of the Secret holding the password of the local admin account
+*/}}
+{{- define "homecraft-backend.localAdminSecretName" -}}
+{{- default (printf "%s-local-admin" (include "homecraft-backend.fullname" .)) .Values.localAdmin.existingSecret }}
+{{- end }}
+
+{{/*
+Fail the render when the API would have no way to authenticate anyone
+*/}}
+{{- define "homecraft-backend.validateAuth" -}}
+{{- if not (or .Values.env.AUTH_ISSUER_URL (eq (toString .Values.env.AUTH_LOCAL_ACCOUNTS) "true") (eq (toString .Values.env.AUTH_DISABLED) "true")) }}
+{{- fail "env.AUTH_ISSUER_URL or env.AUTH_LOCAL_ACCOUNTS=\"true\" is required, set env.AUTH_DISABLED=\"true\" to run the API without authentication" }}
+{{- end }}
+{{- end }}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/auth"
 	"github.com/homecraft/backend/pkg/handlers"
 	"github.com/homecraft/backend/pkg/k8s"
+	"github.com/homecraft/backend/pkg/metrics"
+	"github.com/homecraft/backend/pkg/quota"
 )
 
 func main() {
@@ -16,8 +24,18 @@ func main() {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
+	// Per-user quotas are optional, without them users are only limited by the cluster capacity
+	var quotas *quota.Config
+	if path := os.Getenv("QUOTA_CONFIG_FILE"); path != "" {
+		quotas, err = quota.Load(path)
+		if err != nil {
+			log.Fatalf("Failed to load quotas: %v", err)
+		}
+		log.Printf("Enforcing quotas from %s", path)
+	}
+
 	// Create server handler
-	serverHandler := handlers.NewServerHandler(k8sClient)
+	serverHandler := handlers.NewServerHandler(k8sClient, quotas)
 
 	// Set Gin mode from environment
 	if mode := os.Getenv("GIN_MODE"); mode != "" {
@@ -27,32 +45,68 @@ func main() {
 	// Create Gin router
 	router := gin.Default()
 
+	// Record request latency and status for Prometheus
+	router.Use(metrics.Middleware())
+
 	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+	router.Use(corsMiddleware(splitList(os.Getenv("CORS_ALLOWED_ORIGINS"))))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	// Local accounts log in with a password and are then authenticated by a session cookie
+	var accounts *auth.LocalAccounts
+	var sessions *auth.SessionStore
+	if os.Getenv("AUTH_LOCAL_ACCOUNTS") == "true" && os.Getenv("AUTH_DISABLED") != "true" {
+		accounts, sessions, err = newLocalAccounts(context.Background(), k8sClient)
+		if err != nil {
+			log.Fatalf("Failed to set up local accounts: %v", err)
 		}
+	}
+	authHandler := handlers.NewAuthHandler(accounts, sessions, os.Getenv("AUTH_COOKIE_INSECURE") != "true")
 
-		c.Next()
-	})
+	// Every API call needs a bearer token or session cookie, /health and login stay open
+	authMiddleware, err := newAuthMiddleware(context.Background(), sessions)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
 
 	// Health check endpoint
 	router.GET("/health", serverHandler.HealthCheck)
 
+	// Login endpoints
+	if accounts != nil {
+		router.POST("/api/v1/auth/login", authHandler.Login)
+		router.POST("/api/v1/auth/logout", authHandler.Logout)
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
+	v1.Use(authMiddleware)
 	{
+		// Account endpoints
+		v1.GET("/auth/me", authHandler.Me)
+		if accounts != nil {
+			v1.POST("/auth/users", authHandler.CreateUser)
+		}
+
 		// Minecraft server endpoints
 		v1.POST("/servers", serverHandler.CreateServer)
 		v1.GET("/servers", serverHandler.ListServers)
+		v1.GET("/servers/watch", serverHandler.WatchServers)
 		v1.GET("/servers/:name", serverHandler.GetServer)
+		v1.PATCH("/servers/:name", serverHandler.UpdateServer)
 		v1.DELETE("/servers/:name", serverHandler.DeleteServer)
+		v1.POST("/servers/:name/stop", serverHandler.StopServer)
+		v1.POST("/servers/:name/start", serverHandler.StartServer)
+		v1.POST("/servers/:name/command", serverHandler.ExecuteCommand)
+		v1.GET("/servers/:name/logs", serverHandler.GetServerLogs)
+		v1.GET("/servers/:name/players", serverHandler.GetServerPlayers)
+		v1.GET("/servers/:name/credentials", serverHandler.GetServerCredentials)
+		v1.POST("/servers/:name/sftp/rotate", serverHandler.RotateSFTPCredentials)
+		v1.POST("/servers/:name/backups", serverHandler.CreateBackup)
+		v1.GET("/servers/:name/backups", serverHandler.ListBackups)
+		v1.POST("/servers/:name/backups/:backup/restore", serverHandler.RestoreBackup)
+
+		// Quota endpoints
+		v1.GET("/quotas/me", serverHandler.GetMyQuota)
 
 		// Cluster resource endpoints
 		v1.GET("/cluster/resources", serverHandler.GetClusterResources)
@@ -64,8 +118,134 @@ func main() {
 		port = "8080"
 	}
 
+	// Metrics are served on their own port, so they are not exposed through the API ingress
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if metricsAddr == "" {
+		metricsAddr = ":9090"
+	}
+	go serveMetrics(metricsAddr)
+
 	log.Printf("Starting HomeCraft API server on port %s", port)
 	if err := router.Run(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// serveMetrics serves the Prometheus metrics of the API on /metrics
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	log.Printf("Serving metrics on %s", addr)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("Failed to serve metrics: %v", err)
+	}
+}
+
+// newAuthMiddleware builds the middleware authenticating API calls from the AUTH_* environment variables.
+// Bearer tokens are accepted when an issuer is configured and session cookies when sessions is set.
+func newAuthMiddleware(ctx context.Context, sessions *auth.SessionStore) (gin.HandlerFunc, error) {
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		log.Printf("WARNING: authentication is disabled, anyone who can reach the API can manage servers")
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+
+	var authenticators []auth.RequestAuthenticator
+	cfg := auth.Config{
+		IssuerURL:     os.Getenv("AUTH_ISSUER_URL"),
+		Audience:      os.Getenv("AUTH_AUDIENCE"),
+		JWKSURL:       os.Getenv("AUTH_JWKS_URL"),
+		PublicKeyFile: os.Getenv("AUTH_PUBLIC_KEY_FILE"),
+		AdminGroups:   splitList(os.Getenv("AUTH_ADMIN_GROUPS")),
+	}
+	if cfg.IssuerURL != "" {
+		verifier, err := auth.NewOIDCVerifier(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Authenticating API calls with tokens issued by %s", cfg.IssuerURL)
+		authenticators = append(authenticators, auth.BearerToken(verifier))
+	}
+	if sessions != nil {
+		log.Printf("Authenticating API calls with local account sessions")
+		authenticators = append(authenticators, sessions)
+	}
+
+	if len(authenticators) == 0 {
+		return nil, fmt.Errorf("AUTH_ISSUER_URL or AUTH_LOCAL_ACCOUNTS=true is required, set AUTH_DISABLED=true to run without authentication")
+	}
+	return auth.Middleware(authenticators...), nil
+}
+
+// newLocalAccounts sets up local accounts and their sessions from the AUTH_* and LOCAL_ADMIN_* environment variables,
+// creating the admin account on first start
+func newLocalAccounts(ctx context.Context, k8sClient *k8s.Client) (*auth.LocalAccounts, *auth.SessionStore, error) {
+	ttl := auth.DefaultSessionTTL
+	if value := os.Getenv("AUTH_SESSION_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, nil, fmt.Errorf("invalid AUTH_SESSION_TTL %q, expected a duration like 12h", value)
+		}
+		ttl = parsed
+	}
+
+	accounts := auth.NewLocalAccounts(k8sClient.GetClientset(), handlers.MinecraftNamespace, os.Getenv("AUTH_USERS_SECRET"))
+
+	username := os.Getenv("LOCAL_ADMIN_USERNAME")
+	if username == "" {
+		username = "admin"
+	}
+	password := os.Getenv("LOCAL_ADMIN_PASSWORD")
+	if password == "" {
+		log.Printf("WARNING: LOCAL_ADMIN_PASSWORD is not set, no admin account is created")
+		return accounts, auth.NewSessionStore(ttl), nil
+	}
+	created, err := accounts.EnsureAdmin(ctx, username, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create admin account %s: %w", username, err)
+	}
+	if created {
+		log.Printf("Created local admin account %s", username)
+	}
+	return accounts, auth.NewSessionStore(ttl), nil
+}
+
+// corsMiddleware allows browsers on the given origins to call the API.
+// Without origins any origin is allowed, but browsers then won't send credentials.
+func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+
+	return func(c *gin.Context) {
+		if len(allowed) == 0 {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin := c.GetHeader("Origin"); allowed[origin] {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// splitList splits a comma separated environment variable, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,53 @@
+// Command gateway accepts the player connections of every MinecraftServer on one address and
+// proxies each to the server named by the hostname the player joined with.
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/homecraft/backend/pkg/gateway"
+	"github.com/homecraft/backend/pkg/k8s"
+)
+
+func main() {
+	domain := os.Getenv("GATEWAY_DOMAIN")
+	if domain == "" {
+		log.Fatal("GATEWAY_DOMAIN must be set to the parent domain of the server hostnames, e.g. mc.home.lan")
+	}
+	namespace := os.Getenv("SERVER_NAMESPACE")
+	if namespace == "" {
+		namespace = "minecraft-servers"
+	}
+	listenAddr := os.Getenv("LISTEN_ADDR")
+	if listenAddr == "" {
+		listenAddr = ":25565"
+	}
+
+	k8sClient, err := k8s.NewClient()
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", listenAddr, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g := &gateway.Gateway{
+		Servers:   k8sClient,
+		Namespace: namespace,
+		Domain:    domain,
+	}
+	log.Printf("Routing *.%s to the servers in %s, listening on %s", domain, namespace, listenAddr)
+	if err := g.Run(ctx, listener); err != nil {
+		log.Fatalf("Gateway failed: %v", err)
+	}
+}
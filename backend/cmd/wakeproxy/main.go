@@ -0,0 +1,53 @@
+// Command wakeproxy stands in for a stopped MinecraftServer on its game port. The operator runs it
+// for servers whose idle shutdown policy has wakeOnConnect set.
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/homecraft/backend/pkg/k8s"
+	"github.com/homecraft/backend/pkg/wakeproxy"
+)
+
+func main() {
+	name := os.Getenv("SERVER_NAME")
+	if name == "" {
+		log.Fatal("SERVER_NAME must be set to the MinecraftServer to stand in for")
+	}
+	namespace := os.Getenv("SERVER_NAMESPACE")
+	if namespace == "" {
+		namespace = "minecraft-servers"
+	}
+	listenAddr := os.Getenv("LISTEN_ADDR")
+	if listenAddr == "" {
+		listenAddr = ":25565"
+	}
+
+	k8sClient, err := k8s.NewClient()
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", listenAddr, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	proxy := &wakeproxy.Proxy{
+		Servers:   k8sClient,
+		Namespace: namespace,
+		Name:      name,
+	}
+	log.Printf("Waking server %s/%s on connect, listening on %s", namespace, name, listenAddr)
+	if err := proxy.Serve(ctx, listener); err != nil {
+		log.Fatalf("Proxy failed: %v", err)
+	}
+}
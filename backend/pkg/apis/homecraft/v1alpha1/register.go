@@ -34,6 +34,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&MinecraftServer{},
 		&MinecraftServerList{},
+		&MinecraftBackup{},
+		&MinecraftBackupList{},
+		&MinecraftRestore{},
+		&MinecraftRestoreList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
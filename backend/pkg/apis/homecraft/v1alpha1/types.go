@@ -14,10 +14,20 @@ type MinecraftServerSpec struct {
 	// +optional
 	SFTPUsername string `json:"sftpUsername,omitempty"`
 
-	// SFTPPassword is the auto-generated SFTP password for file access
+	// SFTPPassword is deprecated, the SFTP password is kept in the server's Secret.
+	// A password set here is moved to the Secret and cleared by the operator.
 	// +optional
 	SFTPPassword string `json:"sftpPassword,omitempty"`
 
+	// AuthorizedKeys are SSH public keys, in authorized_keys format, that can log in over SFTP
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	AuthorizedKeys []string `json:"authorizedKeys,omitempty"`
+
+	// DisableSFTPPassword turns off password logins over SFTP, leaving only the authorized keys
+	// +optional
+	DisableSFTPPassword bool `json:"disableSFTPPassword,omitempty"`
+
 	// Memory is the amount of RAM allocated to the server (e.g., "2Gi", "4Gi")
 	// +kubebuilder:default="2Gi"
 	// +kubebuilder:validation:Pattern=`^[0-9]+[MGT]i$`
@@ -58,11 +68,94 @@ type MinecraftServerSpec struct {
 	// PublicEndpoint is the public endpoint for external access (e.g., Playit tunnel address)
 	// +optional
 	PublicEndpoint string `json:"publicEndpoint,omitempty"`
+
+	// PublicTunnel makes the operator open a tunnel to the game port through its tunnel provider,
+	// whose address is reported as the public endpoint instead of PublicEndpoint
+	// +optional
+	PublicTunnel bool `json:"publicTunnel,omitempty"`
+
+	// Paused stops the server by scaling it to zero replicas while keeping its world data
+	// +kubebuilder:default=false
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// BackupSchedule makes the operator back the server up periodically
+	// +optional
+	BackupSchedule *BackupSchedule `json:"backupSchedule,omitempty"`
+
+	// IdleShutdown makes the operator stop the server once nobody has been online for a while
+	// +optional
+	IdleShutdown *IdleShutdownPolicy `json:"idleShutdown,omitempty"`
+
+	// Expose sets how the game and SFTP ports of the server are reachable
+	// +optional
+	Expose *ExposeSpec `json:"expose,omitempty"`
+}
+
+// BackupSchedule defines when a server is backed up and which scheduled backups are kept.
+// A scheduled backup is kept when any of the retention rules keeps it; without rules all are kept.
+type BackupSchedule struct {
+	// Schedule is a cron expression in UTC (e.g., "0 4 * * *" for every day at 04:00)
+	Schedule string `json:"schedule"`
+
+	// KeepLast keeps the newest N completed scheduled backups
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepLast int `json:"keepLast,omitempty"`
+
+	// KeepDaily keeps the newest completed scheduled backup of each of the last N days that have one
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepDaily int `json:"keepDaily,omitempty"`
+
+	// KeepWeekly keeps the newest completed scheduled backup of each of the last N weeks that have one
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepWeekly int `json:"keepWeekly,omitempty"`
+}
+
+// IdleShutdownPolicy defines when an empty server is stopped. The operator stops it by pausing it,
+// so it is started again like any other stopped server or, with WakeOnConnect, by a player joining.
+type IdleShutdownPolicy struct {
+	// TimeoutMinutes is how long the server has to be running without players before it is stopped
+	// +kubebuilder:validation:Minimum=1
+	TimeoutMinutes int `json:"timeoutMinutes"`
+
+	// WakeOnConnect keeps a proxy on the game port while the server is stopped by this policy. It answers
+	// status pings and starts the server when a player joins.
+	// +optional
+	WakeOnConnect bool `json:"wakeOnConnect,omitempty"`
+}
+
+// ExposeSpec defines the kind of service the game and SFTP ports are reachable through. A port
+// without a setting gets a LoadBalancer service, or for the game port a ClusterIP service when the
+// operator routes players through its gateway.
+type ExposeSpec struct {
+	// Game exposes the Minecraft port
+	// +optional
+	Game *ServiceExposure `json:"game,omitempty"`
+
+	// SFTP exposes the SFTP port
+	// +optional
+	SFTP *ServiceExposure `json:"sftp,omitempty"`
+}
+
+// ServiceExposure defines the service of one port of a server
+type ServiceExposure struct {
+	// Type is the service type: LoadBalancer, NodePort or ClusterIP
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort;ClusterIP
+	Type string `json:"type"`
+
+	// NodePort is the port a NodePort service uses on every node, the cluster picks one when unset
+	// +kubebuilder:validation:Minimum=30000
+	// +kubebuilder:validation:Maximum=32767
+	// +optional
+	NodePort int32 `json:"nodePort,omitempty"`
 }
 
 // MinecraftServerStatus defines the observed state of MinecraftServer
 type MinecraftServerStatus struct {
-	// Phase represents the current phase of the server (Pending, Running, Failed)
+	// Phase represents the current phase of the server (Pending, Starting, Running, Stopping, Stopped, Restoring, Failed)
 	Phase string `json:"phase,omitempty"`
 
 	// Endpoint is the service endpoint to connect to the server (local/private)
@@ -77,9 +170,13 @@ type MinecraftServerStatus struct {
 	// SFTPUsername is the generated SFTP username (populated by controller)
 	SFTPUsername string `json:"sftpUsername,omitempty"`
 
-	// SFTPPassword is the generated SFTP password (populated by controller)
+	// SFTPPassword is deprecated and no longer populated, the password is kept in the server's Secret
 	SFTPPassword string `json:"sftpPassword,omitempty"`
 
+	// SFTPPasswordRotatedAt is when the SFTP password was last rotated
+	// +optional
+	SFTPPasswordRotatedAt *metav1.Time `json:"sftpPasswordRotatedAt,omitempty"`
+
 	// AllocatedMemory is the actual memory allocated to the server
 	AllocatedMemory string `json:"allocatedMemory,omitempty"`
 
@@ -91,6 +188,60 @@ type MinecraftServerStatus struct {
 
 	// Conditions represent the latest available observations of the server's state
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Game is what the server reports to status pings, it is only set while the server answers them
+	// +optional
+	Game *GameStatus `json:"game,omitempty"`
+
+	// EmptySince is when the running server was last seen going without players, it is cleared
+	// as soon as a player is online or the server stops
+	// +optional
+	EmptySince *metav1.Time `json:"emptySince,omitempty"`
+
+	// StopReason tells why the operator stopped the server, it is empty when a user stopped it
+	// and cleared when the server is started again
+	// +optional
+	StopReason string `json:"stopReason,omitempty"`
+
+	// TunnelID is the ID the tunnel provider gave the public tunnel of the server
+	// +optional
+	TunnelID string `json:"tunnelID,omitempty"`
+
+	// TunnelOrigin is the address the public tunnel forwards to
+	// +optional
+	TunnelOrigin string `json:"tunnelOrigin,omitempty"`
+
+	// LastScheduledBackupTime is when the backup schedule last created a backup
+	// +optional
+	LastScheduledBackupTime *metav1.Time `json:"lastScheduledBackupTime,omitempty"`
+
+	// LastSuccessfulBackupTime is when the newest completed backup of the server finished
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+}
+
+// GameStatus is what a running server reports to status pings, as shown in the multiplayer server list
+type GameStatus struct {
+	// MOTD is the message of the day, without formatting codes
+	MOTD string `json:"motd,omitempty"`
+
+	// Version is the name of the game version the server runs, e.g. "Paper 1.21.1"
+	Version string `json:"version,omitempty"`
+
+	// Protocol is the protocol version of the game the server runs
+	Protocol int32 `json:"protocol,omitempty"`
+
+	// PlayersOnline is the number of connected players
+	PlayersOnline int `json:"playersOnline"`
+
+	// PlayersMax is the number of player slots
+	PlayersMax int `json:"playersMax,omitempty"`
+
+	// LatencyMilliseconds is the round trip time of the last ping from the operator
+	LatencyMilliseconds int64 `json:"latencyMilliseconds,omitempty"`
+
+	// LastPingTime is when the server last answered a status ping
+	LastPingTime metav1.Time `json:"lastPingTime,omitempty"`
 }
 
 // +genclient
@@ -100,6 +251,7 @@ type MinecraftServerStatus struct {
 // +kubebuilder:resource:scope=Namespaced,shortName=mcs
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint`
+// +kubebuilder:printcolumn:name="Players",type=integer,JSONPath=`.status.game.playersOnline`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // MinecraftServer is the Schema for the minecraftservers API
@@ -120,3 +272,182 @@ type MinecraftServerList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []MinecraftServer `json:"items"`
 }
+
+const (
+	// ServerNameLabel is set on objects that belong to a MinecraftServer, its value is the server name
+	ServerNameLabel = "homecraft.io/server"
+
+	// RestoreAnnotation is set on a MinecraftServer while a MinecraftRestore replaces its world.
+	// Its value is the name of the restore; the server is kept stopped as long as it is present.
+	RestoreAnnotation = "homecraft.io/restore"
+
+	// OwnerAnnotation is set on a MinecraftServer to the username of the user who created it
+	OwnerAnnotation = "homecraft.io/owner"
+
+	// OwnerIDAnnotation is set on a MinecraftServer to the issuer and subject of the user who created it.
+	// Only that user and admins can see and manage the server.
+	OwnerIDAnnotation = "homecraft.io/owner-id"
+
+	// SFTPPasswordRotatedAnnotation is set on a server's Secret to the RFC 3339 time its SFTP password
+	// was last rotated. The operator reports it in the server status.
+	SFTPPasswordRotatedAnnotation = "homecraft.io/sftp-password-rotated-at"
+
+	// ScheduledBackupLabel is set on MinecraftBackups created by a server's backup schedule.
+	// Only these backups are pruned by the schedule's retention rules.
+	ScheduledBackupLabel = "homecraft.io/scheduled"
+
+	// StopReasonIdle is the stop reason of a server stopped by its idle shutdown policy
+	StopReasonIdle = "Idle"
+
+	// ExposeLoadBalancer, ExposeNodePort and ExposeClusterIP are the service types of ServiceExposure
+	ExposeLoadBalancer = "LoadBalancer"
+	ExposeNodePort     = "NodePort"
+	ExposeClusterIP    = "ClusterIP"
+
+	// ServerConditionRestoring is the MinecraftServer condition reporting the progress of a restore
+	ServerConditionRestoring = "Restoring"
+
+	// ServerConditionStorageBound reports whether the world's volume claim is bound to a volume
+	ServerConditionStorageBound = "StorageBound"
+	// ServerConditionScheduled reports whether the server pod is placed on a node
+	ServerConditionScheduled = "Scheduled"
+	// ServerConditionGameReady reports whether the Minecraft container accepts players
+	ServerConditionGameReady = "GameReady"
+	// ServerConditionSFTPReady reports whether the sftp container accepts logins
+	ServerConditionSFTPReady = "SFTPReady"
+	// ServerConditionEndpointAssigned reports whether the game service has an address players can connect to
+	ServerConditionEndpointAssigned = "EndpointAssigned"
+)
+
+// Backup phases
+const (
+	BackupPhasePending   = "Pending"
+	BackupPhaseRunning   = "Running"
+	BackupPhaseCompleted = "Completed"
+	BackupPhaseFailed    = "Failed"
+)
+
+// MinecraftBackupSpec defines the desired state of MinecraftBackup
+type MinecraftBackupSpec struct {
+	// ServerName is the name of the MinecraftServer in the same namespace whose world is backed up
+	// +kubebuilder:validation:MinLength=1
+	ServerName string `json:"serverName"`
+}
+
+// MinecraftBackupStatus defines the observed state of MinecraftBackup
+type MinecraftBackupStatus struct {
+	// Phase represents the current phase of the backup (Pending, Running, Completed, Failed)
+	Phase string `json:"phase,omitempty"`
+
+	// Path is the location of the archive on the backup volume
+	Path string `json:"path,omitempty"`
+
+	// Size is the size of the archive in bytes
+	Size int64 `json:"size,omitempty"`
+
+	// Checksum is the digest of the archive (e.g., "sha256:...")
+	Checksum string `json:"checksum,omitempty"`
+
+	// StartTime is when the archive job was started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the backup completed or failed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message provides additional information about the current state
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=mcb
+// +kubebuilder:printcolumn:name="Server",type=string,JSONPath=`.spec.serverName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.size`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// MinecraftBackup is the Schema for the minecraftbackups API
+type MinecraftBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MinecraftBackupSpec   `json:"spec,omitempty"`
+	Status MinecraftBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// MinecraftBackupList contains a list of MinecraftBackup
+type MinecraftBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MinecraftBackup `json:"items"`
+}
+
+// Restore phases
+const (
+	RestorePhasePending   = "Pending"
+	RestorePhaseStopping  = "Stopping"
+	RestorePhaseRestoring = "Restoring"
+	RestorePhaseCompleted = "Completed"
+	RestorePhaseFailed    = "Failed"
+)
+
+// MinecraftRestoreSpec defines the desired state of MinecraftRestore
+type MinecraftRestoreSpec struct {
+	// BackupName is the name of the completed MinecraftBackup in the same namespace to restore
+	// +kubebuilder:validation:MinLength=1
+	BackupName string `json:"backupName"`
+
+	// ServerName is the MinecraftServer whose world is replaced by the backup.
+	// It may differ from the server the backup was taken of, to restore a copy into a new server.
+	// +kubebuilder:validation:MinLength=1
+	ServerName string `json:"serverName"`
+}
+
+// MinecraftRestoreStatus defines the observed state of MinecraftRestore
+type MinecraftRestoreStatus struct {
+	// Phase represents the current phase of the restore (Pending, Stopping, Restoring, Completed, Failed)
+	Phase string `json:"phase,omitempty"`
+
+	// StartTime is when the server was stopped for the restore
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the restore completed or failed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message provides additional information about the current state
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=mcr
+// +kubebuilder:printcolumn:name="Server",type=string,JSONPath=`.spec.serverName`
+// +kubebuilder:printcolumn:name="Backup",type=string,JSONPath=`.spec.backupName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// MinecraftRestore is the Schema for the minecraftrestores API
+type MinecraftRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MinecraftRestoreSpec   `json:"spec,omitempty"`
+	Status MinecraftRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// MinecraftRestoreList contains a list of MinecraftRestore
+type MinecraftRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MinecraftRestore `json:"items"`
+}
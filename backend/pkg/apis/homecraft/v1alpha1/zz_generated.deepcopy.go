@@ -70,6 +70,26 @@ func (in *MinecraftServerList) DeepCopyObject() runtime.Object {
 // same type that is provided as a pointer.
 func (in *MinecraftServerSpec) DeepCopyInto(out *MinecraftServerSpec) {
 	*out = *in
+	if in.AuthorizedKeys != nil {
+		in, out := &in.AuthorizedKeys, &out.AuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupSchedule != nil {
+		in, out := &in.BackupSchedule, &out.BackupSchedule
+		*out = new(BackupSchedule)
+		**out = **in
+	}
+	if in.IdleShutdown != nil {
+		in, out := &in.IdleShutdown, &out.IdleShutdown
+		*out = new(IdleShutdownPolicy)
+		**out = **in
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(ExposeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy copies the receiver, creating a new MinecraftServerSpec.
@@ -86,6 +106,10 @@ func (in *MinecraftServerSpec) DeepCopy() *MinecraftServerSpec {
 // same type that is provided as a pointer.
 func (in *MinecraftServerStatus) DeepCopyInto(out *MinecraftServerStatus) {
 	*out = *in
+	if in.SFTPPasswordRotatedAt != nil {
+		in, out := &in.SFTPPasswordRotatedAt, &out.SFTPPasswordRotatedAt
+		*out = (*in).DeepCopy()
+	}
 	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -94,6 +118,23 @@ func (in *MinecraftServerStatus) DeepCopyInto(out *MinecraftServerStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Game != nil {
+		in, out := &in.Game, &out.Game
+		*out = new(GameStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EmptySince != nil {
+		in, out := &in.EmptySince, &out.EmptySince
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScheduledBackupTime != nil {
+		in, out := &in.LastScheduledBackupTime, &out.LastScheduledBackupTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulBackupTime != nil {
+		in, out := &in.LastSuccessfulBackupTime, &out.LastSuccessfulBackupTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy copies the receiver, creating a new MinecraftServerStatus.
@@ -105,3 +146,296 @@ func (in *MinecraftServerStatus) DeepCopy() *MinecraftServerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *GameStatus) DeepCopyInto(out *GameStatus) {
+	*out = *in
+	in.LastPingTime.DeepCopyInto(&out.LastPingTime)
+}
+
+// DeepCopy copies the receiver, creating a new GameStatus.
+func (in *GameStatus) DeepCopy() *GameStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GameStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *IdleShutdownPolicy) DeepCopyInto(out *IdleShutdownPolicy) {
+	*out = *in
+}
+
+// DeepCopy copies the receiver, creating a new IdleShutdownPolicy.
+func (in *IdleShutdownPolicy) DeepCopy() *IdleShutdownPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(IdleShutdownPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *ExposeSpec) DeepCopyInto(out *ExposeSpec) {
+	*out = *in
+	if in.Game != nil {
+		in, out := &in.Game, &out.Game
+		*out = new(ServiceExposure)
+		**out = **in
+	}
+	if in.SFTP != nil {
+		in, out := &in.SFTP, &out.SFTP
+		*out = new(ServiceExposure)
+		**out = **in
+	}
+}
+
+// DeepCopy copies the receiver, creating a new ExposeSpec.
+func (in *ExposeSpec) DeepCopy() *ExposeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *ServiceExposure) DeepCopyInto(out *ServiceExposure) {
+	*out = *in
+}
+
+// DeepCopy copies the receiver, creating a new ServiceExposure.
+func (in *ServiceExposure) DeepCopy() *ServiceExposure {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceExposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *BackupSchedule) DeepCopyInto(out *BackupSchedule) {
+	*out = *in
+}
+
+// DeepCopy copies the receiver, creating a new BackupSchedule.
+func (in *BackupSchedule) DeepCopy() *BackupSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftBackup) DeepCopyInto(out *MinecraftBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftBackup.
+func (in *MinecraftBackup) DeepCopy() *MinecraftBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject copies the receiver, creating a new runtime.Object.
+func (in *MinecraftBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftBackupList) DeepCopyInto(out *MinecraftBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MinecraftBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftBackupList.
+func (in *MinecraftBackupList) DeepCopy() *MinecraftBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject copies the receiver, creating a new runtime.Object.
+func (in *MinecraftBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftBackupSpec) DeepCopyInto(out *MinecraftBackupSpec) {
+	*out = *in
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftBackupSpec.
+func (in *MinecraftBackupSpec) DeepCopy() *MinecraftBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftBackupStatus) DeepCopyInto(out *MinecraftBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftBackupStatus.
+func (in *MinecraftBackupStatus) DeepCopy() *MinecraftBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftRestore) DeepCopyInto(out *MinecraftRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftRestore.
+func (in *MinecraftRestore) DeepCopy() *MinecraftRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject copies the receiver, creating a new runtime.Object.
+func (in *MinecraftRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftRestoreList) DeepCopyInto(out *MinecraftRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MinecraftRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftRestoreList.
+func (in *MinecraftRestoreList) DeepCopy() *MinecraftRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject copies the receiver, creating a new runtime.Object.
+func (in *MinecraftRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftRestoreSpec) DeepCopyInto(out *MinecraftRestoreSpec) {
+	*out = *in
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftRestoreSpec.
+func (in *MinecraftRestoreSpec) DeepCopy() *MinecraftRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *MinecraftRestoreStatus) DeepCopyInto(out *MinecraftRestoreStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy copies the receiver, creating a new MinecraftRestoreStatus.
+func (in *MinecraftRestoreStatus) DeepCopy() *MinecraftRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MinecraftRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
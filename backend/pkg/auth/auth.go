@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/models"
+)
+
+// identityKey is the gin context key the authenticated Identity is stored under
+const identityKey = "homecraft.identity"
+
+var (
+	// errNoCredentials is returned by a RequestAuthenticator for requests without its kind of credentials
+	errNoCredentials = errors.New("no credentials")
+
+	// errSessionExpired is returned for a session cookie whose session is gone
+	errSessionExpired = errors.New("session expired or logged out")
+)
+
+// Identity is the authenticated caller of an API request
+type Identity struct {
+	Subject  string   `json:"sub"`
+	Issuer   string   `json:"iss"`
+	Username string   `json:"username"`
+	Email    string   `json:"email,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Admin    bool     `json:"admin"`
+}
+
+// ID identifies the user across login methods; the issuer keeps a local account and an OIDC user
+// with the same subject apart
+func (i *Identity) ID() string {
+	return i.Issuer + "#" + i.Subject
+}
+
+// TokenVerifier checks a bearer token and returns the identity it was issued to
+type TokenVerifier interface {
+	Verify(ctx context.Context, rawToken string) (*Identity, error)
+}
+
+// RequestAuthenticator identifies the caller of a request from one kind of credentials,
+// such as a bearer token or a session cookie
+type RequestAuthenticator interface {
+	AuthenticateRequest(r *http.Request) (*Identity, error)
+}
+
+// AccessTokenParameter is the query parameter carrying the bearer token of event streams, since
+// browsers can't set headers on an EventSource
+const AccessTokenParameter = "access_token"
+
+// BearerToken authenticates requests carrying an "Authorization: Bearer" token that verifier accepts.
+// Event stream requests may pass the token in the AccessTokenParameter instead.
+func BearerToken(verifier TokenVerifier) RequestAuthenticator {
+	return bearerAuthenticator{verifier: verifier}
+}
+
+type bearerAuthenticator struct {
+	verifier TokenVerifier
+}
+
+func (b bearerAuthenticator) AuthenticateRequest(r *http.Request) (*Identity, error) {
+	rawToken, ok := bearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		rawToken, ok = eventStreamToken(r)
+	}
+	if !ok {
+		return nil, errNoCredentials
+	}
+	return b.verifier.Verify(r.Context(), rawToken)
+}
+
+// Config configures how bearer tokens issued by an OIDC provider are verified
+type Config struct {
+	// IssuerURL is the OIDC issuer; tokens must carry it as their iss claim
+	IssuerURL string
+	// Audience is the expected aud claim, usually the client ID. Empty skips the check.
+	Audience string
+	// JWKSURL overrides the signing keys found through OIDC discovery
+	JWKSURL string
+	// PublicKeyFile is a PEM encoded public key to verify tokens with instead of fetching keys,
+	// for issuers without discovery such as a local signing key
+	PublicKeyFile string
+	// AdminGroups are the groups claim values that make a user an admin
+	AdminGroups []string
+}
+
+// OIDCVerifier verifies JWT bearer tokens signed by an OIDC issuer
+type OIDCVerifier struct {
+	verifier    *oidc.IDTokenVerifier
+	adminGroups []string
+}
+
+// NewOIDCVerifier creates a verifier for the issuer in cfg. Without a public key file or JWKS URL
+// the issuer's signing keys are found through OIDC discovery, which requires the issuer to be reachable.
+func NewOIDCVerifier(ctx context.Context, cfg Config) (*OIDCVerifier, error) {
+	if cfg.IssuerURL == "" {
+		return nil, fmt.Errorf("issuer URL is required")
+	}
+	oidcConfig := &oidc.Config{
+		ClientID:          cfg.Audience,
+		SkipClientIDCheck: cfg.Audience == "",
+	}
+
+	var verifier *OIDCVerifier
+	switch {
+	case cfg.PublicKeyFile != "":
+		key, err := loadPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		verifier = NewStaticOIDCVerifier(cfg.IssuerURL, cfg.Audience, key)
+	case cfg.JWKSURL != "":
+		keySet := oidc.NewRemoteKeySet(ctx, cfg.JWKSURL)
+		verifier = &OIDCVerifier{verifier: oidc.NewVerifier(cfg.IssuerURL, keySet, oidcConfig)}
+	default:
+		provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC issuer %s: %w", cfg.IssuerURL, err)
+		}
+		verifier = &OIDCVerifier{verifier: provider.Verifier(oidcConfig)}
+	}
+	verifier.adminGroups = cfg.AdminGroups
+	return verifier, nil
+}
+
+// WithAdminGroups makes members of any of groups admins
+func (v *OIDCVerifier) WithAdminGroups(groups ...string) *OIDCVerifier {
+	v.adminGroups = groups
+	return v
+}
+
+// NewStaticOIDCVerifier creates a verifier that accepts tokens of issuer signed by one of keys
+func NewStaticOIDCVerifier(issuer, audience string, keys ...crypto.PublicKey) *OIDCVerifier {
+	keySet := &oidc.StaticKeySet{PublicKeys: keys}
+	return &OIDCVerifier{verifier: oidc.NewVerifier(issuer, keySet, &oidc.Config{
+		ClientID:          audience,
+		SkipClientIDCheck: audience == "",
+		// Without discovery the algorithm can't be looked up, so accept whatever the keys support
+		SupportedSigningAlgs: []string{oidc.RS256, oidc.RS384, oidc.RS512, oidc.ES256, oidc.ES384, oidc.ES512, oidc.PS256, oidc.EdDSA},
+	})}
+}
+
+// Verify checks the signature, issuer, audience and expiry of a token and returns its identity
+func (v *OIDCVerifier) Verify(ctx context.Context, rawToken string) (*Identity, error) {
+	token, err := v.verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Email             string   `json:"email"`
+		PreferredUsername string   `json:"preferred_username"`
+		Groups            []string `json:"groups"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Email
+	}
+	if username == "" {
+		username = token.Subject
+	}
+
+	return &Identity{
+		Subject:  token.Subject,
+		Issuer:   token.Issuer,
+		Username: username,
+		Email:    claims.Email,
+		Groups:   claims.Groups,
+		Admin:    v.isAdmin(claims.Groups),
+	}, nil
+}
+
+// isAdmin reports whether one of groups is an admin group
+func (v *OIDCVerifier) isAdmin(groups []string) bool {
+	for _, group := range groups {
+		if slices.Contains(v.adminGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests that none of the authenticators accepts with 401 Unauthorized and stores
+// the identity of the others in the gin context, where handlers read it with IdentityFromContext.
+// The first authenticator whose kind of credentials the request carries decides.
+func Middleware(authenticators ...RequestAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, authenticator := range authenticators {
+			identity, err := authenticator.AuthenticateRequest(c.Request)
+			if errors.Is(err, errNoCredentials) {
+				continue
+			}
+			if err != nil {
+				bearerError := ""
+				if _, ok := authenticator.(bearerAuthenticator); ok {
+					bearerError = "invalid_token"
+				}
+				unauthorized(c, bearerError, fmt.Sprintf("Authentication failed: %v", err))
+				return
+			}
+
+			c.Set(identityKey, identity)
+			c.Next()
+			return
+		}
+
+		unauthorized(c, "", "Authentication is required")
+	}
+}
+
+// IdentityFromContext returns the identity the Middleware authenticated the request as, or nil
+func IdentityFromContext(c *gin.Context) *Identity {
+	value, ok := c.Get(identityKey)
+	if !ok {
+		return nil
+	}
+	identity, _ := value.(*Identity)
+	return identity
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// eventStreamToken returns the token in the query of a GET request for an event stream. Other
+// requests must use the header, so tokens don't end up in access logs more than needed.
+func eventStreamToken(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return "", false
+	}
+	token := strings.TrimSpace(r.URL.Query().Get(AccessTokenParameter))
+	return token, token != ""
+}
+
+// unauthorized aborts a request with 401 and the WWW-Authenticate challenge of RFC 6750
+func unauthorized(c *gin.Context, bearerError, message string) {
+	challenge := `Bearer realm="homecraft"`
+	if bearerError != "" {
+		challenge += fmt.Sprintf(`, error="%s"`, bearerError)
+	}
+	c.Header("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+		Error:   "unauthorized",
+		Message: message,
+	})
+}
+
+// loadPublicKey reads a PEM encoded PKIX public key
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,324 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/homecraft/backend/pkg/models"
+)
+
+const testIssuer = "https://auth.example.com"
+
+// signToken signs claims as a JWT with key
+func signToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Failed to marshal claims: %v", err)
+	}
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	token, err := signed.CompactSerialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize token: %v", err)
+	}
+	return token
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                testIssuer,
+		"sub":                "user-123",
+		"aud":                "homecraft",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"email":              "steve@example.com",
+		"preferred_username": "steve",
+		"groups":             []string{"admins"},
+	}
+}
+
+func newTestRouter(verifier TokenVerifier) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	v1 := router.Group("/api/v1")
+	v1.Use(Middleware(BearerToken(verifier)))
+	v1.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, IdentityFromContext(c))
+	})
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	router := newTestRouter(NewStaticOIDCVerifier(testIssuer, "homecraft", &key.PublicKey))
+
+	withClaims := func(modify func(claims map[string]interface{})) map[string]interface{} {
+		claims := validClaims()
+		modify(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{
+			name:          "valid token",
+			authorization: "Bearer " + signToken(t, key, validClaims()),
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "scheme is case insensitive",
+			authorization: "bearer " + signToken(t, key, validClaims()),
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "missing header",
+			authorization: "",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "basic auth",
+			authorization: "Basic c3RldmU6c2VjcmV0",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "not a JWT",
+			authorization: "Bearer not-a-token",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "signed by another key",
+			authorization: "Bearer " + signToken(t, otherKey, validClaims()),
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			authorization: "Bearer " + signToken(t, key, withClaims(func(claims map[string]interface{}) {
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			})),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "other issuer",
+			authorization: "Bearer " + signToken(t, key, withClaims(func(claims map[string]interface{}) {
+				claims["iss"] = "https://evil.example.com"
+			})),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "other audience",
+			authorization: "Bearer " + signToken(t, key, withClaims(func(claims map[string]interface{}) {
+				claims["aud"] = "another-app"
+			})),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/whoami", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			if tt.wantStatus == http.StatusUnauthorized {
+				if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+					t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", w.Header().Get("WWW-Authenticate"))
+				}
+				var response models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error != "unauthorized" {
+					t.Errorf("body = %s, want an unauthorized error", w.Body.String())
+				}
+				return
+			}
+
+			var identity Identity
+			if err := json.Unmarshal(w.Body.Bytes(), &identity); err != nil {
+				t.Fatalf("Failed to parse identity: %v", err)
+			}
+			if identity.Subject != "user-123" || identity.Username != "steve" || identity.Email != "steve@example.com" {
+				t.Errorf("identity = %+v", identity)
+			}
+			if len(identity.Groups) != 1 || identity.Groups[0] != "admins" {
+				t.Errorf("groups = %v, want [admins]", identity.Groups)
+			}
+		})
+	}
+}
+
+func TestMiddleware_EventStreamAccessToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	router := gin.New()
+	router.Use(Middleware(BearerToken(NewStaticOIDCVerifier(testIssuer, "homecraft", &key.PublicKey))))
+	router.Handle("GET", "/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.Handle("POST", "/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+	token := signToken(t, key, validClaims())
+
+	tests := []struct {
+		name       string
+		method     string
+		accept     string
+		wantStatus int
+	}{
+		{name: "event stream", method: "GET", accept: "text/event-stream", wantStatus: http.StatusOK},
+		{name: "plain request", method: "GET", accept: "application/json", wantStatus: http.StatusUnauthorized},
+		{name: "not a GET", method: "POST", accept: "text/event-stream", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "/events?access_token="+token, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOIDCVerifier_AdminGroups(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		adminGroups []string
+		wantAdmin   bool
+	}{
+		{name: "member of an admin group", adminGroups: []string{"owners", "admins"}, wantAdmin: true},
+		{name: "not a member", adminGroups: []string{"owners"}, wantAdmin: false},
+		{name: "no admin groups", wantAdmin: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewStaticOIDCVerifier(testIssuer, "", &key.PublicKey).WithAdminGroups(tt.adminGroups...)
+			identity, err := verifier.Verify(t.Context(), signToken(t, key, validClaims()))
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if identity.Admin != tt.wantAdmin {
+				t.Errorf("Admin = %v, want %v", identity.Admin, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestMiddleware_HealthStaysOpen(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	router := newTestRouter(NewStaticOIDCVerifier(testIssuer, "", &key.PublicKey))
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /health status = %d, want 200", w.Code)
+	}
+}
+
+func TestNewOIDCVerifier_PublicKeyFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write public key: %v", err)
+	}
+
+	verifier, err := NewOIDCVerifier(t.Context(), Config{IssuerURL: testIssuer, PublicKeyFile: path})
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier() error = %v", err)
+	}
+
+	// Without an audience any aud claim is accepted
+	identity, err := verifier.Verify(t.Context(), signToken(t, key, validClaims()))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if identity.Issuer != testIssuer {
+		t.Errorf("issuer = %s, want %s", identity.Issuer, testIssuer)
+	}
+
+	if _, err := NewOIDCVerifier(t.Context(), Config{}); err == nil {
+		t.Error("NewOIDCVerifier() without issuer should fail")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		wantOK bool
+	}{
+		{header: "Bearer abc.def.ghi", want: "abc.def.ghi", wantOK: true},
+		{header: "Bearer   abc ", want: "abc", wantOK: true},
+		{header: "Bearer ", wantOK: false},
+		{header: "Bearer", wantOK: false},
+		{header: "Token abc", wantOK: false},
+		{header: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := bearerToken(tt.header)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("bearerToken(%q) = %q, %v; want %q, %v", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// LocalIssuer is the issuer of identities authenticated with a local account
+const LocalIssuer = "local"
+
+// DefaultUsersSecretName is the Secret local accounts are stored in
+const DefaultUsersSecretName = "homecraft-users"
+
+var (
+	// ErrInvalidCredentials is returned for an unknown username or a wrong password
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrUserExists is returned when creating a user whose username is taken
+	ErrUserExists = errors.New("user already exists")
+
+	// ErrWeakPassword is returned when creating a user with a password shorter than minPasswordLength
+	ErrWeakPassword = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+
+	// usernamePattern keeps usernames usable as Secret keys and in labels
+	usernamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]{0,61}[a-z0-9])?$`)
+
+	// dummyHash is compared against for unknown users, so a login takes as long whether the user exists or not
+	dummyHash, _ = bcrypt.GenerateFromPassword([]byte("homecraft-dummy-password"), bcrypt.DefaultCost)
+)
+
+// minPasswordLength is the shortest password accepted for a local account
+const minPasswordLength = 8
+
+// User is a local account
+type User struct {
+	Username     string `json:"-"`
+	PasswordHash string `json:"passwordHash"`
+	Admin        bool   `json:"admin,omitempty"`
+}
+
+// Identity returns the identity a user is authenticated as
+func (u *User) Identity() *Identity {
+	return &Identity{
+		Subject:  u.Username,
+		Issuer:   LocalIssuer,
+		Username: u.Username,
+		Admin:    u.Admin,
+	}
+}
+
+// LocalAccounts stores local accounts with bcrypt password hashes in a Kubernetes Secret,
+// one key per username holding the JSON encoded User
+type LocalAccounts struct {
+	clientset  kubernetes.Interface
+	namespace  string
+	secretName string
+}
+
+// NewLocalAccounts creates a store for local accounts in the Secret name of namespace
+func NewLocalAccounts(clientset kubernetes.Interface, namespace, name string) *LocalAccounts {
+	if name == "" {
+		name = DefaultUsersSecretName
+	}
+	return &LocalAccounts{clientset: clientset, namespace: namespace, secretName: name}
+}
+
+// Authenticate checks a username and password and returns the user
+func (a *LocalAccounts) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	user, err := a.getUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// CreateUser adds a local account, it fails with ErrUserExists when the username is taken
+func (a *LocalAccounts) CreateUser(ctx context.Context, username, password string, admin bool) (*User, error) {
+	if err := ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if len(password) < minPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user := &User{Username: username, PasswordHash: string(hash), Admin: admin}
+	encoded, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other replicas add users to the same Secret, a conflicting write is retried on the latest version
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return a.addUser(ctx, username, encoded)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// addUser stores an encoded user in the users Secret, creating the Secret with the first user
+func (a *LocalAccounts) addUser(ctx context.Context, username string, encoded []byte) error {
+	secret, err := a.clientset.CoreV1().Secrets(a.namespace).Get(ctx, a.secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      a.secretName,
+				Namespace: a.namespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "homecraft-api"},
+			},
+			Data: map[string][]byte{username: encoded},
+		}
+		_, err = a.clientset.CoreV1().Secrets(a.namespace).Create(ctx, secret, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// Another replica created the Secret first, retry with it like with any other conflict
+			return apierrors.NewConflict(corev1.Resource("secrets"), a.secretName, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create users secret: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get users secret: %w", err)
+	}
+
+	if _, ok := secret.Data[username]; ok {
+		return ErrUserExists
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[username] = encoded
+	if _, err := a.clientset.CoreV1().Secrets(a.namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update users secret: %w", err)
+	}
+	return nil
+}
+
+// EnsureAdmin creates the admin account with password unless a user with that name exists.
+// It reports whether the account was created.
+func (a *LocalAccounts) EnsureAdmin(ctx context.Context, username, password string) (bool, error) {
+	existing, err := a.getUser(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+	if _, err := a.CreateUser(ctx, username, password, true); err != nil {
+		if errors.Is(err, ErrUserExists) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// getUser returns the user with username, or nil when there is none
+func (a *LocalAccounts) getUser(ctx context.Context, username string) (*User, error) {
+	secret, err := a.clientset.CoreV1().Secrets(a.namespace).Get(ctx, a.secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users secret: %w", err)
+	}
+
+	encoded, ok := secret.Data[username]
+	if !ok {
+		return nil, nil
+	}
+	user := &User{}
+	if err := json.Unmarshal(encoded, user); err != nil {
+		return nil, fmt.Errorf("failed to decode user %s: %w", username, err)
+	}
+	user.Username = username
+	return user, nil
+}
+
+// ValidateUsername checks that a username is lowercase alphanumeric with ".", "_" or "-" and at most 63 characters
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("username must be 1-63 lowercase letters, digits, '.', '_' or '-', starting and ending with a letter or digit")
+	}
+	return nil
+}
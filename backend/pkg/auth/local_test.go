@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLocalAccounts(t *testing.T) {
+	ctx := t.Context()
+	clientset := fake.NewClientset()
+	accounts := NewLocalAccounts(clientset, "minecraft-servers", "")
+
+	// The Secret is created with the first user
+	if _, err := accounts.CreateUser(ctx, "steve", "correct-horse", false); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := accounts.CreateUser(ctx, "alex", "battery-staple", true); err != nil {
+		t.Fatalf("CreateUser() second user error = %v", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets("minecraft-servers").Get(ctx, DefaultUsersSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get users secret: %v", err)
+	}
+	if len(secret.Data) != 2 {
+		t.Errorf("users secret has %d keys, want 2", len(secret.Data))
+	}
+	for username, data := range secret.Data {
+		if string(data) == "" || strings.Contains(string(data), "correct-horse") || strings.Contains(string(data), "battery-staple") {
+			t.Errorf("user %s is not stored as a hash: %s", username, data)
+		}
+	}
+
+	user, err := accounts.Authenticate(ctx, "alex", "battery-staple")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	identity := user.Identity()
+	if identity.Username != "alex" || identity.Issuer != LocalIssuer || !identity.Admin {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	if _, err := accounts.Authenticate(ctx, "steve", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate() with wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := accounts.Authenticate(ctx, "herobrine", "correct-horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate() unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := accounts.CreateUser(ctx, "steve", "another-password", false); !errors.Is(err, ErrUserExists) {
+		t.Errorf("CreateUser() duplicate error = %v, want ErrUserExists", err)
+	}
+	if _, err := accounts.CreateUser(ctx, "notch", "short", false); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("CreateUser() short password error = %v, want ErrWeakPassword", err)
+	}
+	if _, err := accounts.CreateUser(ctx, "Not Valid", "long-enough", false); err == nil {
+		t.Error("CreateUser() with invalid username should fail")
+	}
+}
+
+func TestLocalAccounts_CreateUserConflict(t *testing.T) {
+	ctx := t.Context()
+	clientset := fake.NewClientset()
+	accounts := NewLocalAccounts(clientset, "minecraft-servers", "")
+	if _, err := accounts.CreateUser(ctx, "steve", "correct-horse", false); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	// Another replica adds a user between reading and writing the Secret
+	conflicted := false
+	clientset.PrependReactor("update", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		secret := action.(k8stesting.UpdateAction).GetObject().(*corev1.Secret)
+		return true, nil, apierrors.NewConflict(corev1.Resource("secrets"), secret.Name, errors.New("the object has been modified"))
+	})
+
+	if _, err := accounts.CreateUser(ctx, "alex", "battery-staple", false); err != nil {
+		t.Fatalf("CreateUser() after a conflict error = %v", err)
+	}
+	if !conflicted {
+		t.Fatal("Expected the first update to conflict")
+	}
+	for _, username := range []string{"steve", "alex"} {
+		if user, err := accounts.getUser(ctx, username); err != nil || user == nil {
+			t.Errorf("getUser(%s) = %v, %v; want the user to be stored", username, user, err)
+		}
+	}
+}
+
+func TestLocalAccounts_EnsureAdmin(t *testing.T) {
+	ctx := t.Context()
+	accounts := NewLocalAccounts(fake.NewClientset(), "minecraft-servers", "users")
+
+	created, err := accounts.EnsureAdmin(ctx, "admin", "first-password")
+	if err != nil || !created {
+		t.Fatalf("EnsureAdmin() = %v, %v; want the account to be created", created, err)
+	}
+
+	// A restart with another password keeps the existing account
+	created, err = accounts.EnsureAdmin(ctx, "admin", "second-password")
+	if err != nil || created {
+		t.Fatalf("EnsureAdmin() again = %v, %v; want the existing account kept", created, err)
+	}
+	if _, err := accounts.Authenticate(ctx, "admin", "first-password"); err != nil {
+		t.Errorf("Authenticate() with the first password error = %v", err)
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	valid := []string{"steve", "a", "steve.builder", "steve_2", "x-1"}
+	invalid := []string{"", "Steve", "-steve", "steve-", "ste ve", "steve@example.com", string(make([]byte, 64))}
+
+	for _, username := range valid {
+		if err := ValidateUsername(username); err != nil {
+			t.Errorf("ValidateUsername(%q) error = %v", username, err)
+		}
+	}
+	for _, username := range invalid {
+		if err := ValidateUsername(username); err == nil {
+			t.Errorf("ValidateUsername(%q) should fail", username)
+		}
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	store := NewSessionStore(time.Hour)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	identity := &Identity{Subject: "steve", Issuer: LocalIssuer, Username: "steve"}
+	id, err := store.Create(identity)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	other, _ := store.Create(identity)
+	if id == other {
+		t.Error("Create() returned the same session ID twice")
+	}
+
+	if got := store.Get(id); got != identity {
+		t.Errorf("Get() = %v, want the identity", got)
+	}
+
+	store.Delete(other)
+	if store.Get(other) != nil {
+		t.Error("Get() after Delete() should be nil")
+	}
+
+	now = now.Add(time.Hour)
+	if store.Get(id) != nil {
+		t.Error("Get() of an expired session should be nil")
+	}
+}
+
+func TestMiddleware_SessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewSessionStore(time.Hour)
+	sessionID, err := store.Create(&Identity{Subject: "steve", Issuer: LocalIssuer, Username: "steve"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/whoami", Middleware(BearerToken(NewStaticOIDCVerifier(testIssuer, "")), store), func(c *gin.Context) {
+		c.String(http.StatusOK, IdentityFromContext(c).Username)
+	})
+
+	tests := []struct {
+		name       string
+		cookie     string
+		wantStatus int
+	}{
+		{name: "valid session", cookie: sessionID, wantStatus: http.StatusOK},
+		{name: "unknown session", cookie: "forged", wantStatus: http.StatusUnauthorized},
+		{name: "no cookie", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/whoami", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != "steve" {
+				t.Errorf("username = %s, want steve", w.Body.String())
+			}
+		})
+	}
+}
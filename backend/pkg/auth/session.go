@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionCookieName is the cookie a session ID is sent in
+const SessionCookieName = "homecraft_session"
+
+// DefaultSessionTTL is how long a session lasts after login
+const DefaultSessionTTL = 24 * time.Hour
+
+type session struct {
+	identity *Identity
+	expires  time.Time
+}
+
+// SessionStore keeps the sessions of logged in local users in memory.
+// Sessions don't survive a restart and are not shared between replicas.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewSessionStore creates a store whose sessions expire ttl after they are created
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &SessionStore{
+		sessions: map[string]session{},
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// TTL returns how long a session lasts
+func (s *SessionStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// Create starts a session for identity and returns its ID
+func (s *SessionStore) Create(identity *Identity) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeExpired()
+	s.sessions[id] = session{identity: identity, expires: s.now().Add(s.ttl)}
+	return id, nil
+}
+
+// Get returns the identity of a session, or nil when the session is unknown or expired
+func (s *SessionStore) Get(id string) *Identity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	if !s.now().Before(sess.expires) {
+		delete(s.sessions, id)
+		return nil
+	}
+	return sess.identity
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// AuthenticateRequest returns the identity of the session cookie of a request.
+// A request without a session cookie is not authenticated by the store.
+func (s *SessionStore) AuthenticateRequest(r *http.Request) (*Identity, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, errNoCredentials
+	}
+	identity := s.Get(cookie.Value)
+	if identity == nil {
+		return nil, errSessionExpired
+	}
+	return identity, nil
+}
+
+// removeExpired drops expired sessions, the caller holds the lock
+func (s *SessionStore) removeExpired() {
+	now := s.now()
+	for id, sess := range s.sessions {
+		if !now.Before(sess.expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package gateway routes player connections arriving on a single address to the Minecraft servers
+// they are meant for. Every server gets a hostname below the gateway's domain, which clients send
+// in their handshake, so one load balancer IP serves all servers.
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+)
+
+const (
+	// DefaultRefreshInterval is how often the routes are rebuilt from the MinecraftServers
+	DefaultRefreshInterval = 10 * time.Second
+
+	// handshakeTimeout bounds reading the handshake of a client
+	handshakeTimeout = 10 * time.Second
+	// dialTimeout bounds connecting to the game service of a server
+	dialTimeout = 5 * time.Second
+)
+
+// Servers is the part of the Kubernetes client the gateway uses, *k8s.Client implements it
+type Servers interface {
+	ListMinecraftServers(ctx context.Context, namespace string) (*v1alpha1.MinecraftServerList, error)
+}
+
+// Hostname returns the hostname players join a server with through the gateway
+func Hostname(serverName, domain string) string {
+	return serverName + "." + domain
+}
+
+// ServiceAddress returns the in-cluster address of the game service of a server
+func ServiceAddress(namespace, serverName string) string {
+	return fmt.Sprintf("%s-minecraft.%s.svc:%d", serverName, namespace, slp.DefaultPort)
+}
+
+// route is where connections for one hostname go
+type route struct {
+	server     string
+	address    string
+	maxPlayers int
+}
+
+// Gateway proxies player connections to the server named by the hostname in their handshake
+type Gateway struct {
+	Servers   Servers
+	Namespace string
+	// Domain is the parent domain of the server hostnames, e.g. "mc.home.lan" for survival.mc.home.lan
+	Domain string
+
+	// RefreshInterval is how often routes are rebuilt, DefaultRefreshInterval when zero
+	RefreshInterval time.Duration
+	// Upstream returns the address connections for a server are proxied to, ServiceAddress when nil
+	Upstream func(namespace, serverName string) string
+
+	mu     sync.RWMutex
+	routes map[string]route
+}
+
+// Run keeps the routes up to date and accepts connections on listener until ctx is done
+func (g *Gateway) Run(ctx context.Context, listener net.Listener) error {
+	if err := g.Refresh(ctx); err != nil {
+		return err
+	}
+
+	interval := g.RefreshInterval
+	if interval == 0 {
+		interval = DefaultRefreshInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				listener.Close()
+				return
+			case <-ticker.C:
+				// Keep the last routes when the API server is unavailable
+				if err := g.Refresh(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to refresh routes: %v", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go g.handle(ctx, conn)
+	}
+}
+
+// Refresh rebuilds the routes from the MinecraftServers in the gateway's namespace
+func (g *Gateway) Refresh(ctx context.Context) error {
+	servers, err := g.Servers.ListMinecraftServers(ctx, g.Namespace)
+	if err != nil {
+		return err
+	}
+
+	upstream := g.Upstream
+	if upstream == nil {
+		upstream = ServiceAddress
+	}
+	routes := make(map[string]route, len(servers.Items))
+	for _, server := range servers.Items {
+		routes[strings.ToLower(Hostname(server.Name, g.Domain))] = route{
+			server:     server.Name,
+			address:    upstream(g.Namespace, server.Name),
+			maxPlayers: server.Spec.MaxPlayers,
+		}
+	}
+
+	g.mu.Lock()
+	g.routes = routes
+	g.mu.Unlock()
+	return nil
+}
+
+// lookup returns the route for the server address of a handshake
+func (g *Gateway) lookup(serverAddress string) (route, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	r, ok := g.routes[normalizeHost(serverAddress)]
+	return r, ok
+}
+
+// normalizeHost returns the hostname a client asked for. Forge and proxies like BungeeCord
+// append data to the address after a NUL byte, and resolvers may leave a trailing dot.
+func normalizeHost(serverAddress string) string {
+	host, _, _ := strings.Cut(serverAddress, "\x00")
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// handle proxies a connection to the server its handshake names. Players asking for an unknown
+// hostname or a server that can't be reached get an explanation in the server list or on login.
+func (g *Gateway) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+
+	// Probes and port scanners close the connection without a handshake
+	reader := bufio.NewReader(conn)
+	handshake, received, err := slp.ReadHandshake(reader)
+	if err != nil {
+		return
+	}
+
+	r, ok := g.lookup(handshake.ServerAddress)
+	if !ok {
+		refuse(conn, reader, handshake, 0, fmt.Sprintf("There is no server at %s", normalizeHost(handshake.ServerAddress)))
+		return
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	upstream, err := dialer.DialContext(ctx, "tcp", r.address)
+	if err != nil {
+		// A stopped server has no pod behind its service
+		refuse(conn, reader, handshake, r.maxPlayers, "Server is offline")
+		return
+	}
+	defer upstream.Close()
+
+	_ = slp.Splice(conn, reader, upstream, received)
+}
+
+// refuse tells a client why its connection is not proxied, as the MOTD of a status ping or
+// on the disconnect screen of a login
+func refuse(conn net.Conn, reader *bufio.Reader, handshake slp.Handshake, maxPlayers int, message string) {
+	switch handshake.NextState {
+	case slp.StateStatus:
+		_ = slp.AnswerStatus(conn, reader, slp.Status{
+			Version:     slp.Version{Name: "HomeCraft", Protocol: handshake.ProtocolVersion},
+			Players:     slp.Players{Max: maxPlayers},
+			Description: slp.Description{Text: message},
+		})
+	case slp.StateLogin, slp.StateTransfer:
+		_ = slp.WriteLoginDisconnect(conn, message)
+	}
+}
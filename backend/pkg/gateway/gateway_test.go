@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	"github.com/homecraft/backend/pkg/slp/slptest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeServers struct {
+	servers []v1alpha1.MinecraftServer
+}
+
+func (f *fakeServers) ListMinecraftServers(ctx context.Context, namespace string) (*v1alpha1.MinecraftServerList, error) {
+	return &v1alpha1.MinecraftServerList{Items: f.servers}, nil
+}
+
+func minecraftServer(name string) v1alpha1.MinecraftServer {
+	return v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "minecraft-servers"},
+		Spec:       v1alpha1.MinecraftServerSpec{Memory: "2Gi", MaxPlayers: 20},
+	}
+}
+
+// startGateway serves the routes of servers on a local port, upstreams maps server names to the
+// address their game service would have
+func startGateway(t *testing.T, servers []v1alpha1.MinecraftServer, upstreams map[string]string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	g := &Gateway{
+		Servers:   &fakeServers{servers: servers},
+		Namespace: "minecraft-servers",
+		Domain:    "mc.home.lan",
+		Upstream:  func(namespace, name string) string { return upstreams[name] },
+	}
+	go func() { _ = g.Run(ctx, listener) }()
+	return listener.Addr().String()
+}
+
+// connect opens a connection to the gateway with a handshake for host
+func connect(t *testing.T, address, host string, nextState int32) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake := slp.Handshake{ProtocolVersion: 767, ServerAddress: host, ServerPort: 25565, NextState: nextState}
+	if err := slp.WritePacket(conn, slp.PacketHandshake, handshake.Marshal()); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+	return conn, bufio.NewReader(conn)
+}
+
+// motd pings the server at host through the gateway and returns its MOTD
+func motd(t *testing.T, address, host string) string {
+	t.Helper()
+	conn, reader := connect(t, address, host, slp.StateStatus)
+	if err := slp.WritePacket(conn, slp.PacketStatusRequest, nil); err != nil {
+		t.Fatalf("Failed to send status request: %v", err)
+	}
+	id, payload, err := slp.ReadPacket(reader)
+	if err != nil || id != slp.PacketStatusResponse {
+		t.Fatalf("Failed to read status response: id %d, %v", id, err)
+	}
+	response, err := slp.ReadString(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Invalid status response: %v", err)
+	}
+	var status slp.Status
+	if err := json.Unmarshal([]byte(response), &status); err != nil {
+		t.Fatalf("Invalid status JSON: %v", err)
+	}
+	return status.Description.Text
+}
+
+func TestGateway_RoutesByHostname(t *testing.T) {
+	survival := slptest.NewServer(slp.Status{Description: slp.Description{Text: "Survival world"}})
+	defer survival.Close()
+	creative := slptest.NewServer(slp.Status{Description: slp.Description{Text: "Creative world"}})
+	defer creative.Close()
+
+	address := startGateway(t,
+		[]v1alpha1.MinecraftServer{minecraftServer("survival"), minecraftServer("creative"), minecraftServer("stopped")},
+		map[string]string{"survival": survival.Addr, "creative": creative.Addr, "stopped": "127.0.0.1:1"})
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "survival.mc.home.lan", want: "Survival world"},
+		{host: "Creative.MC.home.lan.", want: "Creative world"},
+		{host: "survival.mc.home.lan\x00FML3\x00", want: "Survival world"},
+		{host: "stopped.mc.home.lan", want: "Server is offline"},
+		{host: "missing.mc.home.lan", want: "There is no server at missing.mc.home.lan"},
+		{host: "survival.example.com", want: "There is no server at survival.example.com"},
+	}
+	for _, tt := range tests {
+		if got := motd(t, address, tt.host); got != tt.want {
+			t.Errorf("MOTD of %q = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+
+	// The handshake reaches the server unchanged
+	handshakes := survival.Handshakes()
+	if len(handshakes) != 2 || handshakes[0].ServerAddress != "survival.mc.home.lan" {
+		t.Errorf("survival received handshakes %+v", handshakes)
+	}
+}
+
+func TestGateway_RefusesLoginToUnknownServer(t *testing.T) {
+	address := startGateway(t, nil, nil)
+
+	_, reader := connect(t, address, "missing.mc.home.lan", slp.StateLogin)
+	id, payload, err := slp.ReadPacket(reader)
+	if err != nil || id != slp.PacketLoginDisconnect {
+		t.Fatalf("Expected a disconnect: id %d, %v", id, err)
+	}
+	message, _ := slp.ReadString(bytes.NewReader(payload))
+	if want := `{"text":"There is no server at missing.mc.home.lan"}`; message != want {
+		t.Errorf("disconnect = %s, want %s", message, want)
+	}
+}
+
+func TestGateway_DropsOversizedHandshake(t *testing.T) {
+	address := startGateway(t, nil, nil)
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Only the length prefix of a 2 MiB packet is sent, the gateway must not wait for the rest
+	if err := slp.WriteVarInt(conn, 2097151); err != nil {
+		t.Fatalf("Failed to send length: %v", err)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the gateway to close the connection, got %v", err)
+	}
+}
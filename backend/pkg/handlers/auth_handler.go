@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/models"
+)
+
+// AuthHandler handles logging in with local accounts and who the caller is
+type AuthHandler struct {
+	accounts      *auth.LocalAccounts
+	sessions      *auth.SessionStore
+	secureCookies bool
+}
+
+// NewAuthHandler creates a new AuthHandler. Without accounts only Me is usable.
+// Session cookies are marked Secure unless secureCookies is false, for plain HTTP setups.
+func NewAuthHandler(accounts *auth.LocalAccounts, sessions *auth.SessionStore, secureCookies bool) *AuthHandler {
+	return &AuthHandler{
+		accounts:      accounts,
+		sessions:      sessions,
+		secureCookies: secureCookies,
+	}
+}
+
+// Login handles POST /auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.accounts.Authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "invalid_credentials",
+				Message: "Invalid username or password",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "login_failed",
+			Message: fmt.Sprintf("Failed to check credentials: %v", err),
+		})
+		return
+	}
+
+	identity := user.Identity()
+	sessionID, err := h.sessions.Create(identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "login_failed",
+			Message: fmt.Sprintf("Failed to create session: %v", err),
+		})
+		return
+	}
+
+	h.setSessionCookie(c, sessionID, int(h.sessions.TTL().Seconds()))
+	c.JSON(http.StatusOK, convertIdentityToResponse(identity))
+}
+
+// Logout handles POST /auth/logout
+// Logging out without a session succeeds, so clients can always clear their state.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if sessionID, err := c.Cookie(auth.SessionCookieName); err == nil {
+		h.sessions.Delete(sessionID)
+	}
+	h.setSessionCookie(c, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+// Me handles GET /auth/me
+func (h *AuthHandler) Me(c *gin.Context) {
+	identity := auth.IdentityFromContext(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Not logged in",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, convertIdentityToResponse(identity))
+}
+
+// CreateUser handles POST /auth/users, only admins can add local accounts
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	if identity := auth.IdentityFromContext(c); identity == nil || !identity.Admin {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "forbidden",
+			Message: "Only admins can create users",
+		})
+		return
+	}
+
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := auth.ValidateUsername(req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.accounts.CreateUser(c.Request.Context(), req.Username, req.Password, req.Admin)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserExists) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: fmt.Sprintf("User %s already exists", req.Username),
+			})
+			return
+		}
+		if errors.Is(err, auth.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_request",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "create_failed",
+			Message: fmt.Sprintf("Failed to create user: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertIdentityToResponse(user.Identity()))
+}
+
+// setSessionCookie sets the HTTP-only session cookie, a negative maxAge removes it
+func (h *AuthHandler) setSessionCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(auth.SessionCookieName, value, maxAge, "/", "", h.secureCookies, true)
+}
+
+// convertIdentityToResponse converts an authenticated identity to an API response
+func convertIdentityToResponse(identity *auth.Identity) models.UserResponse {
+	return models.UserResponse{
+		Username: identity.Username,
+		Subject:  identity.Subject,
+		Issuer:   identity.Issuer,
+		Email:    identity.Email,
+		Groups:   identity.Groups,
+		Admin:    identity.Admin,
+	}
+}
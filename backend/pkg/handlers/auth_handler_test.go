@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/models"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestAuthRouter wires the auth endpoints like main.go, with an admin "admin" and a user "steve"
+func newTestAuthRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	accounts := auth.NewLocalAccounts(fake.NewClientset(), MinecraftNamespace, "")
+	if _, err := accounts.EnsureAdmin(t.Context(), "admin", "admin-password"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	if _, err := accounts.CreateUser(t.Context(), "steve", "steve-password", false); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	sessions := auth.NewSessionStore(time.Hour)
+	handler := NewAuthHandler(accounts, sessions, true)
+
+	router := gin.New()
+	router.POST("/api/v1/auth/login", handler.Login)
+	router.POST("/api/v1/auth/logout", handler.Logout)
+	v1 := router.Group("/api/v1")
+	v1.Use(auth.Middleware(sessions))
+	v1.GET("/auth/me", handler.Me)
+	v1.POST("/auth/users", handler.CreateUser)
+	return router
+}
+
+// login logs in and returns the session cookie
+func login(t *testing.T, router *gin.Engine, username, password string) *http.Cookie {
+	t.Helper()
+	body, _ := json.Marshal(models.LoginRequest{Username: username, Password: password})
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login as %s status = %d (%s)", username, w.Code, w.Body.String())
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == auth.SessionCookieName {
+			return cookie
+		}
+	}
+	t.Fatalf("login as %s set no session cookie", username)
+	return nil
+}
+
+func TestAuthHandler_LoginMeLogout(t *testing.T) {
+	router := newTestAuthRouter(t)
+
+	cookie := login(t, router, "steve", "steve-password")
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge != 3600 {
+		t.Errorf("session cookie = %+v, want HttpOnly, Secure, SameSite=Lax for an hour", cookie)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/v1/auth/me", nil)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /auth/me status = %d (%s)", w.Code, w.Body.String())
+	}
+	var me models.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &me); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if me.Username != "steve" || me.Issuer != auth.LocalIssuer || me.Admin {
+		t.Errorf("GET /auth/me = %+v", me)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/auth/logout", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("POST /auth/logout status = %d", w.Code)
+	}
+
+	// The session is gone even if a client keeps the cookie
+	req, _ = http.NewRequest("GET", "/api/v1/auth/me", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /auth/me after logout status = %d, want 401", w.Code)
+	}
+}
+
+func TestAuthHandler_LoginFailures(t *testing.T) {
+	router := newTestAuthRouter(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "wrong password", body: `{"username":"steve","password":"nope"}`, wantStatus: http.StatusUnauthorized, wantError: "invalid_credentials"},
+		{name: "unknown user", body: `{"username":"herobrine","password":"steve-password"}`, wantStatus: http.StatusUnauthorized, wantError: "invalid_credentials"},
+		{name: "missing password", body: `{"username":"steve"}`, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "invalid JSON", body: `{`, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error != tt.wantError {
+				t.Errorf("body = %s, want error %s", w.Body.String(), tt.wantError)
+			}
+			if len(w.Result().Cookies()) != 0 {
+				t.Error("a failed login should not set a cookie")
+			}
+		})
+	}
+}
+
+func TestAuthHandler_CreateUser(t *testing.T) {
+	router := newTestAuthRouter(t)
+	adminCookie := login(t, router, "admin", "admin-password")
+	userCookie := login(t, router, "steve", "steve-password")
+
+	tests := []struct {
+		name       string
+		cookie     *http.Cookie
+		body       string
+		wantStatus int
+	}{
+		{name: "admin creates user", cookie: adminCookie, body: `{"username":"alex","password":"alex-password"}`, wantStatus: http.StatusCreated},
+		{name: "username taken", cookie: adminCookie, body: `{"username":"steve","password":"other-password"}`, wantStatus: http.StatusConflict},
+		{name: "invalid username", cookie: adminCookie, body: `{"username":"Alex!","password":"alex-password"}`, wantStatus: http.StatusBadRequest},
+		{name: "short password", cookie: adminCookie, body: `{"username":"notch","password":"short"}`, wantStatus: http.StatusBadRequest},
+		{name: "non-admin", cookie: userCookie, body: `{"username":"eve","password":"eve-password"}`, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/auth/users", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(tt.cookie)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	login(t, router, "alex", "alex-password")
+}
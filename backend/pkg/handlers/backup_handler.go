@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/metrics"
+	"github.com/homecraft/backend/pkg/models"
+	"github.com/homecraft/backend/pkg/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateBackup handles POST /servers/:name/backups
+func (h *ServerHandler) CreateBackup(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	if h.getAccessibleServer(c, name) == nil {
+		return
+	}
+
+	backup := &v1alpha1.MinecraftBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupName(name, time.Now()),
+			Namespace: MinecraftNamespace,
+			Labels: map[string]string{
+				v1alpha1.ServerNameLabel: name,
+			},
+		},
+		Spec: v1alpha1.MinecraftBackupSpec{
+			ServerName: name,
+		},
+	}
+
+	result, err := h.k8sClient.CreateMinecraftBackup(c.Request.Context(), MinecraftNamespace, backup)
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: "A backup of this server was just started, please retry",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "backup_failed",
+			Message: fmt.Sprintf("Failed to create backup: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertBackupToResponse(result))
+}
+
+// ListBackups handles GET /servers/:name/backups
+// Backups outlive their server, so the backups of a deleted server are still listed, to admins only
+// as there is no owner left to check.
+func (h *ServerHandler) ListBackups(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	server, err := h.k8sClient.GetMinecraftServer(c.Request.Context(), MinecraftNamespace, name)
+	if err != nil {
+		// A server without owner annotations is only accessible to admins
+		server = &v1alpha1.MinecraftServer{}
+	}
+	if !canAccess(auth.IdentityFromContext(c), server) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: fmt.Sprintf("Server not found: server %s not found", name),
+		})
+		return
+	}
+
+	list, err := h.k8sClient.ListMinecraftBackups(c.Request.Context(), MinecraftNamespace, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "list_failed",
+			Message: fmt.Sprintf("Failed to list backups: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": convertBackupsToResponse(list.Items),
+		"count": len(list.Items),
+	})
+}
+
+// RestoreBackup handles POST /servers/:name/backups/:backup/restore
+// It restores a completed backup of the server either in place, which stops the server until its
+// world has been replaced, or into a new server named by targetName that starts with the source
+// server's settings and the restored world.
+func (h *ServerHandler) RestoreBackup(c *gin.Context) {
+	name := c.Param("name")
+	backupParam := c.Param("backup")
+	if name == "" || backupParam == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name and backup name are required",
+		})
+		return
+	}
+
+	// The body is optional, an empty one restores in place
+	var req models.RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	source := h.getAccessibleServer(c, name)
+	if source == nil {
+		return
+	}
+
+	backup, err := h.k8sClient.GetMinecraftBackup(ctx, MinecraftNamespace, backupParam)
+	if err != nil || backup.Spec.ServerName != name {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: fmt.Sprintf("Backup %s of server %s not found", backupParam, name),
+		})
+		return
+	}
+	if backup.Status.Phase != v1alpha1.BackupPhaseCompleted {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "backup_not_ready",
+			Message: fmt.Sprintf("Backup %s is not completed", backup.Name),
+		})
+		return
+	}
+
+	target := name
+	if req.TargetName != "" {
+		target = req.TargetName
+	}
+
+	restore := &v1alpha1.MinecraftRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreName(target, time.Now()),
+			Namespace: MinecraftNamespace,
+			Labels: map[string]string{
+				v1alpha1.ServerNameLabel: target,
+			},
+		},
+		Spec: v1alpha1.MinecraftRestoreSpec{
+			BackupName: backup.Name,
+			ServerName: target,
+		},
+	}
+
+	if target != name && !h.createRestoreTarget(c, source, target, restore.Name) {
+		return
+	}
+
+	result, err := h.k8sClient.CreateMinecraftRestore(ctx, MinecraftNamespace, restore)
+	if err != nil {
+		if target != name {
+			// Don't leave a copy behind that waits for a restore forever
+			_ = h.k8sClient.DeleteMinecraftServer(ctx, MinecraftNamespace, target)
+		}
+		if apierrors.IsAlreadyExists(err) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: "A restore of this server was just started, please retry",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "restore_failed",
+			Message: fmt.Sprintf("Failed to create restore: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, convertRestoreToResponse(result))
+}
+
+// createRestoreTarget creates the server a backup of source is restored into as a copy.
+// The copy is created with the restore annotation already set, so it does not start before its world is restored.
+// It writes the error response and returns false when the copy could not be created.
+func (h *ServerHandler) createRestoreTarget(c *gin.Context, source *v1alpha1.MinecraftServer, target, restoreName string) bool {
+	ctx := c.Request.Context()
+
+	if _, err := h.k8sClient.GetMinecraftServer(ctx, MinecraftNamespace, target); err == nil {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: fmt.Sprintf("Server %s already exists", target),
+		})
+		return false
+	}
+
+	requestedMemory, err := parseMemoryToBytes(source.Spec.Memory)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "invalid_memory",
+			Message: fmt.Sprintf("Failed to parse memory of server %s: %v", source.Name, err),
+		})
+		return false
+	}
+	hasCapacity, message, err := h.k8sClient.CheckMemoryAvailability(ctx, requestedMemory)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "capacity_check_failed",
+			Message: fmt.Sprintf("Failed to check cluster capacity: %v", err),
+		})
+		return false
+	}
+	if !hasCapacity {
+		metrics.CapacityRejections.WithLabelValues("cluster", "memory").Inc()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "insufficient_capacity",
+			Message: message,
+		})
+		return false
+	}
+
+	server := &v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      target,
+			Namespace: MinecraftNamespace,
+			Annotations: map[string]string{
+				v1alpha1.RestoreAnnotation: restoreName,
+			},
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	// A node port can only be used by one service, the cluster picks others for the copy
+	if expose := server.Spec.Expose; expose != nil {
+		if expose.Game != nil {
+			expose.Game.NodePort = 0
+		}
+		if expose.SFTP != nil {
+			expose.SFTP.NodePort = 0
+		}
+	}
+	// The operator generates a new SFTP password for the copy
+	server.Spec.SFTPUsername = utils.SFTPUsername(target)
+	server.Spec.SFTPPassword = ""
+	server.Spec.Paused = false
+	setOwner(server, auth.IdentityFromContext(c))
+
+	if !h.checkQuota(c, "", quotaRequest(1, &server.Spec)) {
+		return false
+	}
+
+	if _, err := h.k8sClient.CreateMinecraftServer(ctx, MinecraftNamespace, server); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: fmt.Sprintf("Server %s already exists", target),
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "creation_failed",
+			Message: fmt.Sprintf("Failed to create server: %v", err),
+		})
+		return false
+	}
+	return true
+}
+
+// backupName returns the name of a backup of a server started at the given time
+func backupName(serverName string, at time.Time) string {
+	return serverName + "-" + at.UTC().Format("20060102-150405")
+}
+
+// restoreName returns the name of a restore into a server started at the given time
+func restoreName(serverName string, at time.Time) string {
+	return serverName + "-restore-" + at.UTC().Format("20060102-150405")
+}
+
+// convertBackupsToResponse converts backups to API responses, newest first
+func convertBackupsToResponse(backups []v1alpha1.MinecraftBackup) []models.BackupResponse {
+	sorted := make([]v1alpha1.MinecraftBackup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[j].CreationTimestamp.Before(&sorted[i].CreationTimestamp)
+	})
+
+	responses := make([]models.BackupResponse, len(sorted))
+	for i := range sorted {
+		responses[i] = convertBackupToResponse(&sorted[i])
+	}
+	return responses
+}
+
+func convertBackupToResponse(backup *v1alpha1.MinecraftBackup) models.BackupResponse {
+	// The operator has not picked up a backup that has no phase yet
+	phase := backup.Status.Phase
+	if phase == "" {
+		phase = v1alpha1.BackupPhasePending
+	}
+
+	completedAt := ""
+	if backup.Status.CompletionTime != nil {
+		completedAt = backup.Status.CompletionTime.Format("2006-01-02T15:04:05Z")
+	}
+
+	return models.BackupResponse{
+		Name:        backup.Name,
+		ServerName:  backup.Spec.ServerName,
+		Phase:       phase,
+		Path:        backup.Status.Path,
+		Size:        backup.Status.Size,
+		Checksum:    backup.Status.Checksum,
+		Message:     backup.Status.Message,
+		CreatedAt:   backup.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+		CompletedAt: completedAt,
+	}
+}
+
+func convertRestoreToResponse(restore *v1alpha1.MinecraftRestore) models.RestoreResponse {
+	phase := restore.Status.Phase
+	if phase == "" {
+		phase = v1alpha1.RestorePhasePending
+	}
+
+	completedAt := ""
+	if restore.Status.CompletionTime != nil {
+		completedAt = restore.Status.CompletionTime.Format("2006-01-02T15:04:05Z")
+	}
+
+	return models.RestoreResponse{
+		Name:        restore.Name,
+		ServerName:  restore.Spec.ServerName,
+		BackupName:  restore.Spec.BackupName,
+		Phase:       phase,
+		Message:     restore.Status.Message,
+		CreatedAt:   restore.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+		CompletedAt: completedAt,
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBackupName(t *testing.T) {
+	at := time.Date(2026, 10, 16, 14, 30, 5, 0, time.FixedZone("CEST", 2*60*60))
+
+	if got := backupName("my-server", at); got != "my-server-20261016-123005" {
+		t.Errorf("backupName() = %s, want my-server-20261016-123005", got)
+	}
+}
+
+func TestConvertBackupsToResponse(t *testing.T) {
+	completedAt := metav1.NewTime(time.Date(2026, 10, 16, 12, 1, 0, 0, time.UTC))
+	backups := []v1alpha1.MinecraftBackup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "my-server-20261016-120000",
+				CreationTimestamp: metav1.NewTime(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)),
+			},
+			Spec: v1alpha1.MinecraftBackupSpec{ServerName: "my-server"},
+			Status: v1alpha1.MinecraftBackupStatus{
+				Phase:          v1alpha1.BackupPhaseCompleted,
+				Path:           "my-server/my-server-20261016-120000.tar.gz",
+				Size:           52428800,
+				Checksum:       "sha256:abc",
+				CompletionTime: &completedAt,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "my-server-20261016-130000",
+				CreationTimestamp: metav1.NewTime(time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)),
+			},
+			Spec: v1alpha1.MinecraftBackupSpec{ServerName: "my-server"},
+		},
+	}
+
+	responses := convertBackupsToResponse(backups)
+
+	if len(responses) != 2 {
+		t.Fatalf("convertBackupsToResponse() returned %d backups, want 2", len(responses))
+	}
+	if responses[0].Name != "my-server-20261016-130000" {
+		t.Errorf("first backup = %s, want the newest one", responses[0].Name)
+	}
+	if responses[0].Phase != "Pending" {
+		t.Errorf("phase of a new backup = %s, want Pending", responses[0].Phase)
+	}
+	if responses[0].CompletedAt != "" {
+		t.Errorf("completedAt of a new backup = %s, want empty", responses[0].CompletedAt)
+	}
+
+	completed := responses[1]
+	if completed.Phase != "Completed" || completed.Size != 52428800 || completed.Checksum != "sha256:abc" {
+		t.Errorf("completed backup = %+v", completed)
+	}
+	if completed.CompletedAt != "2026-10-16T12:01:00Z" {
+		t.Errorf("completedAt = %s, want 2026-10-16T12:01:00Z", completed.CompletedAt)
+	}
+}
+
+func TestRestoreName(t *testing.T) {
+	at := time.Date(2026, 10, 16, 14, 30, 5, 0, time.UTC)
+
+	if got := restoreName("my-server", at); got != "my-server-restore-20261016-143005" {
+		t.Errorf("restoreName() = %s, want my-server-restore-20261016-143005", got)
+	}
+}
+
+func TestRestoreBackup_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := &ServerHandler{}
+	router.POST("/servers/:name/backups/:backup/restore", handler.RestoreBackup)
+
+	req, _ := http.NewRequest("POST", "/servers/my-server/backups/my-server-20261016-120000/restore", strings.NewReader("{invalid"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestCreateRestoreTarget_FixedNodePorts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	source := &v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: MinecraftNamespace},
+		Spec: v1alpha1.MinecraftServerSpec{
+			Memory: "2Gi",
+			Expose: &v1alpha1.ExposeSpec{
+				Game: &v1alpha1.ServiceExposure{Type: v1alpha1.ExposeNodePort, NodePort: 30565},
+				SFTP: &v1alpha1.ServiceExposure{Type: v1alpha1.ExposeNodePort, NodePort: 30022},
+			},
+		},
+	}
+	api := &fakeServerAPI{servers: map[string]*v1alpha1.MinecraftServer{"survival": source}}
+	handler := newTestServerHandler(t, api, "8Gi")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/servers/survival/backups/survival-20261016-120000/restore", nil)
+	if !handler.createRestoreTarget(c, source, "survival-copy", "survival-copy-restore-20261016-120500") {
+		t.Fatalf("createRestoreTarget() failed with %d (%s)", w.Code, w.Body.String())
+	}
+
+	copied := api.servers["survival-copy"]
+	if copied == nil {
+		t.Fatal("Expected the copy to be created")
+	}
+	game, sftp := copied.Spec.Expose.Game, copied.Spec.Expose.SFTP
+	if game.Type != v1alpha1.ExposeNodePort || game.NodePort != 0 || sftp.Type != v1alpha1.ExposeNodePort || sftp.NodePort != 0 {
+		t.Errorf("Expected the copy to keep NodePort services without fixed ports, got game %+v, sftp %+v", game, sftp)
+	}
+	if source.Spec.Expose.Game.NodePort != 30565 || source.Spec.Expose.SFTP.NodePort != 30022 {
+		t.Errorf("Expected the source to keep its node ports, got game %+v, sftp %+v", source.Spec.Expose.Game, source.Spec.Expose.SFTP)
+	}
+}
+
+func TestConvertRestoreToResponse(t *testing.T) {
+	completedAt := metav1.NewTime(time.Date(2026, 10, 16, 13, 2, 0, 0, time.UTC))
+	restore := &v1alpha1.MinecraftRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "my-copy-restore-20261016-130000",
+			CreationTimestamp: metav1.NewTime(time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)),
+		},
+		Spec: v1alpha1.MinecraftRestoreSpec{
+			BackupName: "my-server-20261016-120000",
+			ServerName: "my-copy",
+		},
+	}
+
+	response := convertRestoreToResponse(restore)
+	if response.Phase != "Pending" || response.CompletedAt != "" {
+		t.Errorf("new restore = %+v, want Pending without completedAt", response)
+	}
+
+	restore.Status = v1alpha1.MinecraftRestoreStatus{
+		Phase:          v1alpha1.RestorePhaseCompleted,
+		Message:        "Restored backup my-server-20261016-120000",
+		CompletionTime: &completedAt,
+	}
+	response = convertRestoreToResponse(restore)
+	if response.ServerName != "my-copy" || response.BackupName != "my-server-20261016-120000" {
+		t.Errorf("restore = %+v", response)
+	}
+	if response.Phase != "Completed" || response.CompletedAt != "2026-10-16T13:02:00Z" {
+		t.Errorf("completed restore = %+v", response)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/models"
+)
+
+// setOwner records identity as the owner of a new server.
+// Without an identity, when authentication is disabled, the server has no owner.
+func setOwner(server *v1alpha1.MinecraftServer, identity *auth.Identity) {
+	if identity == nil {
+		return
+	}
+	if server.Annotations == nil {
+		server.Annotations = map[string]string{}
+	}
+	server.Annotations[v1alpha1.OwnerAnnotation] = identity.Username
+	server.Annotations[v1alpha1.OwnerIDAnnotation] = identity.ID()
+}
+
+// canAccess reports whether identity may see and manage server.
+// Admins can access every server, other users only the servers they created. Servers without
+// an owner, such as those created before ownership was recorded, are left to admins.
+// Without an identity authentication is disabled and everything is accessible.
+func canAccess(identity *auth.Identity, server *v1alpha1.MinecraftServer) bool {
+	if identity == nil || identity.Admin {
+		return true
+	}
+	owner, ok := server.Annotations[v1alpha1.OwnerIDAnnotation]
+	return ok && owner == identity.ID()
+}
+
+// getAccessibleServer gets a server the caller can access.
+// It writes a 404 response and returns nil when the server doesn't exist or belongs to someone else,
+// so other users' server names are not revealed.
+func (h *ServerHandler) getAccessibleServer(c *gin.Context, name string) *v1alpha1.MinecraftServer {
+	server, err := h.k8sClient.GetMinecraftServer(c.Request.Context(), MinecraftNamespace, name)
+	if err == nil && !canAccess(auth.IdentityFromContext(c), server) {
+		err = fmt.Errorf("server %s not found", name)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: fmt.Sprintf("Server not found: %v", err),
+		})
+		return nil
+	}
+	return server
+}
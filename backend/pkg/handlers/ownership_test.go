@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCanAccess(t *testing.T) {
+	steve := &auth.Identity{Subject: "steve", Issuer: auth.LocalIssuer, Username: "steve"}
+	oidcSteve := &auth.Identity{Subject: "steve", Issuer: "https://auth.example.com", Username: "steve"}
+	alex := &auth.Identity{Subject: "alex", Issuer: auth.LocalIssuer, Username: "alex"}
+	admin := &auth.Identity{Subject: "admin", Issuer: auth.LocalIssuer, Username: "admin", Admin: true}
+
+	owned := &v1alpha1.MinecraftServer{ObjectMeta: metav1.ObjectMeta{Name: "survival"}}
+	setOwner(owned, steve)
+	if owned.Annotations[v1alpha1.OwnerAnnotation] != "steve" {
+		t.Errorf("owner annotation = %q, want steve", owned.Annotations[v1alpha1.OwnerAnnotation])
+	}
+	unowned := &v1alpha1.MinecraftServer{ObjectMeta: metav1.ObjectMeta{Name: "legacy"}}
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		server   *v1alpha1.MinecraftServer
+		want     bool
+	}{
+		{name: "owner", identity: steve, server: owned, want: true},
+		{name: "other user", identity: alex, server: owned, want: false},
+		{name: "same username from another issuer", identity: oidcSteve, server: owned, want: false},
+		{name: "admin", identity: admin, server: owned, want: true},
+		{name: "server without owner", identity: steve, server: unowned, want: false},
+		{name: "admin and server without owner", identity: admin, server: unowned, want: true},
+		{name: "authentication disabled", identity: nil, server: owned, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAccess(tt.identity, tt.server); got != tt.want {
+				t.Errorf("canAccess() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/models"
+	"github.com/homecraft/backend/pkg/rcon"
+	"github.com/homecraft/backend/pkg/slp"
+)
+
+const (
+	// playerSourceRCON marks a player list read from the output of the list command
+	playerSourceRCON = "rcon"
+	// playerSourcePing marks a player list taken from the sample of a status ping
+	playerSourcePing = "ping"
+
+	// anonymousPlayerID is the UUID servers with hidden player lists put in status samples
+	anonymousPlayerID = "00000000-0000-0000-0000-000000000000"
+)
+
+var (
+	// listOutputPattern matches the output of the list command of vanilla ("2 of a max of 20")
+	// and older Bukkit ("2/20") servers
+	listOutputPattern = regexp.MustCompile(`(?s)^There are (\d+)(?: of a max of |/)(\d+) players online:(.*)$`)
+	// listedPlayerPattern matches a player listed by "list uuids"
+	listedPlayerPattern = regexp.MustCompile(`^(\S+) \(([0-9a-fA-F-]{36})\)$`)
+)
+
+// GetServerPlayers handles GET /servers/:name/players.
+// The list command gives the full list; when the console is not available the sample of a
+// status ping is used, which servers cap at 12 players and may anonymize.
+func (h *ServerHandler) GetServerPlayers(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	if server.Spec.Paused {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "server_stopped",
+			Message: "Server is stopped, nobody is online",
+		})
+		return
+	}
+
+	rconCtx, cancel := context.WithTimeout(c.Request.Context(), rconTimeout)
+	defer cancel()
+
+	response, err := h.listPlayersOverRCON(rconCtx, name)
+	if err != nil {
+		// A console that timed out must not leave the ping without time
+		pingCtx, cancel := context.WithTimeout(c.Request.Context(), pingTimeout)
+		defer cancel()
+
+		var pingErr error
+		response, pingErr = h.listPlayersOverPing(pingCtx, name)
+		if pingErr != nil {
+			c.JSON(http.StatusBadGateway, models.ErrorResponse{
+				Error:   "players_unavailable",
+				Message: fmt.Sprintf("Failed to list players: %v; status ping failed: %v", err, pingErr),
+			})
+			return
+		}
+	}
+
+	response.Name = name
+	c.JSON(http.StatusOK, response)
+}
+
+// listPlayersOverRCON runs "list uuids" on the console of a server
+func (h *ServerHandler) listPlayersOverRCON(ctx context.Context, name string) (models.PlayersResponse, error) {
+	address, password, err := h.k8sClient.GetRCONConnection(ctx, MinecraftNamespace, name)
+	if err != nil {
+		return models.PlayersResponse{}, err
+	}
+
+	rconClient, err := rcon.Dial(ctx, address, password)
+	if err != nil {
+		return models.PlayersResponse{}, err
+	}
+	defer rconClient.Close()
+
+	output, err := rconClient.Command(ctx, "list uuids")
+	if err != nil {
+		return models.PlayersResponse{}, err
+	}
+	return parsePlayerList(output)
+}
+
+// listPlayersOverPing asks a server for its status, like the multiplayer server list does
+func (h *ServerHandler) listPlayersOverPing(ctx context.Context, name string) (models.PlayersResponse, error) {
+	address, err := h.k8sClient.GetGameAddress(ctx, MinecraftNamespace, name)
+	if err != nil {
+		return models.PlayersResponse{}, err
+	}
+	status, err := slp.Ping(ctx, address)
+	if err != nil {
+		return models.PlayersResponse{}, err
+	}
+	return playersFromStatus(status), nil
+}
+
+// parsePlayerList parses the output of the list command, with or without UUIDs
+func parsePlayerList(output string) (models.PlayersResponse, error) {
+	match := listOutputPattern.FindStringSubmatch(strings.TrimSpace(slp.StripFormatting(output)))
+	if match == nil {
+		return models.PlayersResponse{}, fmt.Errorf("unexpected list output %q", output)
+	}
+	online, _ := strconv.Atoi(match[1])
+	maxPlayers, _ := strconv.Atoi(match[2])
+
+	players := []models.Player{}
+	for _, entry := range strings.FieldsFunc(match[3], func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		player := models.Player{Name: entry}
+		if m := listedPlayerPattern.FindStringSubmatch(entry); m != nil {
+			player = models.Player{Name: m[1], UUID: strings.ToLower(m[2])}
+		}
+		players = append(players, player)
+	}
+
+	return models.PlayersResponse{
+		Online:   online,
+		Max:      maxPlayers,
+		Players:  players,
+		Complete: len(players) == online,
+		Source:   playerSourceRCON,
+	}, nil
+}
+
+// playersFromStatus converts the player sample of a status ping, leaving out anonymized players
+func playersFromStatus(status *slp.Status) models.PlayersResponse {
+	players := []models.Player{}
+	for _, sample := range status.Players.Sample {
+		if sample.ID == anonymousPlayerID {
+			continue
+		}
+		players = append(players, models.Player{Name: sample.Name, UUID: sample.ID})
+	}
+
+	return models.PlayersResponse{
+		Online:   status.Players.Online,
+		Max:      status.Players.Max,
+		Players:  players,
+		Complete: len(players) == status.Players.Online,
+		Source:   playerSourcePing,
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/models"
+	"github.com/homecraft/backend/pkg/slp"
+)
+
+func TestParsePlayerList(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    models.PlayersResponse
+		wantErr bool
+	}{
+		{
+			name:   "with uuids",
+			output: "There are 2 of a max of 20 players online: Steve (8667ba71-b85a-4004-af54-457a9734eed7), Alex (EC561538-F3FD-461D-AFF5-086B22154BCE)",
+			want: models.PlayersResponse{
+				Online: 2,
+				Max:    20,
+				Players: []models.Player{
+					{Name: "Steve", UUID: "8667ba71-b85a-4004-af54-457a9734eed7"},
+					{Name: "Alex", UUID: "ec561538-f3fd-461d-aff5-086b22154bce"},
+				},
+				Complete: true,
+				Source:   playerSourceRCON,
+			},
+		},
+		{
+			name:   "nobody online",
+			output: "There are 0 of a max of 20 players online: ",
+			want:   models.PlayersResponse{Online: 0, Max: 20, Players: []models.Player{}, Complete: true, Source: playerSourceRCON},
+		},
+		{
+			name:   "bukkit format",
+			output: "§6There are §c1§6/§c10§6 players online:\n§fSteve",
+			want:   models.PlayersResponse{Online: 1, Max: 10, Players: []models.Player{{Name: "Steve"}}, Complete: true, Source: playerSourceRCON},
+		},
+		{
+			name:    "unknown command",
+			output:  "Unknown or incomplete command, see below for error",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePlayerList(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePlayerList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlayerList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayersFromStatus(t *testing.T) {
+	status := &slp.Status{Players: slp.Players{
+		Online: 14,
+		Max:    50,
+		Sample: []slp.Player{
+			{Name: "Steve", ID: "8667ba71-b85a-4004-af54-457a9734eed7"},
+			{Name: "Anonymous Player", ID: anonymousPlayerID},
+		},
+	}}
+
+	got := playersFromStatus(status)
+	want := models.PlayersResponse{
+		Online:   14,
+		Max:      50,
+		Players:  []models.Player{{Name: "Steve", UUID: "8667ba71-b85a-4004-af54-457a9734eed7"}},
+		Complete: false,
+		Source:   playerSourcePing,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("playersFromStatus() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetServerPlayers_MissingName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/servers//players", nil)
+	handler := &ServerHandler{}
+	handler.GetServerPlayers(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GetServerPlayers() status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+	var response models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error != "invalid_request" {
+		t.Errorf("GetServerPlayers() body = %s, want an invalid_request error", w.Body.String())
+	}
+}
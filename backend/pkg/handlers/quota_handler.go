@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/metrics"
+	"github.com/homecraft/backend/pkg/models"
+	"github.com/homecraft/backend/pkg/quota"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// GetMyQuota handles GET /quotas/me
+func (h *ServerHandler) GetMyQuota(c *gin.Context) {
+	identity := auth.IdentityFromContext(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Quotas apply to logged in users",
+		})
+		return
+	}
+
+	list, err := h.k8sClient.ListMinecraftServers(c.Request.Context(), MinecraftNamespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "list_failed",
+			Message: fmt.Sprintf("Failed to list servers: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertQuotaToResponse(identity, h.limitsFor(identity), quotaUsage(list.Items, identity, "")))
+}
+
+// checkQuota checks that req fits in the caller's quota, on top of the servers they own other than exclude.
+// It writes the error response and returns false when it doesn't.
+func (h *ServerHandler) checkQuota(c *gin.Context, exclude string, req quota.Request) bool {
+	identity := auth.IdentityFromContext(c)
+	if identity == nil || identity.Admin || h.quotas == nil {
+		return true
+	}
+
+	list, err := h.k8sClient.ListMinecraftServers(c.Request.Context(), MinecraftNamespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "quota_check_failed",
+			Message: fmt.Sprintf("Failed to check quota: %v", err),
+		})
+		return false
+	}
+
+	err = h.limitsFor(identity).Check(quotaUsage(list.Items, identity, exclude), req)
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		metrics.CapacityRejections.WithLabelValues("quota", exceeded.Resource).Inc()
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "quota_exceeded",
+			Message: exceeded.Message,
+		})
+		return false
+	}
+	return true
+}
+
+// limitsFor returns the quota of identity, admins are not limited
+func (h *ServerHandler) limitsFor(identity *auth.Identity) quota.Limits {
+	if identity == nil || identity.Admin {
+		return quota.Limits{}
+	}
+	return h.quotas.LimitsFor(identity.Username, identity.Groups)
+}
+
+// quotaRequest returns what a server with spec takes from a quota
+func quotaRequest(servers int, spec *v1alpha1.MinecraftServerSpec) quota.Request {
+	req := quota.Request{Servers: servers, MaxPlayers: spec.MaxPlayers}
+	// Both are validated before they are stored, an unparsable value counts as zero
+	if memory, err := resource.ParseQuantity(spec.Memory); err == nil {
+		req.Memory = memory
+	}
+	if storage, err := resource.ParseQuantity(spec.StorageSize); err == nil {
+		req.Storage = storage
+	}
+	return req
+}
+
+// quotaUsage sums up the servers identity owns, except the one named exclude
+func quotaUsage(servers []v1alpha1.MinecraftServer, identity *auth.Identity, exclude string) quota.Usage {
+	var usage quota.Usage
+	for i := range servers {
+		server := &servers[i]
+		if server.Name == exclude || server.Annotations[v1alpha1.OwnerIDAnnotation] != identity.ID() {
+			continue
+		}
+		req := quotaRequest(1, &server.Spec)
+		usage.Servers++
+		usage.Memory.Add(req.Memory)
+		usage.Storage.Add(req.Storage)
+	}
+	return usage
+}
+
+// convertQuotaToResponse converts the quota and usage of a user to an API response
+func convertQuotaToResponse(identity *auth.Identity, limits quota.Limits, usage quota.Usage) models.QuotaResponse {
+	response := models.QuotaResponse{
+		Username: identity.Username,
+		Limits: models.QuotaLimits{
+			MaxServers:          limits.MaxServers,
+			MaxPlayersPerServer: limits.MaxPlayersPerServer,
+		},
+		Usage: models.QuotaUsage{
+			Servers: usage.Servers,
+			Memory:  usage.Memory.String(),
+			Storage: usage.Storage.String(),
+		},
+	}
+	if limits.MaxMemory != nil {
+		response.Limits.MaxMemory = limits.MaxMemory.String()
+	}
+	if limits.MaxStorage != nil {
+		response.Limits.MaxStorage = limits.MaxStorage.String()
+	}
+	return response
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/quota"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaUsage(t *testing.T) {
+	steve := &auth.Identity{Subject: "steve", Issuer: auth.LocalIssuer, Username: "steve"}
+	alex := &auth.Identity{Subject: "alex", Issuer: auth.LocalIssuer, Username: "alex"}
+
+	newServer := func(name string, owner *auth.Identity, memory, storage string) v1alpha1.MinecraftServer {
+		server := v1alpha1.MinecraftServer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1alpha1.MinecraftServerSpec{Memory: memory, StorageSize: storage},
+		}
+		setOwner(&server, owner)
+		return server
+	}
+	servers := []v1alpha1.MinecraftServer{
+		newServer("survival", steve, "2Gi", "5Gi"),
+		newServer("creative", steve, "1Gi", "1Gi"),
+		newServer("skyblock", alex, "4Gi", "10Gi"),
+		{ObjectMeta: metav1.ObjectMeta{Name: "legacy"}, Spec: v1alpha1.MinecraftServerSpec{Memory: "8Gi"}},
+	}
+
+	usage := quotaUsage(servers, steve, "")
+	if usage.Servers != 2 || usage.Memory.Cmp(resource.MustParse("3Gi")) != 0 || usage.Storage.Cmp(resource.MustParse("6Gi")) != 0 {
+		t.Errorf("quotaUsage() = %d servers, %s memory, %s storage; want 2, 3Gi, 6Gi", usage.Servers, usage.Memory.String(), usage.Storage.String())
+	}
+
+	usage = quotaUsage(servers, steve, "survival")
+	if usage.Servers != 1 || usage.Memory.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("quotaUsage() excluding survival = %d servers, %s memory; want 1, 1Gi", usage.Servers, usage.Memory.String())
+	}
+}
+
+func TestConvertQuotaToResponse(t *testing.T) {
+	maxServers := 3
+	maxMemory := resource.MustParse("8Gi")
+	identity := &auth.Identity{Subject: "steve", Issuer: auth.LocalIssuer, Username: "steve"}
+	usage := quota.Usage{Servers: 1, Memory: resource.MustParse("2Gi")}
+
+	response := convertQuotaToResponse(identity, quota.Limits{MaxServers: &maxServers, MaxMemory: &maxMemory}, usage)
+
+	if response.Username != "steve" || response.Limits.MaxServers == nil || *response.Limits.MaxServers != 3 {
+		t.Errorf("response = %+v", response)
+	}
+	if response.Limits.MaxMemory != "8Gi" || response.Limits.MaxStorage != "" || response.Limits.MaxPlayersPerServer != nil {
+		t.Errorf("limits = %+v, want only 3 servers and 8Gi memory", response.Limits)
+	}
+	if response.Usage.Servers != 1 || response.Usage.Memory != "2Gi" || response.Usage.Storage != "0" {
+		t.Errorf("usage = %+v", response.Usage)
+	}
+}
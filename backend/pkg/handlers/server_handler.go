@@ -1,34 +1,70 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"crypto/rsa"
 	"fmt"
+	"io"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
 	"github.com/homecraft/backend/pkg/k8s"
+	"github.com/homecraft/backend/pkg/metrics"
 	"github.com/homecraft/backend/pkg/models"
+	"github.com/homecraft/backend/pkg/quota"
+	"github.com/homecraft/backend/pkg/rcon"
 	"github.com/homecraft/backend/pkg/utils"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 const (
 	// MinecraftNamespace is the dedicated namespace for all Minecraft servers
 	MinecraftNamespace = "minecraft-servers"
+
+	// rconTimeout bounds connecting to a server console and running one command
+	rconTimeout = 10 * time.Second
+	// pingTimeout bounds a status ping asking a server who is online when its console isn't available
+	pingTimeout = 5 * time.Second
+
+	// defaultLogTail is the number of log lines returned when neither tail nor since is requested
+	defaultLogTail = 100
+	// maxLogTail caps the number of log lines a single request can fetch
+	maxLogTail = 10000
+	// maxLogLineLength is the longest log line that is passed through, longer lines end the stream
+	maxLogLineLength = 1024 * 1024
+
+	// watchKeepAlive is how often an idle event stream sends a comment to keep proxies from closing it
+	watchKeepAlive = 30 * time.Second
 )
 
+// serverContainers are the containers of a server pod whose logs can be read
+var serverContainers = map[string]bool{"minecraft": true, "sftp": true}
+
 // ServerHandler handles HTTP requests for Minecraft servers
 type ServerHandler struct {
 	k8sClient *k8s.Client
+	quotas    *quota.Config
 }
 
-// NewServerHandler creates a new ServerHandler
-func NewServerHandler(k8sClient *k8s.Client) *ServerHandler {
+// NewServerHandler creates a new ServerHandler. Without quotas users are only limited by the cluster capacity.
+func NewServerHandler(k8sClient *k8s.Client, quotas *quota.Config) *ServerHandler {
 	return &ServerHandler{
 		k8sClient: k8sClient,
+		quotas:    quotas,
 	}
 }
 
@@ -60,6 +96,42 @@ func (h *ServerHandler) CreateServer(c *gin.Context) {
 		return
 	}
 
+	if req.BackupSchedule != nil && req.BackupSchedule.Schedule != "" {
+		if err := validateBackupSchedule(req.BackupSchedule); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+	if err := validateIdleShutdown(req.IdleShutdown); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := validateExpose(req.Expose); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	authorizedKeys, err := normalizeAuthorizedKeys(req.AuthorizedKeys)
+	if err == nil {
+		err = validateSFTPLogin(req.DisableSFTPPassword, authorizedKeys)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Parse requested memory to bytes for capacity check
 	requestedMemory, err := parseMemoryToBytes(req.Memory)
 	if err != nil {
@@ -81,6 +153,7 @@ func (h *ServerHandler) CreateServer(c *gin.Context) {
 	}
 
 	if !hasCapacity {
+		metrics.CapacityRejections.WithLabelValues("cluster", "memory").Inc()
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "insufficient_capacity",
 			Message: message,
@@ -88,16 +161,6 @@ func (h *ServerHandler) CreateServer(c *gin.Context) {
 		return
 	}
 
-	// Generate SFTP credentials
-	sftpUsername, sftpPassword, err := utils.GenerateSFTPCredentials(req.Name)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "credential_generation_failed",
-			Message: fmt.Sprintf("Failed to generate SFTP credentials: %v", err),
-		})
-		return
-	}
-
 	// Set defaults
 	if req.StorageSize == "" {
 		req.StorageSize = "1Gi"
@@ -125,19 +188,29 @@ func (h *ServerHandler) CreateServer(c *gin.Context) {
 			Namespace: MinecraftNamespace,
 		},
 		Spec: v1alpha1.MinecraftServerSpec{
-			EULA:           req.EULA,
-			SFTPUsername:   sftpUsername,
-			SFTPPassword:   sftpPassword,
-			Memory:         req.Memory,
-			StorageSize:    req.StorageSize,
-			Version:        req.Version,
-			ServerType:     req.ServerType,
-			MaxPlayers:     req.MaxPlayers,
-			Difficulty:     req.Difficulty,
-			Gamemode:       req.Gamemode,
-			PublicEndpoint: req.PublicEndpoint,
+			EULA:                req.EULA,
+			SFTPUsername:        utils.SFTPUsername(req.Name),
+			Memory:              req.Memory,
+			StorageSize:         req.StorageSize,
+			Version:             req.Version,
+			ServerType:          req.ServerType,
+			MaxPlayers:          req.MaxPlayers,
+			Difficulty:          req.Difficulty,
+			Gamemode:            req.Gamemode,
+			PublicEndpoint:      req.PublicEndpoint,
+			PublicTunnel:        req.PublicTunnel,
+			BackupSchedule:      backupScheduleToSpec(req.BackupSchedule),
+			IdleShutdown:        idleShutdownToSpec(req.IdleShutdown),
+			Expose:              exposeToSpec(req.Expose),
+			AuthorizedKeys:      authorizedKeys,
+			DisableSFTPPassword: req.DisableSFTPPassword,
 		},
 	}
+	setOwner(server, auth.IdentityFromContext(c))
+
+	if !h.checkQuota(c, "", quotaRequest(1, &server.Spec)) {
+		return
+	}
 
 	result, err := h.k8sClient.CreateMinecraftServer(c.Request.Context(), MinecraftNamespace, server)
 	if err != nil {
@@ -162,9 +235,12 @@ func (h *ServerHandler) ListServers(c *gin.Context) {
 		return
 	}
 
-	responses := make([]models.ServerResponse, len(list.Items))
-	for i, item := range list.Items {
-		responses[i] = convertToResponse(&item)
+	identity := auth.IdentityFromContext(c)
+	responses := make([]models.ServerResponse, 0, len(list.Items))
+	for i := range list.Items {
+		if canAccess(identity, &list.Items[i]) {
+			responses = append(responses, convertToResponse(&list.Items[i]))
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -173,6 +249,79 @@ func (h *ServerHandler) ListServers(c *gin.Context) {
 	})
 }
 
+// WatchServers handles GET /servers/watch
+// It streams ADDED, MODIFIED and DELETED events as Server-Sent Events. Every event carries the
+// resourceVersion it was sent at as its id, so a reconnecting client resumes where it left off by
+// passing it as the resourceVersion query parameter or the Last-Event-ID header.
+// Without a resourceVersion the stream starts with an ADDED event for every existing server.
+// Only servers the caller can access are streamed.
+func (h *ServerHandler) WatchServers(c *gin.Context) {
+	ctx := c.Request.Context()
+	identity := auth.IdentityFromContext(c)
+
+	resourceVersion := c.Query("resourceVersion")
+	if resourceVersion == "" {
+		resourceVersion = c.GetHeader("Last-Event-ID")
+	}
+
+	var snapshot []v1alpha1.MinecraftServer
+	if resourceVersion == "" {
+		list, err := h.k8sClient.ListMinecraftServers(ctx, MinecraftNamespace)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "list_failed",
+				Message: fmt.Sprintf("Failed to list servers: %v", err),
+			})
+			return
+		}
+		snapshot = list.Items
+		resourceVersion = list.ResourceVersion
+	}
+
+	watcher, err := h.k8sClient.WatchMinecraftServers(ctx, MinecraftNamespace, resourceVersion)
+	if err != nil {
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			c.JSON(http.StatusGone, models.ErrorResponse{
+				Error:   "resource_expired",
+				Message: "The resourceVersion is too old, reconnect without it to receive the current state",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "watch_failed",
+			Message: fmt.Sprintf("Failed to watch servers: %v", err),
+		})
+		return
+	}
+
+	startEventStream(c)
+
+	for i := range snapshot {
+		if canAccess(identity, &snapshot[i]) {
+			writeServerEvent(c, watch.Added, &snapshot[i], resourceVersion)
+		}
+	}
+	c.Writer.Flush()
+
+	// The API server ends watches after a few minutes, they are picked up again from the last event
+	for {
+		var done bool
+		resourceVersion, done = streamServerEvents(c, watcher, resourceVersion, identity)
+		watcher.Stop()
+		if done {
+			return
+		}
+
+		watcher, err = h.k8sClient.WatchMinecraftServers(ctx, MinecraftNamespace, resourceVersion)
+		if err != nil {
+			if ctx.Err() == nil {
+				writeWatchError(c, err)
+			}
+			return
+		}
+	}
+}
+
 // GetServer handles GET /servers/:name
 func (h *ServerHandler) GetServer(c *gin.Context) {
 	name := c.Param("name")
@@ -184,16 +333,194 @@ func (h *ServerHandler) GetServer(c *gin.Context) {
 		return
 	}
 
-	server, err := h.k8sClient.GetMinecraftServer(c.Request.Context(), MinecraftNamespace, name)
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, convertToResponse(server))
+}
+
+// UpdateServer handles PATCH /servers/:name
+func (h *ServerHandler) UpdateServer(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	var req models.UpdateServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Validate memory format
+	if req.Memory != nil && !isValidMemoryFormat(*req.Memory) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_memory",
+			Message: "Memory must be in format like '2Gi', '4Gi', '512Mi'",
+		})
+		return
+	}
+
+	if err := validateUpdateRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	// Only the additional memory needs to fit in the cluster, the current allocation is already in use.
+	// A stopped server holds no memory, its new size is checked when it is started again.
+	if req.Memory != nil && !server.Spec.Paused {
+		// An unparsable current value counts as zero so the full request is checked
+		currentMemory, _ := parseMemoryToBytes(server.Spec.Memory)
+		requestedMemory, err := parseMemoryToBytes(*req.Memory)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_memory",
+				Message: fmt.Sprintf("Failed to parse memory: %v", err),
+			})
+			return
+		}
+
+		if requestedMemory > currentMemory {
+			hasCapacity, message, err := h.k8sClient.CheckMemoryAvailability(c.Request.Context(), requestedMemory-currentMemory)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "capacity_check_failed",
+					Message: fmt.Sprintf("Failed to check cluster capacity: %v", err),
+				})
+				return
+			}
+
+			if !hasCapacity {
+				metrics.CapacityRejections.WithLabelValues("cluster", "memory").Inc()
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "insufficient_capacity",
+					Message: message,
+				})
+				return
+			}
+		}
+	}
+
+	applyUpdateRequest(&server.Spec, &req)
+
+	if err := validateSFTPLogin(server.Spec.DisableSFTPPassword, server.Spec.AuthorizedKeys); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The server's new memory replaces its old one in the quota
+	quotaReq := quota.Request{}
+	exclude := ""
+	if req.Memory != nil {
+		quotaReq.Memory = quotaRequest(0, &server.Spec).Memory
+		exclude = name
+	}
+	if req.MaxPlayers != nil {
+		quotaReq.MaxPlayers = *req.MaxPlayers
+	}
+	if !h.checkQuota(c, exclude, quotaReq) {
+		return
+	}
+
+	h.saveServer(c, server)
+}
+
+// StopServer handles POST /servers/:name/stop
+func (h *ServerHandler) StopServer(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	if server.Spec.Paused {
+		c.JSON(http.StatusOK, convertToResponse(server))
+		return
+	}
+
+	server.Spec.Paused = true
+	h.saveServer(c, server)
+}
+
+// StartServer handles POST /servers/:name/start
+func (h *ServerHandler) StartServer(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	if !server.Spec.Paused {
+		c.JSON(http.StatusOK, convertToResponse(server))
+		return
+	}
+
+	// The memory of a stopped server was released, so it has to fit in the cluster again
+	requestedMemory, err := parseMemoryToBytes(server.Spec.Memory)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "not_found",
-			Message: fmt.Sprintf("Server not found: %v", err),
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "invalid_memory",
+			Message: fmt.Sprintf("Failed to parse server memory: %v", err),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, convertToResponse(server))
+	hasCapacity, message, err := h.k8sClient.CheckMemoryAvailability(c.Request.Context(), requestedMemory)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "capacity_check_failed",
+			Message: fmt.Sprintf("Failed to check cluster capacity: %v", err),
+		})
+		return
+	}
+
+	if !hasCapacity {
+		metrics.CapacityRejections.WithLabelValues("cluster", "memory").Inc()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "insufficient_capacity",
+			Message: message,
+		})
+		return
+	}
+
+	server.Spec.Paused = false
+	h.saveServer(c, server)
 }
 
 // DeleteServer handles DELETE /servers/:name
@@ -207,6 +534,10 @@ func (h *ServerHandler) DeleteServer(c *gin.Context) {
 		return
 	}
 
+	if h.getAccessibleServer(c, name) == nil {
+		return
+	}
+
 	err := h.k8sClient.DeleteMinecraftServer(c.Request.Context(), MinecraftNamespace, name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -222,6 +553,235 @@ func (h *ServerHandler) DeleteServer(c *gin.Context) {
 	})
 }
 
+// ExecuteCommand handles POST /servers/:name/command
+func (h *ServerHandler) ExecuteCommand(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	var req models.CommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The console takes commands without the chat slash
+	command := strings.TrimPrefix(strings.TrimSpace(req.Command), "/")
+	if command == "" || len(command) > rcon.MaxCommandLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_command",
+			Message: fmt.Sprintf("Command must be between 1 and %d characters", rcon.MaxCommandLength),
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	if server.Spec.Paused {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "server_stopped",
+			Message: "Server is stopped, start it before sending commands",
+		})
+		return
+	}
+
+	address, password, err := h.k8sClient.GetRCONConnection(c.Request.Context(), MinecraftNamespace, name)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "rcon_unavailable",
+			Message: fmt.Sprintf("Server console is not available: %v", err),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), rconTimeout)
+	defer cancel()
+
+	rconClient, err := rcon.Dial(ctx, address, password)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "rcon_failed",
+			Message: fmt.Sprintf("Failed to connect to server console: %v", err),
+		})
+		return
+	}
+	defer rconClient.Close()
+
+	output, err := rconClient.Command(ctx, command)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "rcon_failed",
+			Message: fmt.Sprintf("Failed to run command: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CommandResponse{
+		Command: command,
+		Output:  output,
+	})
+}
+
+// GetServerLogs handles GET /servers/:name/logs
+func (h *ServerHandler) GetServerLogs(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	logOptions, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	if server.Spec.Paused {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "server_stopped",
+			Message: "Server is stopped, start it to read its logs",
+		})
+		return
+	}
+
+	logs, err := h.k8sClient.GetClientset().CoreV1().Pods(MinecraftNamespace).
+		GetLogs(k8s.ServerPodName(name), logOptions).
+		Stream(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "logs_unavailable",
+			Message: fmt.Sprintf("Server logs are not available: %v", err),
+		})
+		return
+	}
+	defer logs.Close()
+
+	if logOptions.Follow {
+		streamLogLines(c, logs)
+		return
+	}
+
+	lines, truncated, err := readLogLines(logs, maxLogTail)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "logs_failed",
+			Message: fmt.Sprintf("Failed to read server logs: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LogsResponse{
+		Name:      name,
+		Container: logOptions.Container,
+		Lines:     lines,
+		Truncated: truncated,
+	})
+}
+
+// GetServerCredentials handles GET /servers/:name/credentials
+func (h *ServerHandler) GetServerCredentials(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	username, password, err := h.k8sClient.GetSFTPCredentials(c.Request.Context(), MinecraftNamespace, name)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "credentials_not_ready",
+			Message: fmt.Sprintf("Server credentials are not available yet: %v", err),
+		})
+		return
+	}
+
+	response := models.CredentialsResponse{
+		Name:         name,
+		SFTPEndpoint: server.Status.SFTPEndpoint,
+		SFTPUsername: username,
+		SFTPPassword: password,
+	}
+	if server.Status.SFTPPasswordRotatedAt != nil {
+		response.SFTPRotatedAt = server.Status.SFTPPasswordRotatedAt.Format("2006-01-02T15:04:05Z")
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RotateSFTPCredentials handles POST /servers/:name/sftp/rotate
+func (h *ServerHandler) RotateSFTPCredentials(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Server name is required",
+		})
+		return
+	}
+
+	server := h.getAccessibleServer(c, name)
+	if server == nil {
+		return
+	}
+
+	// The username stays, it names the SFTP home directory the world is mounted in
+	password, err := utils.GenerateSFTPPassword()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "credential_generation_failed",
+			Message: fmt.Sprintf("Failed to generate SFTP password: %v", err),
+		})
+		return
+	}
+
+	rotatedAt := time.Now().UTC()
+	username, err := h.k8sClient.RotateSFTPPassword(c.Request.Context(), MinecraftNamespace, name, password, rotatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "rotation_failed",
+			Message: fmt.Sprintf("Failed to rotate SFTP password: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CredentialsResponse{
+		Name:          name,
+		SFTPEndpoint:  server.Status.SFTPEndpoint,
+		SFTPUsername:  username,
+		SFTPPassword:  password,
+		SFTPRotatedAt: rotatedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
+
 // GetClusterResources handles GET /cluster/resources
 func (h *ServerHandler) GetClusterResources(c *gin.Context) {
 	total, allocated, available, err := h.k8sClient.GetClusterMemoryResources(c.Request.Context())
@@ -275,6 +835,198 @@ func (h *ServerHandler) GetClusterResources(c *gin.Context) {
 
 // Helper functions
 
+// saveServer writes a modified server back to the cluster and responds with its new state
+func (h *ServerHandler) saveServer(c *gin.Context, server *v1alpha1.MinecraftServer) {
+	result, err := h.k8sClient.UpdateMinecraftServer(c.Request.Context(), MinecraftNamespace, server)
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: "Server was modified concurrently, please retry",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "update_failed",
+			Message: fmt.Sprintf("Failed to update server: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertToResponse(result))
+}
+
+// parseLogOptions reads the tail, since, container and follow query parameters of a log request
+func parseLogOptions(c *gin.Context) (*corev1.PodLogOptions, error) {
+	options := &corev1.PodLogOptions{
+		Container: c.DefaultQuery("container", "minecraft"),
+	}
+	if !serverContainers[options.Container] {
+		return nil, fmt.Errorf("container must be one of minecraft, sftp")
+	}
+
+	if value := c.Query("tail"); value != "" {
+		tail, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || tail < 1 || tail > maxLogTail {
+			return nil, fmt.Errorf("tail must be a number between 1 and %d", maxLogTail)
+		}
+		options.TailLines = &tail
+	}
+
+	if value := c.Query("since"); value != "" {
+		since, err := time.ParseDuration(value)
+		if err != nil || since < time.Second {
+			return nil, fmt.Errorf("since must be a duration of at least 1s, like '10m' or '2h'")
+		}
+		sinceSeconds := int64(since / time.Second)
+		options.SinceSeconds = &sinceSeconds
+	}
+
+	// A since window alone returns all of its lines
+	if options.TailLines == nil && options.SinceSeconds == nil {
+		tail := int64(defaultLogTail)
+		options.TailLines = &tail
+	}
+
+	if value := c.Query("follow"); value != "" {
+		follow, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("follow must be true or false")
+		}
+		options.Follow = follow
+	}
+
+	return options, nil
+}
+
+// readLogLines reads up to maxLines lines of logs. A since window without a tail can hold any
+// number of lines, the ones after maxLines are dropped and truncated is set.
+func readLogLines(logs io.Reader, maxLines int) (lines []string, truncated bool, err error) {
+	lines = []string{}
+	scanner := newLogScanner(logs)
+	for scanner.Scan() {
+		if len(lines) == maxLines {
+			return lines, true, nil
+		}
+		lines = append(lines, scanner.Text())
+	}
+	return lines, false, scanner.Err()
+}
+
+// newLogScanner splits container logs into lines
+func newLogScanner(logs io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineLength)
+	return scanner
+}
+
+// startEventStream sends the headers of a Server-Sent Events response
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep reverse proxies from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// streamLogLines sends every log line as a Server-Sent Event until the logs end or the client goes away.
+// The stream ends with an "end" event, or an "error" event if reading the logs failed.
+func streamLogLines(c *gin.Context, logs io.Reader) {
+	startEventStream(c)
+
+	scanner := newLogScanner(logs)
+	for scanner.Scan() {
+		c.SSEvent("log", scanner.Text())
+		c.Writer.Flush()
+	}
+
+	// A client that went away also ends the log stream, there is nobody left to tell
+	if c.Request.Context().Err() != nil {
+		return
+	}
+	if err := scanner.Err(); err != nil {
+		c.SSEvent("error", err.Error())
+	} else {
+		c.SSEvent("end", "")
+	}
+	c.Writer.Flush()
+}
+
+// streamServerEvents forwards the events of a watch until it closes, the client goes away or the watch fails.
+// It returns the resourceVersion of the last event sent and whether the stream is finished. A watch that
+// closed without an error is not finished and can be resumed from the returned resourceVersion.
+// Events of servers identity can't access are skipped.
+func streamServerEvents(c *gin.Context, watcher watch.Interface, resourceVersion string, identity *auth.Identity) (string, bool) {
+	keepAlive := time.NewTicker(watchKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return resourceVersion, true
+
+		case <-keepAlive.C:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion, false
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				server, isServer := event.Object.(*v1alpha1.MinecraftServer)
+				if !isServer {
+					continue
+				}
+				resourceVersion = server.ResourceVersion
+				if !canAccess(identity, server) {
+					continue
+				}
+				writeServerEvent(c, event.Type, server, resourceVersion)
+				c.Writer.Flush()
+
+			case watch.Error:
+				writeWatchError(c, apierrors.FromObject(event.Object))
+				return resourceVersion, true
+			}
+		}
+	}
+}
+
+// writeServerEvent sends a server change as a Server-Sent Event named after the watch event type
+func writeServerEvent(c *gin.Context, eventType watch.EventType, server *v1alpha1.MinecraftServer, resourceVersion string) {
+	c.Render(-1, sse.Event{
+		Id:    resourceVersion,
+		Event: string(eventType),
+		Data:  convertToResponse(server),
+	})
+}
+
+// writeWatchError sends the final ERROR event of a watch stream.
+// An expired resourceVersion is reported as resource_expired, the client then reconnects without one.
+func writeWatchError(c *gin.Context, err error) {
+	response := models.ErrorResponse{
+		Error:   "watch_failed",
+		Message: fmt.Sprintf("Failed to watch servers: %v", err),
+	}
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+		response = models.ErrorResponse{
+			Error:   "resource_expired",
+			Message: "The resourceVersion is too old, reconnect without it to receive the current state",
+		}
+	}
+
+	c.Render(-1, sse.Event{
+		Event: string(watch.Error),
+		Data:  response,
+	})
+	c.Writer.Flush()
+}
+
 func convertToResponse(server *v1alpha1.MinecraftServer) models.ServerResponse {
 	// Use publicEndpoint from status if available, otherwise fall back to spec
 	publicEndpoint := server.Status.PublicEndpoint
@@ -282,25 +1034,337 @@ func convertToResponse(server *v1alpha1.MinecraftServer) models.ServerResponse {
 		publicEndpoint = server.Spec.PublicEndpoint
 	}
 
+	var backupSchedule *models.BackupSchedule
+	if schedule := server.Spec.BackupSchedule; schedule != nil {
+		backupSchedule = &models.BackupSchedule{
+			Schedule:   schedule.Schedule,
+			KeepLast:   schedule.KeepLast,
+			KeepDaily:  schedule.KeepDaily,
+			KeepWeekly: schedule.KeepWeekly,
+		}
+	}
+
+	var idleShutdown *models.IdleShutdown
+	if policy := server.Spec.IdleShutdown; policy != nil {
+		idleShutdown = &models.IdleShutdown{TimeoutMinutes: policy.TimeoutMinutes, WakeOnConnect: policy.WakeOnConnect}
+	}
+	var expose *models.Expose
+	if spec := server.Spec.Expose; spec != nil {
+		expose = &models.Expose{Game: serviceExposureFromSpec(spec.Game), SFTP: serviceExposureFromSpec(spec.SFTP)}
+	}
+	emptySince := ""
+	if server.Status.EmptySince != nil {
+		emptySince = server.Status.EmptySince.Format("2006-01-02T15:04:05Z")
+	}
+
+	lastBackupAt := ""
+	if server.Status.LastSuccessfulBackupTime != nil {
+		lastBackupAt = server.Status.LastSuccessfulBackupTime.Format("2006-01-02T15:04:05Z")
+	}
+	sftpRotatedAt := ""
+	if server.Status.SFTPPasswordRotatedAt != nil {
+		sftpRotatedAt = server.Status.SFTPPasswordRotatedAt.Format("2006-01-02T15:04:05Z")
+	}
+
+	var conditions []models.Condition
+	for _, condition := range server.Status.Conditions {
+		conditions = append(conditions, models.Condition{
+			Type:               condition.Type,
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	var game *models.GameStatus
+	if status := server.Status.Game; status != nil {
+		game = &models.GameStatus{
+			MOTD:          status.MOTD,
+			Version:       status.Version,
+			PlayersOnline: status.PlayersOnline,
+			PlayersMax:    status.PlayersMax,
+			LatencyMs:     status.LatencyMilliseconds,
+			LastPingAt:    status.LastPingTime.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
 	return models.ServerResponse{
-		Name:            server.Name,
-		Namespace:       server.Namespace,
-		EULA:            server.Spec.EULA,
-		Memory:          server.Spec.Memory,
-		StorageSize:     server.Spec.StorageSize,
-		Version:         server.Spec.Version,
-		ServerType:      server.Spec.ServerType,
-		MaxPlayers:      server.Spec.MaxPlayers,
-		Difficulty:      server.Spec.Difficulty,
-		Gamemode:        server.Spec.Gamemode,
-		Phase:           server.Status.Phase,
-		Endpoint:        server.Status.Endpoint,
-		PublicEndpoint:  publicEndpoint,
-		SFTPEndpoint:    server.Status.SFTPEndpoint,
-		SFTPUsername:    server.Status.SFTPUsername,
-		SFTPPassword:    server.Status.SFTPPassword,
-		AllocatedMemory: server.Status.AllocatedMemory,
-		CreatedAt:       server.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+		Name:                server.Name,
+		Namespace:           server.Namespace,
+		EULA:                server.Spec.EULA,
+		Memory:              server.Spec.Memory,
+		StorageSize:         server.Spec.StorageSize,
+		Version:             server.Spec.Version,
+		ServerType:          server.Spec.ServerType,
+		MaxPlayers:          server.Spec.MaxPlayers,
+		Difficulty:          server.Spec.Difficulty,
+		Gamemode:            server.Spec.Gamemode,
+		Paused:              server.Spec.Paused,
+		Phase:               server.Status.Phase,
+		Message:             server.Status.Message,
+		Conditions:          conditions,
+		Game:                game,
+		Endpoint:            server.Status.Endpoint,
+		PublicEndpoint:      publicEndpoint,
+		PublicTunnel:        server.Spec.PublicTunnel,
+		SFTPEndpoint:        server.Status.SFTPEndpoint,
+		SFTPUsername:        server.Status.SFTPUsername,
+		AllocatedMemory:     server.Status.AllocatedMemory,
+		BackupSchedule:      backupSchedule,
+		IdleShutdown:        idleShutdown,
+		Expose:              expose,
+		EmptySince:          emptySince,
+		StopReason:          server.Status.StopReason,
+		LastBackupAt:        lastBackupAt,
+		SFTPRotatedAt:       sftpRotatedAt,
+		AuthorizedKeys:      server.Spec.AuthorizedKeys,
+		DisableSFTPPassword: server.Spec.DisableSFTPPassword,
+		Owner:               server.Annotations[v1alpha1.OwnerAnnotation],
+		CreatedAt:           server.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+var (
+	validDifficulties = map[string]bool{"peaceful": true, "easy": true, "normal": true, "hard": true}
+	validGamemodes    = map[string]bool{"survival": true, "creative": true, "adventure": true, "spectator": true}
+)
+
+// validateUpdateRequest checks the non-memory fields of a partial update against the CRD constraints
+func validateUpdateRequest(req *models.UpdateServerRequest) error {
+	if req.MaxPlayers != nil && (*req.MaxPlayers < 1 || *req.MaxPlayers > 1000) {
+		return fmt.Errorf("maxPlayers must be between 1 and 1000")
+	}
+	if req.Difficulty != nil && !validDifficulties[*req.Difficulty] {
+		return fmt.Errorf("difficulty must be one of peaceful, easy, normal, hard")
+	}
+	if req.Gamemode != nil && !validGamemodes[*req.Gamemode] {
+		return fmt.Errorf("gamemode must be one of survival, creative, adventure, spectator")
+	}
+	if req.Version != nil && *req.Version == "" {
+		return fmt.Errorf("version must not be empty")
+	}
+	if req.ServerType != nil && *req.ServerType == "" {
+		return fmt.Errorf("serverType must not be empty")
+	}
+	if req.BackupSchedule != nil && req.BackupSchedule.Schedule != "" {
+		if err := validateBackupSchedule(req.BackupSchedule); err != nil {
+			return err
+		}
+	}
+	if err := validateIdleShutdown(req.IdleShutdown); err != nil {
+		return err
+	}
+	if err := validateExpose(req.Expose); err != nil {
+		return err
+	}
+	if req.AuthorizedKeys != nil {
+		keys, err := normalizeAuthorizedKeys(*req.AuthorizedKeys)
+		if err != nil {
+			return err
+		}
+		*req.AuthorizedKeys = keys
+	}
+	return nil
+}
+
+// validateBackupSchedule checks a backup schedule the way the operator parses it
+func validateBackupSchedule(schedule *models.BackupSchedule) error {
+	if _, err := cron.ParseStandard(schedule.Schedule); err != nil {
+		return fmt.Errorf("backupSchedule.schedule is not a valid cron expression: %v", err)
+	}
+	if schedule.KeepLast < 0 || schedule.KeepDaily < 0 || schedule.KeepWeekly < 0 {
+		return fmt.Errorf("backupSchedule retention counts must not be negative")
+	}
+	return nil
+}
+
+// validateIdleShutdown checks an idle shutdown policy against the CRD constraints, a timeout of 0 removes it
+func validateIdleShutdown(policy *models.IdleShutdown) error {
+	if policy != nil && policy.TimeoutMinutes < 0 {
+		return fmt.Errorf("idleShutdown.timeoutMinutes must not be negative")
+	}
+	return nil
+}
+
+// validExposeTypes are the service types a port of a server can be exposed through
+var validExposeTypes = map[string]bool{
+	v1alpha1.ExposeLoadBalancer: true,
+	v1alpha1.ExposeNodePort:     true,
+	v1alpha1.ExposeClusterIP:    true,
+}
+
+// validateExpose checks how the ports of a server are exposed against the CRD constraints
+func validateExpose(expose *models.Expose) error {
+	if expose == nil {
+		return nil
+	}
+	ports := []struct {
+		name     string
+		exposure *models.ServiceExposure
+	}{{"game", expose.Game}, {"sftp", expose.SFTP}}
+	for _, port := range ports {
+		name, exposure := port.name, port.exposure
+		if exposure == nil {
+			continue
+		}
+		if !validExposeTypes[exposure.Type] {
+			return fmt.Errorf("expose.%s.type must be one of LoadBalancer, NodePort, ClusterIP", name)
+		}
+		if exposure.NodePort == 0 {
+			continue
+		}
+		if exposure.Type != v1alpha1.ExposeNodePort {
+			return fmt.Errorf("expose.%s.nodePort can only be set with type NodePort", name)
+		}
+		if exposure.NodePort < 30000 || exposure.NodePort > 32767 {
+			return fmt.Errorf("expose.%s.nodePort must be between 30000 and 32767", name)
+		}
+	}
+	return nil
+}
+
+// maxAuthorizedKeys matches the limit on spec.authorizedKeys in the CRD
+const maxAuthorizedKeys = 20
+
+// normalizeAuthorizedKeys parses SSH public keys in authorized_keys format. The keys are returned
+// with their comment but without options, so a key can't change what the SFTP user is allowed to do.
+func normalizeAuthorizedKeys(keys []string) ([]string, error) {
+	if len(keys) > maxAuthorizedKeys {
+		return nil, fmt.Errorf("at most %d authorizedKeys are allowed", maxAuthorizedKeys)
+	}
+
+	normalized := make([]string, 0, len(keys))
+	for i, key := range keys {
+		key = strings.TrimSpace(key)
+		if strings.ContainsAny(key, "\r\n") {
+			return nil, fmt.Errorf("authorizedKeys[%d] must be a single key", i)
+		}
+		publicKey, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("authorizedKeys[%d] is not a valid SSH public key: %v", i, err)
+		}
+		if err := checkKeyStrength(publicKey); err != nil {
+			return nil, fmt.Errorf("authorizedKeys[%d] %v", i, err)
+		}
+
+		line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+		if comment != "" {
+			line += " " + comment
+		}
+		normalized = append(normalized, line)
+	}
+	return normalized, nil
+}
+
+// checkKeyStrength rejects the key types the SFTP server no longer accepts
+func checkKeyStrength(publicKey ssh.PublicKey) error {
+	if publicKey.Type() == ssh.KeyAlgoDSA {
+		return fmt.Errorf("is a DSA key, which is not supported")
+	}
+	if cryptoKey, ok := publicKey.(ssh.CryptoPublicKey); ok {
+		if rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+			return fmt.Errorf("is an RSA key shorter than 2048 bits")
+		}
+	}
+	return nil
+}
+
+// validateSFTPLogin checks that turning off SFTP passwords leaves authorized keys to log in with
+func validateSFTPLogin(disablePassword bool, authorizedKeys []string) error {
+	if disablePassword && len(authorizedKeys) == 0 {
+		return fmt.Errorf("disableSFTPPassword requires at least one authorized key")
+	}
+	return nil
+}
+
+// backupScheduleToSpec converts a requested backup schedule to the CRD type, an empty schedule removes it
+func backupScheduleToSpec(schedule *models.BackupSchedule) *v1alpha1.BackupSchedule {
+	if schedule == nil || schedule.Schedule == "" {
+		return nil
+	}
+	return &v1alpha1.BackupSchedule{
+		Schedule:   schedule.Schedule,
+		KeepLast:   schedule.KeepLast,
+		KeepDaily:  schedule.KeepDaily,
+		KeepWeekly: schedule.KeepWeekly,
+	}
+}
+
+// idleShutdownToSpec converts a requested idle shutdown policy to the CRD type, a timeout of 0 removes it
+func idleShutdownToSpec(policy *models.IdleShutdown) *v1alpha1.IdleShutdownPolicy {
+	if policy == nil || policy.TimeoutMinutes == 0 {
+		return nil
+	}
+	return &v1alpha1.IdleShutdownPolicy{TimeoutMinutes: policy.TimeoutMinutes, WakeOnConnect: policy.WakeOnConnect}
+}
+
+// exposeToSpec converts how the ports of a server are requested to be exposed to the CRD type,
+// leaving out the setting when both ports use the default
+func exposeToSpec(expose *models.Expose) *v1alpha1.ExposeSpec {
+	if expose == nil || (expose.Game == nil && expose.SFTP == nil) {
+		return nil
+	}
+	return &v1alpha1.ExposeSpec{Game: serviceExposureToSpec(expose.Game), SFTP: serviceExposureToSpec(expose.SFTP)}
+}
+
+func serviceExposureToSpec(exposure *models.ServiceExposure) *v1alpha1.ServiceExposure {
+	if exposure == nil {
+		return nil
+	}
+	return &v1alpha1.ServiceExposure{Type: exposure.Type, NodePort: exposure.NodePort}
+}
+
+func serviceExposureFromSpec(exposure *v1alpha1.ServiceExposure) *models.ServiceExposure {
+	if exposure == nil {
+		return nil
+	}
+	return &models.ServiceExposure{Type: exposure.Type, NodePort: exposure.NodePort}
+}
+
+// applyUpdateRequest copies the fields set in a partial update onto the server spec
+func applyUpdateRequest(spec *v1alpha1.MinecraftServerSpec, req *models.UpdateServerRequest) {
+	if req.EULA != nil {
+		spec.EULA = *req.EULA
+	}
+	if req.Memory != nil {
+		spec.Memory = *req.Memory
+	}
+	if req.Version != nil {
+		spec.Version = *req.Version
+	}
+	if req.ServerType != nil {
+		spec.ServerType = *req.ServerType
+	}
+	if req.MaxPlayers != nil {
+		spec.MaxPlayers = *req.MaxPlayers
+	}
+	if req.Difficulty != nil {
+		spec.Difficulty = *req.Difficulty
+	}
+	if req.Gamemode != nil {
+		spec.Gamemode = *req.Gamemode
+	}
+	if req.PublicEndpoint != nil {
+		spec.PublicEndpoint = *req.PublicEndpoint
+	}
+	if req.PublicTunnel != nil {
+		spec.PublicTunnel = *req.PublicTunnel
+	}
+	if req.BackupSchedule != nil {
+		spec.BackupSchedule = backupScheduleToSpec(req.BackupSchedule)
+	}
+	if req.IdleShutdown != nil {
+		spec.IdleShutdown = idleShutdownToSpec(req.IdleShutdown)
+	}
+	if req.Expose != nil {
+		spec.Expose = exposeToSpec(req.Expose)
+	}
+	if req.AuthorizedKeys != nil {
+		spec.AuthorizedKeys = *req.AuthorizedKeys
+	}
+	if req.DisableSFTPPassword != nil {
+		spec.DisableSFTPPassword = *req.DisableSFTPPassword
 	}
 }
 
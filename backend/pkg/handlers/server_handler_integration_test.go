@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
 	"github.com/homecraft/backend/pkg/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestListServers_EmptyResponse(t *testing.T) {
@@ -176,9 +180,63 @@ func TestHelperFunctions_EdgeCases(t *testing.T) {
 }
 
 func TestConvertToResponse(t *testing.T) {
-	// This is a simple unit test for the helper function
-	// In a real scenario, you'd create a mock MinecraftServer object
-	t.Skip("Requires actual MinecraftServer object from k8s API")
+	transition := metav1.NewTime(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	server := &v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: MinecraftNamespace},
+		Spec: v1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			Memory:       "4Gi",
+			IdleShutdown: &v1alpha1.IdleShutdownPolicy{TimeoutMinutes: 30},
+		},
+		Status: v1alpha1.MinecraftServerStatus{
+			Phase:   "Failed",
+			Message: "The minecraft container keeps crashing, it restarted 5 times, last exit code 1",
+			Conditions: []metav1.Condition{{
+				Type:               v1alpha1.ServerConditionGameReady,
+				Status:             metav1.ConditionFalse,
+				Reason:             "CrashLoopBackOff",
+				Message:            "Restarted 5 times, last exit code 1",
+				LastTransitionTime: transition,
+			}},
+			Game: &v1alpha1.GameStatus{
+				MOTD:                "A HomeCraft Server",
+				Version:             "Paper 1.21.1",
+				PlayersOnline:       2,
+				PlayersMax:          20,
+				LatencyMilliseconds: 3,
+				LastPingTime:        transition,
+			},
+		},
+	}
+
+	response := convertToResponse(server)
+	if response.Phase != "Failed" || response.Message != server.Status.Message {
+		t.Errorf("phase = %s, message = %q", response.Phase, response.Message)
+	}
+	want := []models.Condition{{
+		Type:               v1alpha1.ServerConditionGameReady,
+		Status:             "False",
+		Reason:             "CrashLoopBackOff",
+		Message:            "Restarted 5 times, last exit code 1",
+		LastTransitionTime: "2026-10-16T12:00:00Z",
+	}}
+	if !reflect.DeepEqual(response.Conditions, want) {
+		t.Errorf("conditions = %+v, want %+v", response.Conditions, want)
+	}
+	wantGame := &models.GameStatus{
+		MOTD:          "A HomeCraft Server",
+		Version:       "Paper 1.21.1",
+		PlayersOnline: 2,
+		PlayersMax:    20,
+		LatencyMs:     3,
+		LastPingAt:    "2026-10-16T12:00:00Z",
+	}
+	if !reflect.DeepEqual(response.Game, wantGame) {
+		t.Errorf("game = %+v, want %+v", response.Game, wantGame)
+	}
+	if response.IdleShutdown == nil || response.IdleShutdown.TimeoutMinutes != 30 {
+		t.Errorf("idleShutdown = %+v, want a 30 minute timeout", response.IdleShutdown)
+	}
 }
 
 func BenchmarkIsValidMemoryFormat(b *testing.B) {
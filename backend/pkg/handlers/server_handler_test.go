@@ -2,13 +2,29 @@ package handlers
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/auth"
+	"github.com/homecraft/backend/pkg/k8s"
 	"github.com/homecraft/backend/pkg/models"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 )
 
 func TestIsValidMemoryFormat(t *testing.T) {
@@ -294,6 +310,574 @@ func TestCreateServer_InvalidMemoryFormat(t *testing.T) {
 	}
 }
 
+func TestUpdateServer_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := &ServerHandler{}
+	router.PATCH("/servers/:name", handler.UpdateServer)
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "invalid JSON",
+			body:          "invalid json",
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "invalid memory",
+			body:          `{"memory": "4GB"}`,
+			expectedError: "invalid_memory",
+		},
+		{
+			name:          "max players too low",
+			body:          `{"maxPlayers": 0}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "max players too high",
+			body:          `{"maxPlayers": 1001}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "unknown difficulty",
+			body:          `{"difficulty": "nightmare"}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "unknown gamemode",
+			body:          `{"gamemode": "hardcore"}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "empty version",
+			body:          `{"version": ""}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "invalid backup schedule",
+			body:          `{"backupSchedule": {"schedule": "every day"}}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "negative backup retention",
+			body:          `{"backupSchedule": {"schedule": "0 4 * * *", "keepLast": -1}}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "unknown expose type",
+			body:          `{"expose": {"game": {"type": "Ingress"}}}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "node port outside the node port range",
+			body:          `{"expose": {"sftp": {"type": "NodePort", "nodePort": 2222}}}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "node port on a load balancer",
+			body:          `{"expose": {"game": {"type": "LoadBalancer", "nodePort": 30565}}}`,
+			expectedError: "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PATCH", "/servers/test-server", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("UpdateServer() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse error response: %v", err)
+			}
+
+			if response.Error != tt.expectedError {
+				t.Errorf("UpdateServer() error = %v, want %v", response.Error, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestExecuteCommand_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := &ServerHandler{}
+	router.POST("/servers/:name/command", handler.ExecuteCommand)
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "missing command",
+			body:          `{}`,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "only a slash",
+			body:          `{"command": "/"}`,
+			expectedError: "invalid_command",
+		},
+		{
+			name:          "too long",
+			body:          `{"command": "say ` + strings.Repeat("a", 1500) + `"}`,
+			expectedError: "invalid_command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/servers/test-server/command", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("ExecuteCommand() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse error response: %v", err)
+			}
+
+			if response.Error != tt.expectedError {
+				t.Errorf("ExecuteCommand() error = %v, want %v", response.Error, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestApplyUpdateRequest(t *testing.T) {
+	spec := v1alpha1.MinecraftServerSpec{
+		EULA:           true,
+		Memory:         "2Gi",
+		StorageSize:    "5Gi",
+		Version:        "1.20.1",
+		ServerType:     "VANILLA",
+		MaxPlayers:     20,
+		Difficulty:     "normal",
+		Gamemode:       "survival",
+		PublicEndpoint: "old.example.com",
+	}
+
+	memory := "4Gi"
+	maxPlayers := 50
+	difficulty := "hard"
+	req := models.UpdateServerRequest{
+		Memory:     &memory,
+		MaxPlayers: &maxPlayers,
+		Difficulty: &difficulty,
+	}
+
+	applyUpdateRequest(&spec, &req)
+
+	if spec.Memory != "4Gi" {
+		t.Errorf("Memory = %s, want 4Gi", spec.Memory)
+	}
+	if spec.MaxPlayers != 50 {
+		t.Errorf("MaxPlayers = %d, want 50", spec.MaxPlayers)
+	}
+	if spec.Difficulty != "hard" {
+		t.Errorf("Difficulty = %s, want hard", spec.Difficulty)
+	}
+
+	// Fields absent from the request must be left untouched
+	if spec.Version != "1.20.1" {
+		t.Errorf("Version = %s, want 1.20.1", spec.Version)
+	}
+	if spec.Gamemode != "survival" {
+		t.Errorf("Gamemode = %s, want survival", spec.Gamemode)
+	}
+	if spec.StorageSize != "5Gi" {
+		t.Errorf("StorageSize = %s, want 5Gi", spec.StorageSize)
+	}
+	if spec.PublicEndpoint != "old.example.com" {
+		t.Errorf("PublicEndpoint = %s, want old.example.com", spec.PublicEndpoint)
+	}
+	if !spec.EULA {
+		t.Error("EULA was reset, want true")
+	}
+	if spec.BackupSchedule != nil {
+		t.Errorf("BackupSchedule = %+v, want none", spec.BackupSchedule)
+	}
+
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{
+		BackupSchedule: &models.BackupSchedule{Schedule: "0 4 * * *", KeepDaily: 7},
+	})
+	if spec.BackupSchedule == nil || spec.BackupSchedule.Schedule != "0 4 * * *" || spec.BackupSchedule.KeepDaily != 7 {
+		t.Errorf("BackupSchedule = %+v, want daily at 04:00 keeping 7", spec.BackupSchedule)
+	}
+
+	// An empty schedule removes it
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{BackupSchedule: &models.BackupSchedule{}})
+	if spec.BackupSchedule != nil {
+		t.Errorf("BackupSchedule = %+v, want it removed", spec.BackupSchedule)
+	}
+
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{IdleShutdown: &models.IdleShutdown{TimeoutMinutes: 30, WakeOnConnect: true}})
+	if spec.IdleShutdown == nil || spec.IdleShutdown.TimeoutMinutes != 30 || !spec.IdleShutdown.WakeOnConnect {
+		t.Errorf("IdleShutdown = %+v, want a 30 minute timeout waking on connect", spec.IdleShutdown)
+	}
+
+	// A timeout of 0 removes the policy
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{IdleShutdown: &models.IdleShutdown{}})
+	if spec.IdleShutdown != nil {
+		t.Errorf("IdleShutdown = %+v, want it removed", spec.IdleShutdown)
+	}
+
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{Expose: &models.Expose{
+		Game: &models.ServiceExposure{Type: "NodePort", NodePort: 30565},
+	}})
+	if spec.Expose == nil || spec.Expose.Game == nil || spec.Expose.Game.NodePort != 30565 || spec.Expose.SFTP != nil {
+		t.Errorf("Expose = %+v, want the game port on node port 30565", spec.Expose)
+	}
+
+	// Leaving out both ports goes back to the defaults
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{Expose: &models.Expose{}})
+	if spec.Expose != nil {
+		t.Errorf("Expose = %+v, want it removed", spec.Expose)
+	}
+
+	publicTunnel := true
+	applyUpdateRequest(&spec, &models.UpdateServerRequest{PublicTunnel: &publicTunnel})
+	if !spec.PublicTunnel || spec.PublicEndpoint != "old.example.com" {
+		t.Errorf("PublicTunnel = %v with endpoint %s, want a tunnel next to the manual endpoint", spec.PublicTunnel, spec.PublicEndpoint)
+	}
+}
+
+func TestNormalizeAuthorizedKeys(t *testing.T) {
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	edKey, err := ssh.NewPublicKey(edPublic)
+	if err != nil {
+		t.Fatalf("Failed to convert key: %v", err)
+	}
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	weakKey, err := ssh.NewPublicKey(&weakRSA.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to convert key: %v", err)
+	}
+	key := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(edKey)))
+
+	tests := []struct {
+		name    string
+		keys    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no keys", keys: nil, want: []string{}},
+		{name: "key with comment", keys: []string{"  " + key + " deploy@ci\n"}, want: []string{key + " deploy@ci"}},
+		{name: "options are dropped", keys: []string{`no-pty,command="rm -rf /data" ` + key}, want: []string{key}},
+		{name: "not a key", keys: []string{"ssh-ed25519 not-base64"}, wantErr: true},
+		{name: "two keys in one entry", keys: []string{key + "\n" + key}, wantErr: true},
+		{name: "short RSA key", keys: []string{string(ssh.MarshalAuthorizedKey(weakKey))}, wantErr: true},
+		{name: "too many keys", keys: make([]string, maxAuthorizedKeys+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeAuthorizedKeys(tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeAuthorizedKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("normalizeAuthorizedKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSFTPLogin(t *testing.T) {
+	if err := validateSFTPLogin(true, nil); err == nil {
+		t.Error("turning off the SFTP password without keys should fail")
+	}
+	if err := validateSFTPLogin(true, []string{"ssh-ed25519 AAAA"}); err != nil {
+		t.Errorf("turning off the SFTP password with a key error = %v", err)
+	}
+	if err := validateSFTPLogin(false, nil); err != nil {
+		t.Errorf("password login without keys error = %v", err)
+	}
+}
+
+func TestParseLogOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		query         string
+		wantContainer string
+		wantTail      int64
+		wantSince     int64
+		wantFollow    bool
+		wantErr       bool
+	}{
+		{
+			name:          "defaults",
+			query:         "",
+			wantContainer: "minecraft",
+			wantTail:      100,
+		},
+		{
+			name:          "all options",
+			query:         "container=sftp&tail=500&since=10m&follow=true",
+			wantContainer: "sftp",
+			wantTail:      500,
+			wantSince:     600,
+			wantFollow:    true,
+		},
+		{
+			name:          "since without tail",
+			query:         "since=1h",
+			wantContainer: "minecraft",
+			wantSince:     3600,
+		},
+		{
+			name:    "unknown container",
+			query:   "container=init",
+			wantErr: true,
+		},
+		{
+			name:    "tail not a number",
+			query:   "tail=all",
+			wantErr: true,
+		},
+		{
+			name:    "tail too large",
+			query:   "tail=20000",
+			wantErr: true,
+		},
+		{
+			name:    "since not a duration",
+			query:   "since=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "since below one second",
+			query:   "since=500ms",
+			wantErr: true,
+		},
+		{
+			name:    "follow not a bool",
+			query:   "follow=maybe",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("GET", "/servers/test-server/logs?"+tt.query, nil)
+
+			options, err := parseLogOptions(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if options.Container != tt.wantContainer {
+				t.Errorf("Container = %s, want %s", options.Container, tt.wantContainer)
+			}
+			if tt.wantTail == 0 && options.TailLines != nil {
+				t.Errorf("TailLines = %d, want unset", *options.TailLines)
+			}
+			if tt.wantTail != 0 && (options.TailLines == nil || *options.TailLines != tt.wantTail) {
+				t.Errorf("TailLines = %v, want %d", options.TailLines, tt.wantTail)
+			}
+			if tt.wantSince == 0 && options.SinceSeconds != nil {
+				t.Errorf("SinceSeconds = %d, want unset", *options.SinceSeconds)
+			}
+			if tt.wantSince != 0 && (options.SinceSeconds == nil || *options.SinceSeconds != tt.wantSince) {
+				t.Errorf("SinceSeconds = %v, want %d", options.SinceSeconds, tt.wantSince)
+			}
+			if options.Follow != tt.wantFollow {
+				t.Errorf("Follow = %v, want %v", options.Follow, tt.wantFollow)
+			}
+		})
+	}
+}
+
+func TestReadLogLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		logs          string
+		wantLines     []string
+		wantTruncated bool
+	}{
+		{name: "empty", logs: "", wantLines: []string{}},
+		{name: "below the limit", logs: "one\ntwo\n", wantLines: []string{"one", "two"}},
+		{name: "at the limit", logs: "one\ntwo\nthree\n", wantLines: []string{"one", "two", "three"}},
+		{name: "over the limit", logs: "one\ntwo\nthree\nfour\n", wantLines: []string{"one", "two", "three"}, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, truncated, err := readLogLines(strings.NewReader(tt.logs), 3)
+			if err != nil {
+				t.Fatalf("readLogLines() error = %v", err)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) || truncated != tt.wantTruncated {
+				t.Errorf("readLogLines() = %q, %v; want %q, %v", lines, truncated, tt.wantLines, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestStreamLogLines(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/servers/test-server/logs?follow=true", nil)
+
+	streamLogLines(c, strings.NewReader("[Server thread/INFO]: Starting minecraft server\n[Server thread/INFO]: Done (3.2s)!\n"))
+
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	want := "event:log\ndata:[Server thread/INFO]: Starting minecraft server\n\n" +
+		"event:log\ndata:[Server thread/INFO]: Done (3.2s)!\n\n" +
+		"event:end\ndata:\n\n"
+	if w.Body.String() != want {
+		t.Errorf("streamLogLines() body = %q, want %q", w.Body.String(), want)
+	}
+}
+
+func TestStreamServerEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newServer := func(phase, resourceVersion string) *v1alpha1.MinecraftServer {
+		return &v1alpha1.MinecraftServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "minecraft-servers", ResourceVersion: resourceVersion},
+			Status:     v1alpha1.MinecraftServerStatus{Phase: phase},
+		}
+	}
+
+	t.Run("forwards events until the watch fails", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/servers/watch", nil)
+
+		watcher := watch.NewFake()
+		go func() {
+			watcher.Add(newServer("Pending", "11"))
+			watcher.Modify(newServer("Running", "12"))
+			watcher.Delete(newServer("Running", "13"))
+			watcher.Error(&apierrors.NewResourceExpired("too old resource version: 10 (13)").ErrStatus)
+		}()
+
+		resourceVersion, done := streamServerEvents(c, watcher, "10", nil)
+		if !done {
+			t.Error("streamServerEvents() done = false after a watch error, want true")
+		}
+		if resourceVersion != "13" {
+			t.Errorf("streamServerEvents() resourceVersion = %s, want 13", resourceVersion)
+		}
+
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		wantPrefixes := []string{
+			"id:11\nevent:ADDED\ndata:",
+			"id:12\nevent:MODIFIED\ndata:",
+			"id:13\nevent:DELETED\ndata:",
+			"event:ERROR\ndata:",
+		}
+		if len(events) != len(wantPrefixes) {
+			t.Fatalf("streamServerEvents() sent %d events, want %d: %q", len(events), len(wantPrefixes), w.Body.String())
+		}
+		for i, prefix := range wantPrefixes {
+			if !strings.HasPrefix(events[i], prefix) {
+				t.Errorf("event %d = %q, want prefix %q", i, events[i], prefix)
+			}
+		}
+
+		var modified models.ServerResponse
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(events[1], wantPrefixes[1])), &modified); err != nil {
+			t.Fatalf("Failed to parse event data: %v", err)
+		}
+		if modified.Name != "test-server" || modified.Phase != "Running" {
+			t.Errorf("MODIFIED event data = %+v, want test-server in phase Running", modified)
+		}
+
+		var failure models.ErrorResponse
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(events[3], wantPrefixes[3])), &failure); err != nil {
+			t.Fatalf("Failed to parse error data: %v", err)
+		}
+		if failure.Error != "resource_expired" {
+			t.Errorf("ERROR event error = %s, want resource_expired", failure.Error)
+		}
+	})
+
+	t.Run("closed watch can be resumed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/servers/watch", nil)
+
+		watcher := watch.NewFake()
+		go func() {
+			watcher.Modify(newServer("Stopping", "21"))
+			watcher.Stop()
+		}()
+
+		resourceVersion, done := streamServerEvents(c, watcher, "20", nil)
+		if done {
+			t.Error("streamServerEvents() done = true after the watch closed, want false")
+		}
+		if resourceVersion != "21" {
+			t.Errorf("streamServerEvents() resourceVersion = %s, want 21", resourceVersion)
+		}
+	})
+
+	t.Run("skips servers of other users", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/servers/watch", nil)
+
+		steve := &auth.Identity{Subject: "steve", Issuer: auth.LocalIssuer, Username: "steve"}
+		alex := &auth.Identity{Subject: "alex", Issuer: auth.LocalIssuer, Username: "alex"}
+		owned := newServer("Running", "31")
+		setOwner(owned, steve)
+		other := newServer("Running", "32")
+		setOwner(other, alex)
+
+		watcher := watch.NewFake()
+		go func() {
+			watcher.Modify(owned)
+			watcher.Modify(other)
+			watcher.Stop()
+		}()
+
+		resourceVersion, _ := streamServerEvents(c, watcher, "30", steve)
+		if resourceVersion != "32" {
+			t.Errorf("streamServerEvents() resourceVersion = %s, want 32 so skipped events aren't replayed", resourceVersion)
+		}
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		if len(events) != 1 || !strings.HasPrefix(events[0], "id:31\n") {
+			t.Errorf("streamServerEvents() sent %q, want only the event of steve's server", w.Body.String())
+		}
+	})
+}
+
 func BenchmarkParseMemoryToBytes(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = parseMemoryToBytes("4Gi")
@@ -306,3 +890,249 @@ func BenchmarkBytesToHumanReadable(b *testing.B) {
 		_ = bytesToHumanReadable(bytes)
 	}
 }
+
+// stubAuthenticator authenticates every request as identity
+type stubAuthenticator struct {
+	identity *auth.Identity
+}
+
+func (a stubAuthenticator) AuthenticateRequest(r *http.Request) (*auth.Identity, error) {
+	return a.identity, nil
+}
+
+// fakeServerAPI serves the MinecraftServers of the minecraft-servers namespace and counts updates
+type fakeServerAPI struct {
+	servers map[string]*v1alpha1.MinecraftServer
+	updates int
+}
+
+func (f *fakeServerAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	collection := "/apis/homecraft.io/v1alpha1/namespaces/" + MinecraftNamespace + "/minecraftservers"
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodPost && r.URL.Path == collection {
+		created := &v1alpha1.MinecraftServer{}
+		if err := json.NewDecoder(r.Body).Decode(created); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.servers[created.Name] = created
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(created)
+		return
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, collection+"/")
+	server := f.servers[name]
+	if !ok || server == nil {
+		status := apierrors.NewNotFound(v1alpha1.Resource("minecraftservers"), name)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(status.ErrStatus)
+		return
+	}
+	if r.Method == http.MethodPut {
+		updated := &v1alpha1.MinecraftServer{}
+		if err := json.NewDecoder(r.Body).Decode(updated); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.servers[name] = updated
+		f.updates++
+		server = updated
+	}
+	_ = json.NewEncoder(w).Encode(server)
+}
+
+// newTestServerHandler returns a handler for servers served by api on a cluster with one node
+// of nodeMemory allocatable memory
+func newTestServerHandler(t *testing.T, api http.Handler, nodeMemory string) *ServerHandler {
+	t.Helper()
+
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(nodeMemory)},
+		},
+	}
+	k8sClient, err := k8s.NewClientForConfig(&rest.Config{Host: server.URL}, k8sfake.NewSimpleClientset(node))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return NewServerHandler(k8sClient, nil)
+}
+
+func TestStopAndStartServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	steve := &auth.Identity{Subject: "steve", Issuer: auth.LocalIssuer, Username: "steve"}
+	alex := &auth.Identity{Subject: "alex", Issuer: auth.LocalIssuer, Username: "alex"}
+	admin := &auth.Identity{Subject: "admin", Issuer: auth.LocalIssuer, Username: "admin", Admin: true}
+
+	tests := []struct {
+		name       string
+		action     string
+		identity   *auth.Identity
+		missing    bool
+		paused     bool
+		memory     string
+		nodeMemory string
+		wantStatus int
+		wantError  string
+		wantPaused bool
+		wantUpdate bool
+	}{
+		{
+			name:       "stop unknown server",
+			action:     "stop",
+			identity:   steve,
+			missing:    true,
+			wantStatus: http.StatusNotFound,
+			wantError:  "not_found",
+		},
+		{
+			name:       "stop someone else's server",
+			action:     "stop",
+			identity:   alex,
+			wantStatus: http.StatusNotFound,
+			wantError:  "not_found",
+		},
+		{
+			name:       "stop running server",
+			action:     "stop",
+			identity:   steve,
+			wantStatus: http.StatusOK,
+			wantPaused: true,
+			wantUpdate: true,
+		},
+		{
+			name:       "admin stops someone else's server",
+			action:     "stop",
+			identity:   admin,
+			wantStatus: http.StatusOK,
+			wantPaused: true,
+			wantUpdate: true,
+		},
+		{
+			name:       "stop stopped server",
+			action:     "stop",
+			identity:   steve,
+			paused:     true,
+			wantStatus: http.StatusOK,
+			wantPaused: true,
+		},
+		{
+			name:       "start unknown server",
+			action:     "start",
+			identity:   steve,
+			missing:    true,
+			wantStatus: http.StatusNotFound,
+			wantError:  "not_found",
+		},
+		{
+			name:       "start someone else's server",
+			action:     "start",
+			identity:   alex,
+			paused:     true,
+			wantStatus: http.StatusNotFound,
+			wantError:  "not_found",
+			wantPaused: true,
+		},
+		{
+			name:       "start stopped server",
+			action:     "start",
+			identity:   steve,
+			paused:     true,
+			wantStatus: http.StatusOK,
+			wantUpdate: true,
+		},
+		{
+			name:       "start running server",
+			action:     "start",
+			identity:   steve,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "start without enough memory",
+			action:     "start",
+			identity:   steve,
+			paused:     true,
+			nodeMemory: "1Gi",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "insufficient_capacity",
+			wantPaused: true,
+		},
+		{
+			name:       "start with invalid memory",
+			action:     "start",
+			identity:   steve,
+			paused:     true,
+			memory:     "lots",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "invalid_memory",
+			wantPaused: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := tt.memory
+			if memory == "" {
+				memory = "2Gi"
+			}
+			nodeMemory := tt.nodeMemory
+			if nodeMemory == "" {
+				nodeMemory = "8Gi"
+			}
+
+			owned := &v1alpha1.MinecraftServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: MinecraftNamespace},
+				Spec:       v1alpha1.MinecraftServerSpec{Memory: memory, Paused: tt.paused},
+			}
+			setOwner(owned, steve)
+			api := &fakeServerAPI{servers: map[string]*v1alpha1.MinecraftServer{}}
+			if !tt.missing {
+				api.servers["survival"] = owned
+			}
+			handler := newTestServerHandler(t, api, nodeMemory)
+
+			router := gin.New()
+			router.Use(auth.Middleware(stubAuthenticator{identity: tt.identity}))
+			router.POST("/servers/:name/stop", handler.StopServer)
+			router.POST("/servers/:name/start", handler.StartServer)
+
+			req, _ := http.NewRequest("POST", "/servers/survival/"+tt.action, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d (%s)", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantError != "" {
+				var response models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if response.Error != tt.wantError {
+					t.Errorf("Expected error %s, got %s (%s)", tt.wantError, response.Error, response.Message)
+				}
+			} else {
+				var response models.ServerResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if response.Paused != tt.wantPaused {
+					t.Errorf("Expected paused %v in the response, got %v", tt.wantPaused, response.Paused)
+				}
+			}
+
+			if (api.updates > 0) != tt.wantUpdate {
+				t.Errorf("Expected update %v, got %d updates", tt.wantUpdate, api.updates)
+			}
+			if stored := api.servers["survival"]; stored != nil && stored.Spec.Paused != tt.wantPaused {
+				t.Errorf("Expected stored paused %v, got %v", tt.wantPaused, stored.Spec.Paused)
+			}
+		})
+	}
+}
@@ -3,17 +3,32 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/rcon"
+	"github.com/homecraft/backend/pkg/slp"
+	"github.com/homecraft/backend/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+const (
+	// ManagedByLabel is the label the operator sets on the workloads it manages
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	// OperatorName is the value of ManagedByLabel for workloads created by the HomeCraft operator
+	OperatorName = "homecraft-operator"
+)
+
 // Client wraps the Kubernetes client
 type Client struct {
 	config     *rest.Config
@@ -42,6 +57,12 @@ func NewClient() (*Client, error) {
 		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
 	}
 
+	return NewClientForConfig(config, clientset)
+}
+
+// NewClientForConfig creates a client reaching the custom resources through config and the
+// built-in resources through clientset, e.g. a fake one in tests
+func NewClientForConfig(config *rest.Config, clientset kubernetes.Interface) (*Client, error) {
 	// Create scheme and add our types
 	scheme := runtime.NewScheme()
 	if err := v1alpha1.AddToScheme(scheme); err != nil {
@@ -49,26 +70,36 @@ func NewClient() (*Client, error) {
 	}
 
 	// Create REST client for custom resources
+	restClient, err := newCRDRESTClient(config, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		config:     config,
+		clientset:  clientset,
+		restClient: restClient,
+		scheme:     scheme,
+	}, nil
+}
+
+// newCRDRESTClient creates a REST client for the homecraft.io custom resources
+func newCRDRESTClient(config *rest.Config, scheme *runtime.Scheme) (*rest.RESTClient, error) {
 	crdConfig := *config
 	crdConfig.ContentConfig.GroupVersion = &schema.GroupVersion{
 		Group:   v1alpha1.GroupName,
 		Version: v1alpha1.Version,
 	}
 	crdConfig.APIPath = "/apis"
-	crdConfig.NegotiatedSerializer = serializer.NewCodecFactory(scheme)
+	// Custom resources have no internal version, so objects are decoded as served
+	crdConfig.NegotiatedSerializer = serializer.NewCodecFactory(scheme).WithoutConversion()
 	crdConfig.UserAgent = rest.DefaultKubernetesUserAgent()
 
 	restClient, err := rest.UnversionedRESTClientFor(&crdConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create REST client: %w", err)
 	}
-
-	return &Client{
-		config:     config,
-		clientset:  clientset,
-		restClient: restClient,
-		scheme:     scheme,
-	}, nil
+	return restClient, nil
 }
 
 // CreateMinecraftServer creates a new MinecraftServer custom resource
@@ -115,6 +146,40 @@ func (c *Client) ListMinecraftServers(ctx context.Context, namespace string) (*v
 	return result, nil
 }
 
+// UpdateMinecraftServer replaces an existing MinecraftServer with the given object.
+// The object's resourceVersion is sent along, so concurrent modifications result in a conflict error.
+func (c *Client) UpdateMinecraftServer(ctx context.Context, namespace string, server *v1alpha1.MinecraftServer) (*v1alpha1.MinecraftServer, error) {
+	result := &v1alpha1.MinecraftServer{}
+	err := c.restClient.Put().
+		Namespace(namespace).
+		Resource("minecraftservers").
+		Name(server.Name).
+		Body(server).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update MinecraftServer: %w", err)
+	}
+	return result, nil
+}
+
+// WatchMinecraftServers watches the MinecraftServers in a namespace for changes.
+// Events start after the given resourceVersion, an empty resourceVersion starts from the current state.
+func (c *Client) WatchMinecraftServers(ctx context.Context, namespace, resourceVersion string) (watch.Interface, error) {
+	watcher, err := c.restClient.Get().
+		Namespace(namespace).
+		Resource("minecraftservers").
+		VersionedParams(&metav1.ListOptions{
+			Watch:           true,
+			ResourceVersion: resourceVersion,
+		}, metav1.ParameterCodec).
+		Watch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch MinecraftServers: %w", err)
+	}
+	return watcher, nil
+}
+
 // DeleteMinecraftServer deletes a MinecraftServer by name
 func (c *Client) DeleteMinecraftServer(ctx context.Context, namespace, name string) error {
 	err := c.restClient.Delete().
@@ -130,6 +195,159 @@ func (c *Client) DeleteMinecraftServer(ctx context.Context, namespace, name stri
 	return nil
 }
 
+// CreateMinecraftBackup creates a new MinecraftBackup custom resource
+func (c *Client) CreateMinecraftBackup(ctx context.Context, namespace string, backup *v1alpha1.MinecraftBackup) (*v1alpha1.MinecraftBackup, error) {
+	result := &v1alpha1.MinecraftBackup{}
+	err := c.restClient.Post().
+		Namespace(namespace).
+		Resource("minecraftbackups").
+		Body(backup).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinecraftBackup: %w", err)
+	}
+	return result, nil
+}
+
+// ListMinecraftBackups lists the MinecraftBackups of a server
+func (c *Client) ListMinecraftBackups(ctx context.Context, namespace, serverName string) (*v1alpha1.MinecraftBackupList, error) {
+	result := &v1alpha1.MinecraftBackupList{}
+	err := c.restClient.Get().
+		Namespace(namespace).
+		Resource("minecraftbackups").
+		VersionedParams(&metav1.ListOptions{
+			LabelSelector: v1alpha1.ServerNameLabel + "=" + serverName,
+		}, metav1.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MinecraftBackups: %w", err)
+	}
+	return result, nil
+}
+
+// GetMinecraftBackup retrieves a MinecraftBackup by name
+func (c *Client) GetMinecraftBackup(ctx context.Context, namespace, name string) (*v1alpha1.MinecraftBackup, error) {
+	result := &v1alpha1.MinecraftBackup{}
+	err := c.restClient.Get().
+		Namespace(namespace).
+		Resource("minecraftbackups").
+		Name(name).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MinecraftBackup: %w", err)
+	}
+	return result, nil
+}
+
+// CreateMinecraftRestore creates a new MinecraftRestore custom resource
+func (c *Client) CreateMinecraftRestore(ctx context.Context, namespace string, restore *v1alpha1.MinecraftRestore) (*v1alpha1.MinecraftRestore, error) {
+	result := &v1alpha1.MinecraftRestore{}
+	err := c.restClient.Post().
+		Namespace(namespace).
+		Resource("minecraftrestores").
+		Body(restore).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinecraftRestore: %w", err)
+	}
+	return result, nil
+}
+
+// GetRCONConnection returns the RCON address and password of a running MinecraftServer.
+// The operator runs each server as the single pod of a StatefulSet named after the server
+// and stores the RCON password in the server's Secret.
+func (c *Client) GetRCONConnection(ctx context.Context, namespace, name string) (address, password string, err error) {
+	pod, err := c.getRunningServerPod(ctx, namespace, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, ServerSecretName(name), metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get server secret: %w", err)
+	}
+	passwordBytes, ok := secret.Data[rcon.PasswordSecretKey]
+	if !ok || len(passwordBytes) == 0 {
+		return "", "", fmt.Errorf("server secret %s has no RCON password", secret.Name)
+	}
+
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(rcon.DefaultPort)), string(passwordBytes), nil
+}
+
+// GetGameAddress returns the address of the game port of a running MinecraftServer's pod,
+// which answers status pings from inside the cluster
+func (c *Client) GetGameAddress(ctx context.Context, namespace, name string) (string, error) {
+	pod, err := c.getRunningServerPod(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(slp.DefaultPort)), nil
+}
+
+// getRunningServerPod returns the pod of a MinecraftServer, or an error when it isn't running
+func (c *Client) getRunningServerPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, ServerPodName(name), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server pod: %w", err)
+	}
+	if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		return nil, fmt.Errorf("server pod %s is not running", pod.Name)
+	}
+	return pod, nil
+}
+
+// GetSFTPCredentials returns the SFTP username and password the operator stored in a server's Secret
+func (c *Client) GetSFTPCredentials(ctx context.Context, namespace, name string) (username, password string, err error) {
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, ServerSecretName(name), metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get server secret: %w", err)
+	}
+	username = string(secret.Data[utils.SFTPUsernameSecretKey])
+	password = string(secret.Data[utils.SFTPPasswordSecretKey])
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("server secret %s has no SFTP credentials yet", secret.Name)
+	}
+	return username, password, nil
+}
+
+// RotateSFTPPassword replaces the SFTP password in a server's Secret and records when it was rotated.
+// It returns the unchanged SFTP username.
+func (c *Client) RotateSFTPPassword(ctx context.Context, namespace, name, password string, rotatedAt time.Time) (string, error) {
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, ServerSecretName(name), metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get server secret: %w", err)
+	}
+	username := string(secret.Data[utils.SFTPUsernameSecretKey])
+	if username == "" {
+		return "", fmt.Errorf("server secret %s has no SFTP credentials yet", secret.Name)
+	}
+
+	secret.Data[utils.SFTPPasswordSecretKey] = []byte(password)
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[v1alpha1.SFTPPasswordRotatedAnnotation] = rotatedAt.UTC().Format(time.RFC3339)
+
+	if _, err := c.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to update server secret: %w", err)
+	}
+	return username, nil
+}
+
+// ServerPodName returns the name of the pod running a server
+func ServerPodName(serverName string) string {
+	return serverName + "-0"
+}
+
+// ServerSecretName returns the name of the Secret holding a server's credentials
+func ServerSecretName(serverName string) string {
+	return serverName + "-sftp"
+}
+
 // GetClientset returns the underlying Kubernetes clientset
 func (c *Client) GetClientset() kubernetes.Interface {
 	return c.clientset
@@ -158,19 +376,43 @@ func (c *Client) GetClusterMemoryResources(ctx context.Context) (totalMemory, al
 		return 0, 0, 0, fmt.Errorf("failed to list pods: %w", err)
 	}
 
+	activePods := make(map[string]int32)
 	for _, pod := range pods.Items {
 		// Skip completed/failed pods
 		if pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
 			continue
 		}
 
-		for _, container := range pod.Spec.Containers {
-			if memory, ok := container.Resources.Requests["memory"]; ok {
-				allocated += memory.Value()
+		allocated += podMemoryRequests(&pod.Spec)
+
+		for _, owner := range pod.OwnerReferences {
+			if owner.Kind == "StatefulSet" {
+				activePods[pod.Namespace+"/"+owner.Name]++
 			}
 		}
 	}
 
+	// Servers that were just (re)started have no pod yet, reserve their memory up front.
+	// Stopped servers are scaled to zero and therefore reserve nothing.
+	statefulSets, err := c.clientset.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{
+		LabelSelector: ManagedByLabel + "=" + OperatorName,
+	})
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+
+	for _, sts := range statefulSets.Items {
+		desired := int32(1)
+		if sts.Spec.Replicas != nil {
+			desired = *sts.Spec.Replicas
+		}
+
+		missing := desired - activePods[sts.Namespace+"/"+sts.Name]
+		if missing > 0 {
+			allocated += int64(missing) * podMemoryRequests(&sts.Spec.Template.Spec)
+		}
+	}
+
 	available := total - allocated
 	return total, allocated, available, nil
 }
@@ -191,6 +433,17 @@ func (c *Client) CheckMemoryAvailability(ctx context.Context, requestedMemory in
 	return true, "", nil
 }
 
+// podMemoryRequests sums the memory requests of all containers in a pod spec
+func podMemoryRequests(spec *corev1.PodSpec) int64 {
+	var total int64
+	for _, container := range spec.Containers {
+		if memory, ok := container.Resources.Requests["memory"]; ok {
+			total += memory.Value()
+		}
+	}
+	return total
+}
+
 // bytesToHumanReadable converts bytes to human-readable format
 func bytesToHumanReadable(bytes int64) string {
 	const unit = 1024
@@ -2,12 +2,25 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/rcon"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 )
 
 func TestGetClusterMemoryResources(t *testing.T) {
@@ -343,6 +356,125 @@ func TestGetClusterMemoryResources(t *testing.T) {
 	}
 }
 
+func TestGetClusterMemoryResources_ServerStatefulSets(t *testing.T) {
+	replicas := func(n int32) *int32 { return &n }
+	serverStatefulSet := func(name string, desired *int32) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "minecraft-servers",
+				Labels:    map[string]string{ManagedByLabel: OperatorName},
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: desired,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "minecraft",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{
+										"memory": resource.MustParse("2Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				"memory": resource.MustParse("8Gi"),
+			},
+		},
+	}
+
+	// Pod of the running server, owned by its StatefulSet
+	runningPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "running-0",
+			Namespace: "minecraft-servers",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "StatefulSet", Name: "running"},
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "minecraft",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							"memory": resource.MustParse("2Gi"),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	fakeClientset := fake.NewSimpleClientset(
+		node,
+		runningPod,
+		serverStatefulSet("running", replicas(1)),
+		serverStatefulSet("stopped", replicas(0)),
+		serverStatefulSet("starting", replicas(1)),
+	)
+
+	client := &Client{
+		clientset: fakeClientset,
+	}
+
+	total, allocated, available, err := client.GetClusterMemoryResources(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterMemoryResources() error = %v", err)
+	}
+
+	// running pod (2Gi) + starting server without pod yet (2Gi), the stopped server reserves nothing
+	wantAllocated := int64(4294967296)
+	if total != 8589934592 {
+		t.Errorf("GetClusterMemoryResources() total = %d, want %d", total, int64(8589934592))
+	}
+	if allocated != wantAllocated {
+		t.Errorf("GetClusterMemoryResources() allocated = %s, want %s",
+			bytesToHumanReadable(allocated), bytesToHumanReadable(wantAllocated))
+	}
+	if available != total-wantAllocated {
+		t.Errorf("GetClusterMemoryResources() available = %s, want %s",
+			bytesToHumanReadable(available), bytesToHumanReadable(total-wantAllocated))
+	}
+}
+
+// TestCheckMemoryAvailability_APICalls lists the calls of the capacity check, which the roles of
+// everything running it (the API and the wake proxies) have to allow
+func TestCheckMemoryAvailability_APICalls(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset()
+	client := &Client{clientset: fakeClientset}
+
+	if _, _, err := client.CheckMemoryAvailability(context.Background(), 1024); err != nil {
+		t.Fatalf("CheckMemoryAvailability() error = %v", err)
+	}
+
+	var got []string
+	for _, action := range fakeClientset.Actions() {
+		resource := action.GetResource()
+		got = append(got, fmt.Sprintf("%s %s/%s in %q", action.GetVerb(), resource.Group, resource.Resource, action.GetNamespace()))
+	}
+	want := []string{
+		`list /nodes in ""`,
+		`list /pods in ""`,
+		`list apps/statefulsets in ""`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("CheckMemoryAvailability() calls = %q, want %q", got, want)
+	}
+}
+
 func TestCheckMemoryAvailability(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -524,6 +656,403 @@ func TestCheckMemoryAvailability(t *testing.T) {
 	}
 }
 
+// newTestCRDClient creates a Client whose custom resource calls are served by the given handler
+func newTestCRDClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClientForConfig(&rest.Config{Host: server.URL}, fake.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestUpdateMinecraftServer(t *testing.T) {
+	var gotMethod, gotPath string
+	var gotServer v1alpha1.MinecraftServer
+
+	client := newTestCRDClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotServer); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+
+		gotServer.ResourceVersion = "2"
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(gotServer)
+	}))
+
+	server := &v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test-server",
+			Namespace:       "minecraft-servers",
+			ResourceVersion: "1",
+		},
+		Spec: v1alpha1.MinecraftServerSpec{
+			EULA:   true,
+			Memory: "4Gi",
+		},
+	}
+
+	result, err := client.UpdateMinecraftServer(context.Background(), "minecraft-servers", server)
+	if err != nil {
+		t.Fatalf("UpdateMinecraftServer() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("UpdateMinecraftServer() method = %s, want PUT", gotMethod)
+	}
+	wantPath := "/apis/homecraft.io/v1alpha1/namespaces/minecraft-servers/minecraftservers/test-server"
+	if gotPath != wantPath {
+		t.Errorf("UpdateMinecraftServer() path = %s, want %s", gotPath, wantPath)
+	}
+	if gotServer.ResourceVersion != "2" || gotServer.Spec.Memory != "4Gi" {
+		t.Errorf("UpdateMinecraftServer() sent unexpected body: %+v", gotServer)
+	}
+	if result.ResourceVersion != "2" {
+		t.Errorf("UpdateMinecraftServer() resourceVersion = %s, want 2", result.ResourceVersion)
+	}
+}
+
+func TestUpdateMinecraftServer_Conflict(t *testing.T) {
+	client := newTestCRDClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := apierrors.NewConflict(v1alpha1.Resource("minecraftservers"), "test-server", fmt.Errorf("object has been modified"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(status.ErrStatus)
+	}))
+
+	server := &v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "minecraft-servers"},
+	}
+
+	_, err := client.UpdateMinecraftServer(context.Background(), "minecraft-servers", server)
+	if !apierrors.IsConflict(err) {
+		t.Errorf("UpdateMinecraftServer() error = %v, want conflict", err)
+	}
+}
+
+func TestWatchMinecraftServers(t *testing.T) {
+	var gotQuery url.Values
+
+	client := newTestCRDClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		for _, event := range []struct {
+			eventType string
+			phase     string
+			version   string
+		}{
+			{"ADDED", "Pending", "11"},
+			{"MODIFIED", "Running", "12"},
+		} {
+			server := v1alpha1.MinecraftServer{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "homecraft.io/v1alpha1", Kind: "MinecraftServer"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "minecraft-servers", ResourceVersion: event.version},
+				Status:     v1alpha1.MinecraftServerStatus{Phase: event.phase},
+			}
+			_ = encoder.Encode(map[string]interface{}{"type": event.eventType, "object": server})
+		}
+	}))
+
+	watcher, err := client.WatchMinecraftServers(context.Background(), "minecraft-servers", "10")
+	if err != nil {
+		t.Fatalf("WatchMinecraftServers() error = %v", err)
+	}
+	defer watcher.Stop()
+
+	if gotQuery.Get("watch") != "true" || gotQuery.Get("resourceVersion") != "10" {
+		t.Errorf("WatchMinecraftServers() query = %v, want watch=true and resourceVersion=10", gotQuery)
+	}
+
+	wantEvents := []struct {
+		eventType watch.EventType
+		phase     string
+		version   string
+	}{
+		{watch.Added, "Pending", "11"},
+		{watch.Modified, "Running", "12"},
+	}
+	for _, want := range wantEvents {
+		event, ok := <-watcher.ResultChan()
+		if !ok {
+			t.Fatalf("watch closed, want %s event", want.eventType)
+		}
+		server, isServer := event.Object.(*v1alpha1.MinecraftServer)
+		if event.Type != want.eventType || !isServer {
+			t.Fatalf("event = %s %#v, want %s *MinecraftServer", event.Type, event.Object, want.eventType)
+		}
+		if server.Status.Phase != want.phase || server.ResourceVersion != want.version {
+			t.Errorf("event server phase = %s, resourceVersion = %s, want %s, %s",
+				server.Status.Phase, server.ResourceVersion, want.phase, want.version)
+		}
+	}
+
+	if _, ok := <-watcher.ResultChan(); ok {
+		t.Error("watch still open after the response ended")
+	}
+}
+
+func TestListMinecraftBackups(t *testing.T) {
+	var gotPath string
+	var gotQuery url.Values
+
+	client := newTestCRDClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.Query()
+
+		list := v1alpha1.MinecraftBackupList{
+			TypeMeta: metav1.TypeMeta{APIVersion: "homecraft.io/v1alpha1", Kind: "MinecraftBackupList"},
+			Items: []v1alpha1.MinecraftBackup{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-server-20261016-120000", Namespace: "minecraft-servers"},
+					Spec:       v1alpha1.MinecraftBackupSpec{ServerName: "test-server"},
+					Status:     v1alpha1.MinecraftBackupStatus{Phase: v1alpha1.BackupPhaseCompleted, Size: 1024},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	}))
+
+	result, err := client.ListMinecraftBackups(context.Background(), "minecraft-servers", "test-server")
+	if err != nil {
+		t.Fatalf("ListMinecraftBackups() error = %v", err)
+	}
+
+	wantPath := "/apis/homecraft.io/v1alpha1/namespaces/minecraft-servers/minecraftbackups"
+	if gotPath != wantPath {
+		t.Errorf("ListMinecraftBackups() path = %s, want %s", gotPath, wantPath)
+	}
+	if gotQuery.Get("labelSelector") != "homecraft.io/server=test-server" {
+		t.Errorf("ListMinecraftBackups() labelSelector = %q, want homecraft.io/server=test-server", gotQuery.Get("labelSelector"))
+	}
+	if len(result.Items) != 1 || result.Items[0].Status.Size != 1024 {
+		t.Errorf("ListMinecraftBackups() items = %+v", result.Items)
+	}
+}
+
+func TestCreateMinecraftRestore(t *testing.T) {
+	var gotMethod, gotPath string
+	var got v1alpha1.MinecraftRestore
+
+	client := newTestCRDClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(got)
+	}))
+
+	restore := &v1alpha1.MinecraftRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-restore-20261016-130000", Namespace: "minecraft-servers"},
+		Spec: v1alpha1.MinecraftRestoreSpec{
+			BackupName: "test-server-20261016-120000",
+			ServerName: "test-server",
+		},
+	}
+
+	result, err := client.CreateMinecraftRestore(context.Background(), "minecraft-servers", restore)
+	if err != nil {
+		t.Fatalf("CreateMinecraftRestore() error = %v", err)
+	}
+
+	wantPath := "/apis/homecraft.io/v1alpha1/namespaces/minecraft-servers/minecraftrestores"
+	if gotMethod != http.MethodPost || gotPath != wantPath {
+		t.Errorf("CreateMinecraftRestore() request = %s %s, want POST %s", gotMethod, gotPath, wantPath)
+	}
+	if got.Spec.BackupName != "test-server-20261016-120000" {
+		t.Errorf("CreateMinecraftRestore() sent backup %q", got.Spec.BackupName)
+	}
+	if result.Spec.ServerName != "test-server" {
+		t.Errorf("CreateMinecraftRestore() server = %q, want test-server", result.Spec.ServerName)
+	}
+}
+
+func TestGetRCONConnection(t *testing.T) {
+	runningPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-0", Namespace: "minecraft-servers"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.42.0.15"},
+	}
+	pendingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-0", Namespace: "minecraft-servers"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-sftp", Namespace: "minecraft-servers"},
+		Data:       map[string][]byte{rcon.PasswordSecretKey: []byte("rcon-secret")},
+	}
+	secretWithoutRCON := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-sftp", Namespace: "minecraft-servers"},
+		Data:       map[string][]byte{"password": []byte("sftp-secret")},
+	}
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		wantAddress  string
+		wantPassword string
+		wantErr      bool
+	}{
+		{
+			name:         "running server",
+			objects:      []runtime.Object{runningPod, secret},
+			wantAddress:  "10.42.0.15:25575",
+			wantPassword: "rcon-secret",
+		},
+		{
+			name:    "pod not running",
+			objects: []runtime.Object{pendingPod, secret},
+			wantErr: true,
+		},
+		{
+			name:    "no pod",
+			objects: []runtime.Object{secret},
+			wantErr: true,
+		},
+		{
+			name:    "secret without rcon password",
+			objects: []runtime.Object{runningPod, secretWithoutRCON},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				clientset: fake.NewSimpleClientset(tt.objects...),
+			}
+
+			address, password, err := client.GetRCONConnection(context.Background(), "minecraft-servers", "test-server")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRCONConnection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if address != tt.wantAddress {
+				t.Errorf("GetRCONConnection() address = %q, want %q", address, tt.wantAddress)
+			}
+			if password != tt.wantPassword {
+				t.Errorf("GetRCONConnection() password = %q, want %q", password, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestGetGameAddress(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-0", Namespace: "minecraft-servers"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.42.0.15"},
+	}
+	client := &Client{clientset: fake.NewSimpleClientset(pod)}
+
+	address, err := client.GetGameAddress(context.Background(), "minecraft-servers", "test-server")
+	if err != nil || address != "10.42.0.15:25565" {
+		t.Errorf("GetGameAddress() = %q, %v; want 10.42.0.15:25565", address, err)
+	}
+
+	if _, err := client.GetGameAddress(context.Background(), "minecraft-servers", "other-server"); err == nil {
+		t.Error("GetGameAddress() expected an error for a server without pod")
+	}
+}
+
+func TestGetSFTPCredentials(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		wantUsername string
+		wantPassword string
+		wantErr      bool
+	}{
+		{
+			name:         "credentials in secret",
+			data:         map[string][]byte{"username": []byte("mc-test-server"), "password": []byte("sftp-secret")},
+			wantUsername: "mc-test-server",
+			wantPassword: "sftp-secret",
+		},
+		{
+			name:    "password not generated yet",
+			data:    map[string][]byte{"username": []byte("mc-test-server")},
+			wantErr: true,
+		},
+		{
+			name:    "no secret",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.data != nil {
+				objects = append(objects, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-server-sftp", Namespace: "minecraft-servers"},
+					Data:       tt.data,
+				})
+			}
+			client := &Client{
+				clientset: fake.NewSimpleClientset(objects...),
+			}
+
+			username, password, err := client.GetSFTPCredentials(context.Background(), "minecraft-servers", "test-server")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSFTPCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if username != tt.wantUsername || password != tt.wantPassword {
+				t.Errorf("GetSFTPCredentials() = %q, %q, want %q, %q", username, password, tt.wantUsername, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestRotateSFTPPassword(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-sftp", Namespace: "minecraft-servers"},
+		Data: map[string][]byte{
+			"username":             []byte("mc-test-server"),
+			"password":             []byte("leaked-password"),
+			rcon.PasswordSecretKey: []byte("rcon-secret"),
+		},
+	}
+	clientset := fake.NewSimpleClientset(secret)
+	client := &Client{clientset: clientset}
+	rotatedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	username, err := client.RotateSFTPPassword(context.Background(), "minecraft-servers", "test-server", "new-password", rotatedAt)
+	if err != nil {
+		t.Fatalf("RotateSFTPPassword() error = %v", err)
+	}
+	if username != "mc-test-server" {
+		t.Errorf("RotateSFTPPassword() username = %q, want mc-test-server", username)
+	}
+
+	updated, err := clientset.CoreV1().Secrets("minecraft-servers").Get(context.Background(), "test-server-sftp", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if string(updated.Data["password"]) != "new-password" {
+		t.Errorf("password = %q, want new-password", updated.Data["password"])
+	}
+	if string(updated.Data[rcon.PasswordSecretKey]) != "rcon-secret" {
+		t.Error("the RCON password should not change")
+	}
+	if got := updated.Annotations[v1alpha1.SFTPPasswordRotatedAnnotation]; got != "2026-10-16T12:00:00Z" {
+		t.Errorf("rotation annotation = %q, want 2026-10-16T12:00:00Z", got)
+	}
+
+	if _, err := client.RotateSFTPPassword(context.Background(), "minecraft-servers", "missing", "new-password", rotatedAt); err == nil {
+		t.Error("RotateSFTPPassword() of a server without secret should fail")
+	}
+}
+
 func TestBytesToHumanReadable(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -2,38 +2,242 @@ package models
 
 // CreateServerRequest represents the request to create a new Minecraft server
 type CreateServerRequest struct {
-	Name           string `json:"name" binding:"required"`
-	EULA           bool   `json:"eula"`
-	Memory         string `json:"memory" binding:"required"` // Required: RAM allocation (e.g., "2Gi", "4Gi")
-	StorageSize    string `json:"storageSize"`
-	Version        string `json:"version"`
-	ServerType     string `json:"serverType"`
-	MaxPlayers     int    `json:"maxPlayers"`
-	Difficulty     string `json:"difficulty"`
-	Gamemode       string `json:"gamemode"`
-	PublicEndpoint string `json:"publicEndpoint"` // Optional: Public endpoint (e.g., Playit tunnel)
+	Name                string          `json:"name" binding:"required"`
+	EULA                bool            `json:"eula"`
+	Memory              string          `json:"memory" binding:"required"` // Required: RAM allocation (e.g., "2Gi", "4Gi")
+	StorageSize         string          `json:"storageSize"`
+	Version             string          `json:"version"`
+	ServerType          string          `json:"serverType"`
+	MaxPlayers          int             `json:"maxPlayers"`
+	Difficulty          string          `json:"difficulty"`
+	Gamemode            string          `json:"gamemode"`
+	PublicEndpoint      string          `json:"publicEndpoint"`      // Optional: Public endpoint (e.g., Playit tunnel)
+	PublicTunnel        bool            `json:"publicTunnel"`        // Optional: the operator opens a Playit tunnel and reports its address
+	BackupSchedule      *BackupSchedule `json:"backupSchedule"`      // Optional: periodic backups
+	IdleShutdown        *IdleShutdown   `json:"idleShutdown"`        // Optional: stop the server when nobody is online
+	Expose              *Expose         `json:"expose"`              // Optional: how the game and SFTP ports are reachable
+	AuthorizedKeys      []string        `json:"authorizedKeys"`      // Optional: SSH public keys that can log in over SFTP
+	DisableSFTPPassword bool            `json:"disableSFTPPassword"` // Optional: only the authorized keys can log in over SFTP
+}
+
+// BackupSchedule represents when a server is backed up and which scheduled backups are kept
+type BackupSchedule struct {
+	Schedule   string `json:"schedule"`             // Cron expression in UTC (e.g., "0 4 * * *"), empty to remove the schedule
+	KeepLast   int    `json:"keepLast,omitempty"`   // Keep the newest N scheduled backups
+	KeepDaily  int    `json:"keepDaily,omitempty"`  // Keep the newest scheduled backup of each of the last N days
+	KeepWeekly int    `json:"keepWeekly,omitempty"` // Keep the newest scheduled backup of each of the last N weeks
+}
+
+// IdleShutdown represents how long a server may run without players before it is stopped
+type IdleShutdown struct {
+	TimeoutMinutes int  `json:"timeoutMinutes"`          // Minutes without players before the server is stopped, 0 to remove the policy
+	WakeOnConnect  bool `json:"wakeOnConnect,omitempty"` // A player joining the stopped server starts it again
+}
+
+// Expose represents the kind of service the game and SFTP ports of a server are reachable through
+type Expose struct {
+	Game *ServiceExposure `json:"game,omitempty"` // Default: LoadBalancer, or ClusterIP behind the gateway when the operator has one
+	SFTP *ServiceExposure `json:"sftp,omitempty"` // Default: LoadBalancer
+}
+
+// ServiceExposure represents the service of one port of a server
+type ServiceExposure struct {
+	Type     string `json:"type"`               // LoadBalancer, NodePort or ClusterIP
+	NodePort int32  `json:"nodePort,omitempty"` // Fixed port of a NodePort service between 30000 and 32767, picked by the cluster when 0
+}
+
+// UpdateServerRequest represents a partial update of an existing Minecraft server.
+// Only the fields present in the request body are applied to the server.
+type UpdateServerRequest struct {
+	EULA                *bool           `json:"eula"`
+	Memory              *string         `json:"memory"` // RAM allocation (e.g., "2Gi", "4Gi"), re-checked against cluster capacity when increased
+	Version             *string         `json:"version"`
+	ServerType          *string         `json:"serverType"`
+	MaxPlayers          *int            `json:"maxPlayers"`
+	Difficulty          *string         `json:"difficulty"`
+	Gamemode            *string         `json:"gamemode"`
+	PublicEndpoint      *string         `json:"publicEndpoint"`
+	PublicTunnel        *bool           `json:"publicTunnel"`        // Opens or closes the public tunnel
+	BackupSchedule      *BackupSchedule `json:"backupSchedule"`      // Replaces the schedule, an empty schedule removes it
+	IdleShutdown        *IdleShutdown   `json:"idleShutdown"`        // Replaces the idle shutdown policy, a timeout of 0 removes it
+	Expose              *Expose         `json:"expose"`              // Replaces how the ports are exposed, a port left out goes back to the default
+	AuthorizedKeys      *[]string       `json:"authorizedKeys"`      // Replaces the SSH public keys, an empty list removes them
+	DisableSFTPPassword *bool           `json:"disableSFTPPassword"` // Turns SFTP password logins off or back on, off requires authorized keys
 }
 
 // ServerResponse represents a Minecraft server in API responses
 type ServerResponse struct {
-	Name            string `json:"name"`
-	Namespace       string `json:"namespace"`
-	EULA            bool   `json:"eula"`
-	Memory          string `json:"memory"`
-	StorageSize     string `json:"storageSize"`
-	Version         string `json:"version"`
-	ServerType      string `json:"serverType"`
-	MaxPlayers      int    `json:"maxPlayers"`
-	Difficulty      string `json:"difficulty"`
-	Gamemode        string `json:"gamemode"`
-	Phase           string `json:"phase,omitempty"`
-	Endpoint        string `json:"endpoint,omitempty"`
-	PublicEndpoint  string `json:"publicEndpoint,omitempty"`
-	SFTPEndpoint    string `json:"sftpEndpoint,omitempty"`
-	SFTPUsername    string `json:"sftpUsername,omitempty"`
-	SFTPPassword    string `json:"sftpPassword,omitempty"`
-	AllocatedMemory string `json:"allocatedMemory,omitempty"`
-	CreatedAt       string `json:"createdAt,omitempty"`
+	Name                string          `json:"name"`
+	Namespace           string          `json:"namespace"`
+	EULA                bool            `json:"eula"`
+	Memory              string          `json:"memory"`
+	StorageSize         string          `json:"storageSize"`
+	Version             string          `json:"version"`
+	ServerType          string          `json:"serverType"`
+	MaxPlayers          int             `json:"maxPlayers"`
+	Difficulty          string          `json:"difficulty"`
+	Gamemode            string          `json:"gamemode"`
+	Paused              bool            `json:"paused"`
+	Phase               string          `json:"phase,omitempty"`
+	Message             string          `json:"message,omitempty"`    // Why the server is in its phase, e.g. what failed
+	Conditions          []Condition     `json:"conditions,omitempty"` // Observations of the parts a server needs to run
+	Game                *GameStatus     `json:"game,omitempty"`       // What the server reports to status pings while it answers them
+	Endpoint            string          `json:"endpoint,omitempty"`
+	PublicEndpoint      string          `json:"publicEndpoint,omitempty"`
+	PublicTunnel        bool            `json:"publicTunnel,omitempty"` // The operator keeps a public tunnel open, its address is the public endpoint
+	SFTPEndpoint        string          `json:"sftpEndpoint,omitempty"`
+	SFTPUsername        string          `json:"sftpUsername,omitempty"`
+	AllocatedMemory     string          `json:"allocatedMemory,omitempty"`
+	BackupSchedule      *BackupSchedule `json:"backupSchedule,omitempty"`
+	IdleShutdown        *IdleShutdown   `json:"idleShutdown,omitempty"`
+	Expose              *Expose         `json:"expose,omitempty"`
+	EmptySince          string          `json:"emptySince,omitempty"`            // Since when the running server has had nobody online, with an idle shutdown policy
+	StopReason          string          `json:"stopReason,omitempty"`            // Why the operator stopped the server, "Idle" for the idle shutdown policy
+	LastBackupAt        string          `json:"lastBackupAt,omitempty"`          // When the newest completed backup finished
+	SFTPRotatedAt       string          `json:"sftpPasswordRotatedAt,omitempty"` // When the SFTP password was last rotated
+	AuthorizedKeys      []string        `json:"authorizedKeys,omitempty"`        // SSH public keys that can log in over SFTP
+	DisableSFTPPassword bool            `json:"disableSFTPPassword,omitempty"`   // Only the authorized keys can log in over SFTP
+	Owner               string          `json:"owner,omitempty"`                 // Username of the user who created the server
+	CreatedAt           string          `json:"createdAt,omitempty"`
+}
+
+// Condition represents one observation of a server's state, such as whether its storage is bound
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// GameStatus represents what a running server shows in the multiplayer server list
+type GameStatus struct {
+	MOTD          string `json:"motd"`
+	Version       string `json:"version"` // Reported by the server, e.g. "Paper 1.21.1"
+	PlayersOnline int    `json:"playersOnline"`
+	PlayersMax    int    `json:"playersMax"`
+	LatencyMs     int64  `json:"latencyMs"`
+	LastPingAt    string `json:"lastPingAt"`
+}
+
+// CredentialsResponse represents the SFTP credentials of a server
+type CredentialsResponse struct {
+	Name          string `json:"name"`
+	SFTPEndpoint  string `json:"sftpEndpoint,omitempty"`
+	SFTPUsername  string `json:"sftpUsername"`
+	SFTPPassword  string `json:"sftpPassword"`
+	SFTPRotatedAt string `json:"sftpPasswordRotatedAt,omitempty"`
+}
+
+// PlayersResponse represents the players online on a server
+type PlayersResponse struct {
+	Name     string   `json:"name"`
+	Online   int      `json:"online"`
+	Max      int      `json:"max"`
+	Players  []Player `json:"players"`
+	Complete bool     `json:"complete"` // False when the server lists only some of the online players
+	Source   string   `json:"source"`   // "rcon" for the list command, "ping" for a status ping sample
+}
+
+// Player represents an online player
+type Player struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"`
+}
+
+// CommandRequest represents a console command to run on a server over RCON
+type CommandRequest struct {
+	Command string `json:"command" binding:"required"` // e.g. "whitelist add Steve", a leading "/" is optional
+}
+
+// CommandResponse represents the output of a console command
+type CommandResponse struct {
+	Command string `json:"command"`
+	Output  string `json:"output"`
+}
+
+// LogsResponse represents the most recent log lines of a server container
+type LogsResponse struct {
+	Name      string   `json:"name"`
+	Container string   `json:"container"`
+	Lines     []string `json:"lines"`
+	// Truncated is set when the since window held more lines than a response returns
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// BackupResponse represents a backup of a Minecraft server in API responses
+type BackupResponse struct {
+	Name        string `json:"name"`
+	ServerName  string `json:"serverName"`
+	Phase       string `json:"phase"` // Pending, Running, Completed or Failed
+	Path        string `json:"path,omitempty"`
+	Size        int64  `json:"size"` // Archive size in bytes
+	Checksum    string `json:"checksum,omitempty"`
+	Message     string `json:"message,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	CompletedAt string `json:"completedAt,omitempty"`
+}
+
+// RestoreRequest represents the request to restore a backup.
+// Without a target name the backup replaces the world of the server it was taken of.
+type RestoreRequest struct {
+	TargetName string `json:"targetName"` // Optional: name of a new server to restore the backup into
+}
+
+// RestoreResponse represents a restore of a backup in API responses
+type RestoreResponse struct {
+	Name        string `json:"name"`
+	ServerName  string `json:"serverName"`
+	BackupName  string `json:"backupName"`
+	Phase       string `json:"phase"` // Pending, Stopping, Restoring, Completed or Failed
+	Message     string `json:"message,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	CompletedAt string `json:"completedAt,omitempty"`
+}
+
+// LoginRequest represents the credentials of a local account
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// CreateUserRequest represents the request to add a local account
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"` // Lowercase letters, digits, '.', '_' or '-'
+	Password string `json:"password" binding:"required"` // At least 8 characters
+	Admin    bool   `json:"admin"`
+}
+
+// UserResponse represents the caller of the API or a local account
+type UserResponse struct {
+	Username string   `json:"username"`
+	Subject  string   `json:"subject,omitempty"`
+	Issuer   string   `json:"issuer,omitempty"` // "local" for local accounts, otherwise the OIDC issuer
+	Email    string   `json:"email,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Admin    bool     `json:"admin"`
+}
+
+// QuotaResponse represents the quota of a user and how much of it is in use
+type QuotaResponse struct {
+	Username string      `json:"username"`
+	Limits   QuotaLimits `json:"limits"`
+	Usage    QuotaUsage  `json:"usage"`
+}
+
+// QuotaLimits represents the limits of a user, a missing limit is unlimited
+type QuotaLimits struct {
+	MaxServers          *int   `json:"maxServers,omitempty"`
+	MaxMemory           string `json:"maxMemory,omitempty"`  // Total memory of all servers, stopped ones included
+	MaxStorage          string `json:"maxStorage,omitempty"` // Total storage of all servers
+	MaxPlayersPerServer *int   `json:"maxPlayersPerServer,omitempty"`
+}
+
+// QuotaUsage represents what the servers of a user take up
+type QuotaUsage struct {
+	Servers int    `json:"servers"`
+	Memory  string `json:"memory"`
+	Storage string `json:"storage"`
 }
 
 // ClusterResourcesResponse represents available cluster resources
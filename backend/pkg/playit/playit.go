@@ -0,0 +1,196 @@
+// Package playit is a client for the parts of the Playit.gg API that manage tunnels. Requests are
+// authenticated with the secret key of a Playit agent, the same one the agent container runs with.
+package playit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the address of the Playit.gg API
+	DefaultBaseURL = "https://api.playit.gg"
+
+	// TunnelTypeMinecraftJava tunnels get a hostname Java edition clients can join without a port
+	TunnelTypeMinecraftJava = "minecraft-java"
+	// AllocStatusAllocated is the status of a tunnel that has been given its public address
+	AllocStatusAllocated = "allocated"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// Client calls the Playit.gg API
+type Client struct {
+	// BaseURL is the API address, DefaultBaseURL when empty
+	BaseURL string
+	// SecretKey is the secret key of the agent the tunnels belong to
+	SecretKey string
+	// HTTPClient sends the requests, a client with a 10 second timeout when nil
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client of the Playit.gg API authenticated with an agent secret key
+func NewClient(secretKey string) *Client {
+	return &Client{SecretKey: secretKey}
+}
+
+// Tunnel is a public address Playit forwards to a local address of an agent
+type Tunnel struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	TunnelType string      `json:"tunnel_type,omitempty"`
+	PortType   string      `json:"port_type"`
+	PortCount  int         `json:"port_count"`
+	Alloc      Allocation  `json:"alloc"`
+	Origin     AgentOrigin `json:"origin"`
+}
+
+// Allocation is the public address of a tunnel, Data is only set once Status is allocated
+type Allocation struct {
+	Status string          `json:"status"`
+	Data   *AllocationData `json:"data,omitempty"`
+}
+
+// AllocationData is where players reach a tunnel
+type AllocationData struct {
+	AssignedDomain string `json:"assigned_domain"`
+	PortStart      int    `json:"port_start"`
+}
+
+// AgentOrigin sends the traffic of a tunnel to a local address of an agent
+type AgentOrigin struct {
+	Type string          `json:"type"`
+	Data AgentOriginData `json:"data"`
+}
+
+// AgentOriginData is the agent and the address on its network a tunnel forwards to
+type AgentOriginData struct {
+	AgentID   string `json:"agent_id"`
+	LocalIP   string `json:"local_ip"`
+	LocalPort int    `json:"local_port"`
+}
+
+// NewAgentOrigin returns the origin forwarding to localIP:localPort from the agent agentID
+func NewAgentOrigin(agentID, localIP string, localPort int) AgentOrigin {
+	return AgentOrigin{Type: "agent", Data: AgentOriginData{AgentID: agentID, LocalIP: localIP, LocalPort: localPort}}
+}
+
+// PublicAddress returns the address players join the tunnel with, or "" while it is being allocated.
+// Minecraft Java tunnels publish an SRV record, so their hostname is enough.
+func (t Tunnel) PublicAddress() string {
+	if t.Alloc.Status != AllocStatusAllocated || t.Alloc.Data == nil || t.Alloc.Data.AssignedDomain == "" {
+		return ""
+	}
+	if t.TunnelType == TunnelTypeMinecraftJava {
+		return t.Alloc.Data.AssignedDomain
+	}
+	return net.JoinHostPort(t.Alloc.Data.AssignedDomain, strconv.Itoa(t.Alloc.Data.PortStart))
+}
+
+// CreateTunnelRequest describes a new tunnel
+type CreateTunnelRequest struct {
+	Name       string      `json:"name"`
+	TunnelType string      `json:"tunnel_type,omitempty"`
+	PortType   string      `json:"port_type"`
+	PortCount  int         `json:"port_count"`
+	Origin     AgentOrigin `json:"origin"`
+	Enabled    bool        `json:"enabled"`
+}
+
+// Error is a request the API refused
+type Error struct {
+	Path    string
+	Status  string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("playit %s: %s: %s", e.Path, e.Status, e.Message)
+}
+
+// response is the envelope of every API response, Data holds the result on success and the
+// reason otherwise
+type response struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// CreateTunnel creates a tunnel and returns its ID
+func (c *Client) CreateTunnel(ctx context.Context, req CreateTunnelRequest) (string, error) {
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.call(ctx, "/tunnels/create", req, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// ListTunnels returns the tunnels of the account the agent belongs to
+func (c *Client) ListTunnels(ctx context.Context) ([]Tunnel, error) {
+	var list struct {
+		Tunnels []Tunnel `json:"tunnels"`
+	}
+	body := map[string]any{"tunnel_id": nil, "agent_id": nil}
+	if err := c.call(ctx, "/tunnels/list", body, &list); err != nil {
+		return nil, err
+	}
+	return list.Tunnels, nil
+}
+
+// DeleteTunnel deletes the tunnel with the given ID
+func (c *Client) DeleteTunnel(ctx context.Context, id string) error {
+	return c.call(ctx, "/tunnels/delete", map[string]string{"tunnel_id": id}, nil)
+}
+
+// call posts body to an API endpoint and decodes the data of a successful response into result
+func (c *Client) call(ctx context.Context, path string, body, result any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Agent-Key "+c.SecretKey)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("playit %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	var envelope response
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("playit %s: unexpected response with status %d: %w", path, resp.StatusCode, err)
+	}
+	if envelope.Status != "success" {
+		// The reason is usually a string such as "TunnelNotFound"
+		message := string(envelope.Data)
+		_ = json.Unmarshal(envelope.Data, &message)
+		return &Error{Path: path, Status: envelope.Status, Message: message}
+	}
+	if result == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, result); err != nil {
+		return fmt.Errorf("playit %s: invalid response data: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package playit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/homecraft/backend/pkg/playit"
+	"github.com/homecraft/backend/pkg/playit/playittest"
+)
+
+func TestClient_TunnelLifecycle(t *testing.T) {
+	server := playittest.NewServer("agent-secret")
+	defer server.Close()
+	client := &playit.Client{BaseURL: server.URL, SecretKey: "agent-secret"}
+	ctx := context.Background()
+
+	id, err := client.CreateTunnel(ctx, playit.CreateTunnelRequest{
+		Name:       "survival",
+		TunnelType: playit.TunnelTypeMinecraftJava,
+		PortType:   "tcp",
+		PortCount:  1,
+		Origin:     playit.NewAgentOrigin("agent-1", "10.43.0.10", 25565),
+		Enabled:    true,
+	})
+	if err != nil {
+		t.Fatalf("CreateTunnel() error = %v", err)
+	}
+
+	tunnels, err := client.ListTunnels(ctx)
+	if err != nil {
+		t.Fatalf("ListTunnels() error = %v", err)
+	}
+	if len(tunnels) != 1 || tunnels[0].ID != id {
+		t.Fatalf("ListTunnels() = %+v, want the created tunnel", tunnels)
+	}
+	if got := tunnels[0].Origin.Data; got.AgentID != "agent-1" || got.LocalIP != "10.43.0.10" || got.LocalPort != 25565 {
+		t.Errorf("origin = %+v", got)
+	}
+	if got := tunnels[0].PublicAddress(); got != "survival.joinmc.link" {
+		t.Errorf("PublicAddress() = %q, want survival.joinmc.link", got)
+	}
+
+	if err := client.DeleteTunnel(ctx, id); err != nil {
+		t.Fatalf("DeleteTunnel() error = %v", err)
+	}
+	var apiErr *playit.Error
+	if err := client.DeleteTunnel(ctx, id); !errors.As(err, &apiErr) || apiErr.Message != "TunnelNotFound" {
+		t.Errorf("deleting a missing tunnel error = %v, want TunnelNotFound", err)
+	}
+}
+
+func TestClient_WrongSecretKey(t *testing.T) {
+	server := playittest.NewServer("agent-secret")
+	defer server.Close()
+	client := &playit.Client{BaseURL: server.URL, SecretKey: "wrong"}
+
+	var apiErr *playit.Error
+	if _, err := client.ListTunnels(context.Background()); !errors.As(err, &apiErr) || apiErr.Message != "AuthRequired" {
+		t.Errorf("ListTunnels() error = %v, want AuthRequired", err)
+	}
+}
+
+func TestTunnel_PublicAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		tunnel playit.Tunnel
+		want   string
+	}{
+		{
+			name:   "pending",
+			tunnel: playit.Tunnel{TunnelType: playit.TunnelTypeMinecraftJava, Alloc: playit.Allocation{Status: "pending"}},
+			want:   "",
+		},
+		{
+			name: "minecraft java",
+			tunnel: playit.Tunnel{TunnelType: playit.TunnelTypeMinecraftJava, Alloc: playit.Allocation{
+				Status: playit.AllocStatusAllocated,
+				Data:   &playit.AllocationData{AssignedDomain: "abc.joinmc.link", PortStart: 41234},
+			}},
+			want: "abc.joinmc.link",
+		},
+		{
+			name: "plain tcp",
+			tunnel: playit.Tunnel{Alloc: playit.Allocation{
+				Status: playit.AllocStatusAllocated,
+				Data:   &playit.AllocationData{AssignedDomain: "abc.gl.at.ply.gg", PortStart: 41234},
+			}},
+			want: "abc.gl.at.ply.gg:41234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tunnel.PublicAddress(); got != tt.want {
+				t.Errorf("PublicAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package playittest provides an in-process fake of the Playit.gg tunnel API for testing clients.
+package playittest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/homecraft/backend/pkg/playit"
+)
+
+// Server is a fake Playit.gg API serving the tunnel endpoints of one account. Tunnels are
+// allocated as <name>.joinmc.link as soon as they are created.
+type Server struct {
+	// URL is the base URL to point playit.Client at
+	URL string
+
+	secretKey string
+	server    *httptest.Server
+
+	mu       sync.Mutex
+	nextID   int
+	tunnels  []playit.Tunnel
+	requests map[string]int
+}
+
+// NewServer starts a fake API accepting requests authenticated with secretKey
+func NewServer(secretKey string) *Server {
+	s := &Server{secretKey: secretKey, nextID: 1, requests: map[string]int{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tunnels/create", s.create)
+	mux.HandleFunc("POST /tunnels/list", s.list)
+	mux.HandleFunc("POST /tunnels/delete", s.delete)
+	s.server = httptest.NewServer(s.authenticate(mux))
+	s.URL = s.server.URL
+	return s
+}
+
+// Tunnels returns the tunnels that currently exist, in creation order
+func (s *Server) Tunnels() []playit.Tunnel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]playit.Tunnel(nil), s.tunnels...)
+}
+
+// Requests returns how many authenticated requests were made to path, such as "/tunnels/list"
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Agent-Key ")
+		if !ok || key != s.secretKey {
+			reply(w, http.StatusUnauthorized, "error", "AuthRequired")
+			return
+		}
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var req playit.CreateTunnelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reply(w, http.StatusBadRequest, "error", "InvalidBody")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tunnel := range s.tunnels {
+		if tunnel.Name == req.Name {
+			reply(w, http.StatusBadRequest, "fail", "TunnelNameTaken")
+			return
+		}
+	}
+	tunnel := playit.Tunnel{
+		ID:         fmt.Sprintf("tunnel-%d", s.nextID),
+		Name:       req.Name,
+		TunnelType: req.TunnelType,
+		PortType:   req.PortType,
+		PortCount:  req.PortCount,
+		Alloc: playit.Allocation{
+			Status: playit.AllocStatusAllocated,
+			Data:   &playit.AllocationData{AssignedDomain: req.Name + ".joinmc.link", PortStart: 20000 + s.nextID},
+		},
+		Origin: req.Origin,
+	}
+	s.nextID++
+	s.tunnels = append(s.tunnels, tunnel)
+	reply(w, http.StatusOK, "success", map[string]string{"id": tunnel.ID})
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply(w, http.StatusOK, "success", map[string]any{"tunnels": append([]playit.Tunnel{}, s.tunnels...)})
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TunnelID string `json:"tunnel_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reply(w, http.StatusBadRequest, "error", "InvalidBody")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, tunnel := range s.tunnels {
+		if tunnel.ID == req.TunnelID {
+			s.tunnels = append(s.tunnels[:i], s.tunnels[i+1:]...)
+			reply(w, http.StatusOK, "success", nil)
+			return
+		}
+	}
+	reply(w, http.StatusBadRequest, "fail", "TunnelNotFound")
+}
+
+func reply(w http.ResponseWriter, code int, status string, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{"status": status, "data": data})
+}
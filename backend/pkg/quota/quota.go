@@ -0,0 +1,185 @@
+package quota
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// Limits caps the servers of one user. A nil limit is not enforced.
+type Limits struct {
+	// MaxServers is the number of servers the user can own
+	MaxServers *int `json:"maxServers,omitempty"`
+	// MaxMemory is the total memory of all servers of the user, stopped ones included
+	MaxMemory *resource.Quantity `json:"maxMemory,omitempty"`
+	// MaxStorage is the total world storage of all servers of the user
+	MaxStorage *resource.Quantity `json:"maxStorage,omitempty"`
+	// MaxPlayersPerServer is the highest maxPlayers a server of the user can have
+	MaxPlayersPerServer *int `json:"maxPlayersPerServer,omitempty"`
+}
+
+// Config assigns limits to users. Field by field, a user's own entry wins over the most generous
+// of their groups, each completed with the defaults, so joining a group never lowers a limit.
+type Config struct {
+	Default Limits            `json:"default"`
+	Groups  map[string]Limits `json:"groups,omitempty"`
+	Users   map[string]Limits `json:"users,omitempty"`
+}
+
+// Usage is what the servers of a user take up
+type Usage struct {
+	Servers int
+	Memory  resource.Quantity
+	Storage resource.Quantity
+}
+
+// Request is what a new or changed server adds to a user's usage
+type Request struct {
+	Servers    int
+	Memory     resource.Quantity
+	Storage    resource.Quantity
+	MaxPlayers int
+}
+
+// ExceededError reports which limit a request exceeds
+type ExceededError struct {
+	Resource string
+	Message  string
+}
+
+func (e *ExceededError) Error() string {
+	return e.Message
+}
+
+// Load reads a quota configuration from a YAML file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quota config: %w", err)
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse quota config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LimitsFor returns the limits of a user. Without a config nothing is limited.
+func (c *Config) LimitsFor(username string, groups []string) Limits {
+	if c == nil {
+		return Limits{}
+	}
+
+	limits := c.Default
+	inGroup := false
+	for _, group := range groups {
+		groupLimits, ok := c.Groups[group]
+		if !ok {
+			continue
+		}
+		// A limit the group doesn't set is the default one
+		groupLimits = override(c.Default, groupLimits)
+		if inGroup {
+			groupLimits = mostGenerous(limits, groupLimits)
+		}
+		limits = groupLimits
+		inGroup = true
+	}
+
+	if userLimits, ok := c.Users[username]; ok {
+		limits = override(limits, userLimits)
+	}
+	return limits
+}
+
+// Check returns an *ExceededError when adding req to usage exceeds one of the limits.
+// Only the limits req asks something of are checked, so a user over a lowered quota can still
+// change what doesn't add to it.
+func (l Limits) Check(usage Usage, req Request) error {
+	if l.MaxServers != nil && req.Servers > 0 && usage.Servers+req.Servers > *l.MaxServers {
+		return &ExceededError{
+			Resource: "servers",
+			Message:  fmt.Sprintf("Server quota exceeded: %d of %d servers in use", usage.Servers, *l.MaxServers),
+		}
+	}
+
+	if l.MaxMemory != nil && !req.Memory.IsZero() {
+		total := usage.Memory.DeepCopy()
+		total.Add(req.Memory)
+		if total.Cmp(*l.MaxMemory) > 0 {
+			return &ExceededError{
+				Resource: "memory",
+				Message:  fmt.Sprintf("Memory quota exceeded: %s requested, %s of %s in use", req.Memory.String(), usage.Memory.String(), l.MaxMemory.String()),
+			}
+		}
+	}
+
+	if l.MaxStorage != nil && !req.Storage.IsZero() {
+		total := usage.Storage.DeepCopy()
+		total.Add(req.Storage)
+		if total.Cmp(*l.MaxStorage) > 0 {
+			return &ExceededError{
+				Resource: "storage",
+				Message:  fmt.Sprintf("Storage quota exceeded: %s requested, %s of %s in use", req.Storage.String(), usage.Storage.String(), l.MaxStorage.String()),
+			}
+		}
+	}
+
+	if l.MaxPlayersPerServer != nil && req.MaxPlayers > *l.MaxPlayersPerServer {
+		return &ExceededError{
+			Resource: "maxPlayers",
+			Message:  fmt.Sprintf("Player quota exceeded: at most %d players per server", *l.MaxPlayersPerServer),
+		}
+	}
+
+	return nil
+}
+
+// override returns base with the limits set in o replacing its own
+func override(base, o Limits) Limits {
+	if o.MaxServers != nil {
+		base.MaxServers = o.MaxServers
+	}
+	if o.MaxMemory != nil {
+		base.MaxMemory = o.MaxMemory
+	}
+	if o.MaxStorage != nil {
+		base.MaxStorage = o.MaxStorage
+	}
+	if o.MaxPlayersPerServer != nil {
+		base.MaxPlayersPerServer = o.MaxPlayersPerServer
+	}
+	return base
+}
+
+// mostGenerous returns the higher of each limit in a and b, where a nil limit is unlimited
+func mostGenerous(a, b Limits) Limits {
+	var result Limits
+	if a.MaxServers != nil && b.MaxServers != nil {
+		result.MaxServers = b.MaxServers
+		if *a.MaxServers > *b.MaxServers {
+			result.MaxServers = a.MaxServers
+		}
+	}
+	if a.MaxMemory != nil && b.MaxMemory != nil {
+		result.MaxMemory = b.MaxMemory
+		if a.MaxMemory.Cmp(*b.MaxMemory) > 0 {
+			result.MaxMemory = a.MaxMemory
+		}
+	}
+	if a.MaxStorage != nil && b.MaxStorage != nil {
+		result.MaxStorage = b.MaxStorage
+		if a.MaxStorage.Cmp(*b.MaxStorage) > 0 {
+			result.MaxStorage = a.MaxStorage
+		}
+	}
+	if a.MaxPlayersPerServer != nil && b.MaxPlayersPerServer != nil {
+		result.MaxPlayersPerServer = b.MaxPlayersPerServer
+		if *a.MaxPlayersPerServer > *b.MaxPlayersPerServer {
+			result.MaxPlayersPerServer = a.MaxPlayersPerServer
+		}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package quota
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const testConfig = `
+default:
+  maxServers: 2
+  maxMemory: 4Gi
+  maxPlayersPerServer: 10
+groups:
+  family:
+    maxServers: 3
+    maxStorage: 20Gi
+  friends:
+    maxServers: 5
+    maxMemory: 2Gi
+users:
+  steve:
+    maxMemory: 16Gi
+`
+
+func loadTestConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quotas.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write quota config: %v", err)
+	}
+	return Load(path)
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := loadTestConfig(t, testConfig); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := loadTestConfig(t, "default:\n  maxServer: 2\n"); err == nil {
+		t.Error("Load() with a misspelled limit should fail")
+	}
+	if _, err := loadTestConfig(t, "default:\n  maxMemory: lots\n"); err == nil {
+		t.Error("Load() with an invalid quantity should fail")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load() of a missing file should fail")
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	cfg, err := loadTestConfig(t, testConfig)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		username       string
+		groups         []string
+		wantServers    int
+		wantMemory     string
+		wantStorage    string
+		wantMaxPlayers int
+	}{
+		{name: "defaults", username: "alex", wantServers: 2, wantMemory: "4Gi", wantMaxPlayers: 10},
+		{name: "group overrides defaults", username: "alex", groups: []string{"family"}, wantServers: 3, wantMemory: "4Gi", wantStorage: "20Gi", wantMaxPlayers: 10},
+		// friends has the default memory and, like the default, no storage limit
+		{name: "most generous group wins", username: "alex", groups: []string{"family", "friends"}, wantServers: 5, wantMemory: "4Gi", wantMaxPlayers: 10},
+		{name: "a group can lower the defaults", username: "alex", groups: []string{"friends"}, wantServers: 5, wantMemory: "2Gi", wantMaxPlayers: 10},
+		{name: "unknown groups are ignored", username: "alex", groups: []string{"strangers"}, wantServers: 2, wantMemory: "4Gi", wantMaxPlayers: 10},
+		{name: "user overrides groups", username: "steve", groups: []string{"friends"}, wantServers: 5, wantMemory: "16Gi", wantMaxPlayers: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := cfg.LimitsFor(tt.username, tt.groups)
+			if limits.MaxServers == nil || *limits.MaxServers != tt.wantServers {
+				t.Errorf("MaxServers = %v, want %d", limits.MaxServers, tt.wantServers)
+			}
+			if limits.MaxMemory == nil || limits.MaxMemory.String() != tt.wantMemory {
+				t.Errorf("MaxMemory = %v, want %s", limits.MaxMemory, tt.wantMemory)
+			}
+			if tt.wantStorage == "" && limits.MaxStorage != nil {
+				t.Errorf("MaxStorage = %v, want unlimited", limits.MaxStorage)
+			}
+			if tt.wantStorage != "" && (limits.MaxStorage == nil || limits.MaxStorage.String() != tt.wantStorage) {
+				t.Errorf("MaxStorage = %v, want %s", limits.MaxStorage, tt.wantStorage)
+			}
+			if limits.MaxPlayersPerServer == nil || *limits.MaxPlayersPerServer != tt.wantMaxPlayers {
+				t.Errorf("MaxPlayersPerServer = %v, want %d", limits.MaxPlayersPerServer, tt.wantMaxPlayers)
+			}
+		})
+	}
+
+	var noConfig *Config
+	if limits := noConfig.LimitsFor("alex", nil); limits != (Limits{}) {
+		t.Errorf("LimitsFor() without config = %+v, want no limits", limits)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	maxServers, maxPlayers := 2, 10
+	maxMemory, maxStorage := resource.MustParse("4Gi"), resource.MustParse("10Gi")
+	limits := Limits{MaxServers: &maxServers, MaxMemory: &maxMemory, MaxStorage: &maxStorage, MaxPlayersPerServer: &maxPlayers}
+
+	usage := Usage{Servers: 1, Memory: resource.MustParse("2Gi"), Storage: resource.MustParse("5Gi")}
+	newServer := func(memory, storage string, players int) Request {
+		return Request{Servers: 1, Memory: resource.MustParse(memory), Storage: resource.MustParse(storage), MaxPlayers: players}
+	}
+
+	tests := []struct {
+		name         string
+		usage        Usage
+		req          Request
+		wantResource string
+	}{
+		{name: "fits exactly", usage: usage, req: newServer("2Gi", "5Gi", 10)},
+		{name: "too many servers", usage: Usage{Servers: 2}, req: newServer("1Gi", "1Gi", 10), wantResource: "servers"},
+		{name: "too much memory", usage: usage, req: newServer("3Gi", "1Gi", 10), wantResource: "memory"},
+		{name: "too much storage", usage: usage, req: newServer("1Gi", "6Gi", 10), wantResource: "storage"},
+		{name: "too many players", usage: usage, req: newServer("1Gi", "1Gi", 20), wantResource: "maxPlayers"},
+		{name: "over quota but adding nothing", usage: Usage{Servers: 3, Memory: resource.MustParse("8Gi")}, req: Request{MaxPlayers: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check(tt.usage, tt.req)
+			var exceeded *ExceededError
+			if tt.wantResource == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v, want none", err)
+				}
+				return
+			}
+			if !errors.As(err, &exceeded) || exceeded.Resource != tt.wantResource {
+				t.Errorf("Check() error = %v, want %s exceeded", err, tt.wantResource)
+			}
+		})
+	}
+
+	if err := (Limits{}).Check(usage, newServer("64Gi", "1Ti", 1000)); err != nil {
+		t.Errorf("Check() without limits error = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort is the port the Minecraft server listens on for RCON connections
+	DefaultPort = 25575
+	// PasswordSecretKey is the key holding the RCON password in the per-server Secret
+	PasswordSecretKey = "rcon-password"
+
+	// MaxCommandLength is the longest command a Minecraft server accepts over RCON
+	MaxCommandLength = 1446
+
+	// Packet types of the Source RCON protocol used by Minecraft
+	TypeResponseValue = 0
+	TypeExecCommand   = 2
+	TypeAuthResponse  = 2
+	TypeAuth          = 3
+
+	// typeEndMarker is an invalid packet type; the server answers it with a single packet,
+	// which tells the client that all fragments of the preceding response have been received
+	typeEndMarker = 100
+
+	maxPacketSize  = 4096 + 10
+	defaultTimeout = 10 * time.Second
+)
+
+// ErrAuthFailed is returned by Dial when the server rejects the password
+var ErrAuthFailed = errors.New("rcon authentication failed")
+
+// Client is a connection to a Minecraft server's RCON port
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	nextID int32
+}
+
+// Packet is a single Source RCON protocol packet
+type Packet struct {
+	ID   int32
+	Type int32
+	Body string
+}
+
+// Dial connects to the RCON server at address and authenticates with password.
+// The context bounds the whole handshake; without a deadline a default timeout is applied.
+func Dial(ctx context.Context, address, password string) (*Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	client := &Client{conn: conn, nextID: 1}
+	if err := client.authenticate(ctx, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (c *Client) authenticate(ctx context.Context, password string) error {
+	c.setDeadline(ctx)
+
+	id := c.newID()
+	if err := WritePacket(c.conn, Packet{ID: id, Type: TypeAuth, Body: password}); err != nil {
+		return fmt.Errorf("failed to send auth packet: %w", err)
+	}
+
+	// Some servers send an empty response value before the auth response
+	for {
+		packet, err := ReadPacket(c.conn)
+		if err != nil {
+			return fmt.Errorf("failed to read auth response: %w", err)
+		}
+		if packet.Type != TypeAuthResponse {
+			continue
+		}
+		if packet.ID == -1 {
+			return ErrAuthFailed
+		}
+		if packet.ID != id {
+			return fmt.Errorf("unexpected auth response id %d", packet.ID)
+		}
+		return nil
+	}
+}
+
+// Command runs a console command and returns its output
+func (c *Client) Command(ctx context.Context, command string) (string, error) {
+	if len(command) > MaxCommandLength {
+		return "", fmt.Errorf("command exceeds %d bytes", MaxCommandLength)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setDeadline(ctx)
+
+	id := c.newID()
+	if err := WritePacket(c.conn, Packet{ID: id, Type: TypeExecCommand, Body: command}); err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+
+	// Long outputs are split over several packets carrying the command id. The vanilla server
+	// drops clients whose packets arrive in a single read, so the end marker is only sent once
+	// the server has started answering the command.
+	var output bytes.Buffer
+	markerID := int32(0)
+	for {
+		packet, err := ReadPacket(c.conn)
+		if err != nil {
+			return "", fmt.Errorf("failed to read command response: %w", err)
+		}
+		switch {
+		case packet.ID == id:
+			output.WriteString(packet.Body)
+			if markerID == 0 {
+				markerID = c.newID()
+				if err := WritePacket(c.conn, Packet{ID: markerID, Type: typeEndMarker}); err != nil {
+					return "", fmt.Errorf("failed to send end marker: %w", err)
+				}
+			}
+		case markerID != 0 && packet.ID == markerID:
+			return output.String(), nil
+		}
+	}
+}
+
+// Close closes the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) newID() int32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+func (c *Client) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	_ = c.conn.SetDeadline(deadline)
+}
+
+// WritePacket encodes a packet onto w
+func WritePacket(w io.Writer, packet Packet) error {
+	// id + type + body + two null terminators
+	length := int32(4 + 4 + len(packet.Body) + 2)
+
+	buf := bytes.NewBuffer(make([]byte, 0, length+4))
+	_ = binary.Write(buf, binary.LittleEndian, length)
+	_ = binary.Write(buf, binary.LittleEndian, packet.ID)
+	_ = binary.Write(buf, binary.LittleEndian, packet.Type)
+	buf.WriteString(packet.Body)
+	buf.Write([]byte{0, 0})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadPacket decodes a single packet from r
+func ReadPacket(r io.Reader) (Packet, error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return Packet{}, err
+	}
+	if length < 10 || length > maxPacketSize {
+		return Packet{}, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return Packet{}, err
+	}
+
+	return Packet{
+		ID:   int32(binary.LittleEndian.Uint32(payload[0:4])),
+		Type: int32(binary.LittleEndian.Uint32(payload[4:8])),
+		Body: string(bytes.TrimRight(payload[8:], "\x00")),
+	}, nil
+}
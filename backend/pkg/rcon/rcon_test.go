@@ -0,0 +1,140 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homecraft/backend/pkg/rcon"
+	"github.com/homecraft/backend/pkg/rcon/rcontest"
+)
+
+func TestCommand(t *testing.T) {
+	server := rcontest.NewServer("secret", func(command string) string {
+		if command == "list" {
+			return "There are 0 of a max of 20 players online: "
+		}
+		return "Unknown command"
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := rcon.Dial(ctx, server.Addr, "secret")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	output, err := client.Command(ctx, "list")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	if output != "There are 0 of a max of 20 players online: " {
+		t.Errorf("Command() output = %q", output)
+	}
+
+	// The connection can be reused for further commands
+	output, err = client.Command(ctx, "foo")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	if output != "Unknown command" {
+		t.Errorf("Command() output = %q, want %q", output, "Unknown command")
+	}
+
+	commands := server.Commands()
+	if len(commands) != 2 || commands[0] != "list" || commands[1] != "foo" {
+		t.Errorf("server received commands %v, want [list foo]", commands)
+	}
+}
+
+func TestCommand_FragmentedResponse(t *testing.T) {
+	longOutput := strings.Repeat("a", 10000)
+	server := rcontest.NewServer("secret", func(command string) string {
+		return longOutput
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := rcon.Dial(ctx, server.Addr, "secret")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	output, err := client.Command(ctx, "help")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	if output != longOutput {
+		t.Errorf("Command() returned %d bytes, want %d", len(output), len(longOutput))
+	}
+}
+
+func TestCommand_OnePacketPerRead(t *testing.T) {
+	// The fake server, like the vanilla one, drops the connection when packets sent back to back
+	// arrive in a single read
+	server := rcontest.NewServer("secret", func(command string) string {
+		return "Saved the game"
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := rcon.Dial(ctx, server.Addr, "secret")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	for _, command := range []string{"save-off", "save-all flush", "save-on"} {
+		output, err := client.Command(ctx, command)
+		if err != nil {
+			t.Fatalf("Command(%q) error = %v", command, err)
+		}
+		if output != "Saved the game" {
+			t.Errorf("Command(%q) output = %q", command, output)
+		}
+	}
+}
+
+func TestDial_WrongPassword(t *testing.T) {
+	server := rcontest.NewServer("secret", nil)
+	defer server.Close()
+
+	_, err := rcon.Dial(context.Background(), server.Addr, "wrong")
+	if !errors.Is(err, rcon.ErrAuthFailed) {
+		t.Errorf("Dial() error = %v, want %v", err, rcon.ErrAuthFailed)
+	}
+}
+
+func TestDial_ConnectionRefused(t *testing.T) {
+	server := rcontest.NewServer("secret", nil)
+	addr := server.Addr
+	server.Close()
+
+	if _, err := rcon.Dial(context.Background(), addr, "secret"); err == nil {
+		t.Error("Dial() expected an error for a closed port")
+	}
+}
+
+func TestCommand_TooLong(t *testing.T) {
+	server := rcontest.NewServer("secret", nil)
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := rcon.Dial(ctx, server.Addr, "secret")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Command(ctx, strings.Repeat("x", rcon.MaxCommandLength+1)); err == nil {
+		t.Error("Command() expected an error for an oversized command")
+	}
+}
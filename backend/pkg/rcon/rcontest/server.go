@@ -0,0 +1,174 @@
+// Package rcontest provides an in-process RCON server for testing RCON clients.
+package rcontest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/homecraft/backend/pkg/rcon"
+)
+
+const (
+	// maxFragmentSize is the largest response body a Minecraft server puts in one packet
+	maxFragmentSize = 4096
+	// readBufferSize is how much the vanilla server reads at once, expecting exactly one packet
+	readBufferSize = 1460
+	// readDelay lets packets a client writes back to back arrive together, as on a busy server
+	readDelay = 20 * time.Millisecond
+)
+
+// HandlerFunc computes the output of a console command
+type HandlerFunc func(command string) string
+
+// Server is a fake Minecraft RCON server listening on a local port
+type Server struct {
+	// Addr is the host:port the server listens on
+	Addr string
+
+	password string
+	handler  HandlerFunc
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands []string
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a fake RCON server accepting password and answering commands with handler
+func NewServer(password string, handler HandlerFunc) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("rcontest: failed to listen: %v", err))
+	}
+
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		password: password,
+		handler:  handler,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Commands returns the commands received so far, in order
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Close stops the server, closes open connections and waits for their handlers to return
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle mimics the vanilla server: every read must hold exactly one packet, commands are
+// rejected until authenticated, long outputs are fragmented and unknown packet types get an
+// "Unknown request" reply.
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	authenticated := false
+	for {
+		packet, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case packet.Type == rcon.TypeAuth:
+			id := packet.ID
+			if packet.Body != s.password {
+				id = -1
+			}
+			authenticated = id != -1
+			if err := rcon.WritePacket(conn, rcon.Packet{ID: id, Type: rcon.TypeAuthResponse}); err != nil {
+				return
+			}
+		case !authenticated:
+			return
+		case packet.Type == rcon.TypeExecCommand:
+			s.mu.Lock()
+			s.commands = append(s.commands, packet.Body)
+			s.mu.Unlock()
+
+			output := ""
+			if s.handler != nil {
+				output = s.handler(packet.Body)
+			}
+			for {
+				fragment := output
+				if len(fragment) > maxFragmentSize {
+					fragment = fragment[:maxFragmentSize]
+				}
+				output = output[len(fragment):]
+				if err := rcon.WritePacket(conn, rcon.Packet{ID: packet.ID, Type: rcon.TypeResponseValue, Body: fragment}); err != nil {
+					return
+				}
+				if output == "" {
+					break
+				}
+			}
+		default:
+			body := fmt.Sprintf("Unknown request %x", packet.Type)
+			if err := rcon.WritePacket(conn, rcon.Packet{ID: packet.ID, Type: rcon.TypeResponseValue, Body: body}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPacket reads a packet the way the vanilla server does, dropping the connection when a read
+// holds more or less than one packet
+func readPacket(conn net.Conn) (rcon.Packet, error) {
+	time.Sleep(readDelay)
+	buf := make([]byte, readBufferSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return rcon.Packet{}, err
+	}
+	if n < 4 || int(int32(binary.LittleEndian.Uint32(buf))) != n-4 {
+		return rcon.Packet{}, fmt.Errorf("rcontest: read %d bytes that are not a single packet", n)
+	}
+	return rcon.ReadPacket(bytes.NewReader(buf[:n]))
+}
@@ -0,0 +1,442 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPort is the port the Minecraft server listens on for players and status pings
+	DefaultPort = 25565
+
+	// Packet ids of the handshaking and status states
+	PacketHandshake      = 0x00
+	PacketStatusRequest  = 0x00
+	PacketStatusResponse = 0x00
+	PacketPing           = 0x01
+	PacketPong           = 0x01
+
+	// Packet ids of the login state
+	PacketLoginStart      = 0x00
+	PacketLoginDisconnect = 0x00
+
+	// Next states a client can ask for in its handshake
+	StateStatus   = 1
+	StateLogin    = 2
+	StateTransfer = 3
+
+	// MaxHandshakeLength bounds the packets read from a client before it is answered or passed
+	// through. Handshakes, login starts and status requests of vanilla and modded clients stay
+	// well below it.
+	MaxHandshakeLength = 4096
+
+	// anyProtocolVersion is sent in status handshakes, servers answer them whatever their version
+	anyProtocolVersion = -1
+
+	maxPacketLength = 2097151
+	maxStringLength = 32767 * 4
+	defaultTimeout  = 10 * time.Second
+)
+
+// Handshake is the first packet a client sends, it says which server it connects to and what for
+type Handshake struct {
+	ProtocolVersion int32
+	ServerAddress   string
+	ServerPort      uint16
+	NextState       int32
+}
+
+// Packet is a packet read from a client, kept to be replayed to the server the client is passed on to
+type Packet struct {
+	ID      int32
+	Payload []byte
+}
+
+// Status is what a server reports in the multiplayer server list
+type Status struct {
+	Version     Version     `json:"version"`
+	Players     Players     `json:"players"`
+	Description Description `json:"description"`
+
+	// Latency is the round trip time of the ping that followed the status request
+	Latency time.Duration `json:"-"`
+}
+
+// Version is the name and protocol version of the game the server runs
+type Version struct {
+	Name     string `json:"name"`
+	Protocol int32  `json:"protocol"`
+}
+
+// Players is the number of online players, with a sample of their names
+type Players struct {
+	Max    int      `json:"max"`
+	Online int      `json:"online"`
+	Sample []Player `json:"sample,omitempty"`
+}
+
+// Player is an online player, ID is their UUID
+type Player struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// Description is the message of the day of a server. Servers send it as a string or a chat
+// component; Text holds its plain text, without formatting codes.
+type Description struct {
+	Text string
+}
+
+// UnmarshalJSON flattens a string or chat component into plain text
+func (d *Description) UnmarshalJSON(data []byte) error {
+	var component any
+	if err := json.Unmarshal(data, &component); err != nil {
+		return err
+	}
+	var text strings.Builder
+	flattenComponent(&text, component)
+	d.Text = StripFormatting(text.String())
+	return nil
+}
+
+// MarshalJSON writes the description as a text component
+func (d Description) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"text": d.Text})
+}
+
+// flattenComponent appends the text of a chat component and its children to text
+func flattenComponent(text *strings.Builder, component any) {
+	switch c := component.(type) {
+	case string:
+		text.WriteString(c)
+	case []any:
+		for _, child := range c {
+			flattenComponent(text, child)
+		}
+	case map[string]any:
+		flattenComponent(text, c["text"])
+		flattenComponent(text, c["extra"])
+	}
+}
+
+// StripFormatting removes the § color and style codes from s
+func StripFormatting(s string) string {
+	if !strings.ContainsRune(s, '§') {
+		return s
+	}
+	var stripped strings.Builder
+	skip := false
+	for _, r := range s {
+		switch {
+		case skip:
+			skip = false
+		case r == '§':
+			skip = true
+		default:
+			stripped.WriteRune(r)
+		}
+	}
+	return stripped.String()
+}
+
+// Ping asks the server at address for its status, the way the multiplayer server list does.
+// The context bounds the whole exchange; without a deadline a default timeout is applied.
+func Ping(ctx context.Context, address string) (*Status, error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portString)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	handshake := Handshake{
+		ProtocolVersion: anyProtocolVersion,
+		ServerAddress:   host,
+		ServerPort:      uint16(port),
+		NextState:       StateStatus,
+	}
+	if err := WritePacket(conn, PacketHandshake, handshake.Marshal()); err != nil {
+		return nil, fmt.Errorf("failed to send handshake: %w", err)
+	}
+	if err := WritePacket(conn, PacketStatusRequest, nil); err != nil {
+		return nil, fmt.Errorf("failed to send status request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	id, payload, err := ReadPacket(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status response: %w", err)
+	}
+	if id != PacketStatusResponse {
+		return nil, fmt.Errorf("unexpected packet %#x instead of the status response", id)
+	}
+	response, err := ReadString(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("invalid status response: %w", err)
+	}
+	status := &Status{}
+	if err := json.Unmarshal([]byte(response), status); err != nil {
+		return nil, fmt.Errorf("invalid status response: %w", err)
+	}
+
+	payload = binary.BigEndian.AppendUint64(nil, rand.Uint64())
+	sent := time.Now()
+	if err := WritePacket(conn, PacketPing, payload); err != nil {
+		return nil, fmt.Errorf("failed to send ping: %w", err)
+	}
+	id, pong, err := ReadPacket(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pong: %w", err)
+	}
+	if id != PacketPong || !bytes.Equal(pong, payload) {
+		return nil, errors.New("the pong does not match the ping")
+	}
+	status.Latency = time.Since(sent)
+
+	return status, nil
+}
+
+// Marshal encodes the handshake as the payload of a handshake packet
+func (h Handshake) Marshal() []byte {
+	var buf bytes.Buffer
+	_ = WriteVarInt(&buf, h.ProtocolVersion)
+	_ = WriteString(&buf, h.ServerAddress)
+	_ = binary.Write(&buf, binary.BigEndian, h.ServerPort)
+	_ = WriteVarInt(&buf, h.NextState)
+	return buf.Bytes()
+}
+
+// ParseHandshake decodes the payload of a handshake packet
+func ParseHandshake(payload []byte) (Handshake, error) {
+	r := bytes.NewReader(payload)
+	var h Handshake
+	var err error
+	if h.ProtocolVersion, err = ReadVarInt(r); err != nil {
+		return Handshake{}, fmt.Errorf("invalid protocol version: %w", err)
+	}
+	if h.ServerAddress, err = ReadString(r); err != nil {
+		return Handshake{}, fmt.Errorf("invalid server address: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.ServerPort); err != nil {
+		return Handshake{}, fmt.Errorf("invalid server port: %w", err)
+	}
+	if h.NextState, err = ReadVarInt(r); err != nil {
+		return Handshake{}, fmt.Errorf("invalid next state: %w", err)
+	}
+	return h, nil
+}
+
+// ReadHandshake reads the handshake a client opens its connection with, bounded by
+// MaxHandshakeLength since the client is not known yet. The packet is returned too so it can be
+// replayed to the server.
+func ReadHandshake(r io.ByteReader) (Handshake, Packet, error) {
+	id, payload, err := ReadPacketLimit(r, MaxHandshakeLength)
+	if err != nil {
+		return Handshake{}, Packet{}, err
+	}
+	if id != PacketHandshake {
+		return Handshake{}, Packet{}, fmt.Errorf("unexpected packet %d instead of a handshake", id)
+	}
+	handshake, err := ParseHandshake(payload)
+	if err != nil {
+		return Handshake{}, Packet{}, err
+	}
+	return handshake, Packet{ID: id, Payload: payload}, nil
+}
+
+// Splice replays the packets read from client to server and then copies the rest of the
+// connection both ways until either side closes it. The client is read through reader, which
+// holds what was buffered after those packets. The caller closes both connections.
+func Splice(client net.Conn, reader io.Reader, server net.Conn, received ...Packet) error {
+	for _, packet := range received {
+		if err := WritePacket(server, packet.ID, packet.Payload); err != nil {
+			return err
+		}
+	}
+	_ = client.SetReadDeadline(time.Time{})
+
+	// Either side closing ends the splice, the caller's closes stop the other copy
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(server, reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, server)
+		done <- struct{}{}
+	}()
+	<-done
+	return nil
+}
+
+// WriteStatusResponse answers a status request with status, the way servers do
+func WriteStatusResponse(w io.Writer, status Status) error {
+	response, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	_ = WriteString(&buf, string(response))
+	return WritePacket(w, PacketStatusResponse, buf.Bytes())
+}
+
+// AnswerStatus serves a connection whose handshake asked for the status state: the status request
+// is answered with status and the ping with a pong, after which the connection can be closed
+func AnswerStatus(w io.Writer, r io.ByteReader, status Status) error {
+	for {
+		id, payload, err := ReadPacketLimit(r, MaxHandshakeLength)
+		if err != nil {
+			return err
+		}
+		switch id {
+		case PacketStatusRequest:
+			if err := WriteStatusResponse(w, status); err != nil {
+				return err
+			}
+		case PacketPing:
+			return WritePacket(w, PacketPong, payload)
+		default:
+			return fmt.Errorf("unexpected packet %d in the status state", id)
+		}
+	}
+}
+
+// WriteLoginDisconnect refuses a login, the client shows reason on its disconnect screen
+func WriteLoginDisconnect(w io.Writer, reason string) error {
+	message, err := json.Marshal(map[string]string{"text": reason})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	_ = WriteString(&buf, string(message))
+	return WritePacket(w, PacketLoginDisconnect, buf.Bytes())
+}
+
+// WritePacket writes a length-prefixed packet with id and payload onto w
+func WritePacket(w io.Writer, id int32, payload []byte) error {
+	var body bytes.Buffer
+	_ = WriteVarInt(&body, id)
+	body.Write(payload)
+
+	var packet bytes.Buffer
+	_ = WriteVarInt(&packet, int32(body.Len()))
+	packet.Write(body.Bytes())
+
+	_, err := w.Write(packet.Bytes())
+	return err
+}
+
+// ReadPacket reads a single length-prefixed packet from r and returns its id and payload
+func ReadPacket(r io.ByteReader) (int32, []byte, error) {
+	return ReadPacketLimit(r, maxPacketLength)
+}
+
+// ReadPacketLimit reads a packet like ReadPacket, refusing one longer than maxLength before its
+// buffer is allocated. Packets of clients that are not trusted yet are read with it.
+func ReadPacketLimit(r io.ByteReader, maxLength int) (int32, []byte, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length < 1 || int64(length) > int64(min(maxLength, maxPacketLength)) {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	data := make([]byte, length)
+	for i := range data {
+		if data[i], err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	body := bytes.NewReader(data)
+	id, err := ReadVarInt(body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid packet id: %w", err)
+	}
+	return id, data[len(data)-body.Len():], nil
+}
+
+// WriteVarInt writes value in the variable length encoding of the protocol
+func WriteVarInt(w io.Writer, value int32) error {
+	var buf [5]byte
+	n := 0
+	v := uint32(value)
+	for {
+		if v&^0x7f == 0 {
+			buf[n] = byte(v)
+			n++
+			break
+		}
+		buf[n] = byte(v&0x7f) | 0x80
+		n++
+		v >>= 7
+	}
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// ReadVarInt reads a value in the variable length encoding of the protocol
+func ReadVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+	return 0, errors.New("varint is too long")
+}
+
+// WriteString writes a length-prefixed UTF-8 string
+func WriteString(w io.Writer, s string) error {
+	if err := WriteVarInt(w, int32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// ReadString reads a length-prefixed UTF-8 string
+func ReadString(r *bytes.Reader) (string, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || length > maxStringLength || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+	buf := make([]byte, length)
+	_, _ = r.Read(buf)
+	return string(buf), nil
+}
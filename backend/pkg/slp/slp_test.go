@@ -0,0 +1,183 @@
+package slp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/homecraft/backend/pkg/slp"
+	"github.com/homecraft/backend/pkg/slp/slptest"
+)
+
+func TestPing(t *testing.T) {
+	server := slptest.NewServer(slp.Status{
+		Version: slp.Version{Name: "Paper 1.21.1", Protocol: 767},
+		Players: slp.Players{
+			Max:    20,
+			Online: 2,
+			Sample: []slp.Player{
+				{Name: "Steve", ID: "8667ba71-b85a-4004-af54-457a9734eed7"},
+				{Name: "Alex", ID: "ec561538-f3fd-461d-aff5-086b22154bce"},
+			},
+		},
+		Description: slp.Description{Text: "A HomeCraft Server"},
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := slp.Ping(ctx, server.Addr)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if status.Version.Name != "Paper 1.21.1" || status.Version.Protocol != 767 {
+		t.Errorf("version = %+v", status.Version)
+	}
+	if status.Players.Online != 2 || status.Players.Max != 20 || len(status.Players.Sample) != 2 || status.Players.Sample[0].Name != "Steve" {
+		t.Errorf("players = %+v", status.Players)
+	}
+	if status.Description.Text != "A HomeCraft Server" {
+		t.Errorf("description = %q", status.Description.Text)
+	}
+	if status.Latency <= 0 {
+		t.Errorf("latency = %v, want a measured round trip", status.Latency)
+	}
+
+	host, port, _ := net.SplitHostPort(server.Addr)
+	handshakes := server.Handshakes()
+	if len(handshakes) != 1 || handshakes[0].ServerAddress != host || handshakes[0].NextState != slp.StateStatus {
+		t.Errorf("server received handshakes %+v, want a status handshake for %s:%s", handshakes, host, port)
+	}
+}
+
+func TestPing_ConnectionRefused(t *testing.T) {
+	server := slptest.NewServer(slp.Status{})
+	addr := server.Addr
+	server.Close()
+
+	if _, err := slp.Ping(context.Background(), addr); err == nil {
+		t.Error("Ping() expected an error for a closed port")
+	}
+}
+
+func TestPing_NoResponse(t *testing.T) {
+	// A server still loading its world accepts connections without answering them
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := slp.Ping(ctx, listener.Addr().String()); err == nil {
+		t.Error("Ping() expected an error when the server doesn't answer")
+	}
+}
+
+func TestDescription_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{name: "string", json: `"A Minecraft Server"`, want: "A Minecraft Server"},
+		{name: "formatting codes", json: `"§aGreen §lbold§r text"`, want: "Green bold text"},
+		{name: "component", json: `{"text":"Hello ","extra":[{"text":"world","color":"gold"},"!"]}`, want: "Hello world!"},
+		{name: "array", json: `[{"text":"Survival"},{"text":" 1.21"}]`, want: "Survival 1.21"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var description slp.Description
+			if err := json.Unmarshal([]byte(tt.json), &description); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if description.Text != tt.want {
+				t.Errorf("Text = %q, want %q", description.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestVarInt(t *testing.T) {
+	tests := []struct {
+		value int32
+		bytes []byte
+	}{
+		{value: 0, bytes: []byte{0x00}},
+		{value: 127, bytes: []byte{0x7f}},
+		{value: 128, bytes: []byte{0x80, 0x01}},
+		{value: 25565, bytes: []byte{0xdd, 0xc7, 0x01}},
+		{value: -1, bytes: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := slp.WriteVarInt(&buf, tt.value); err != nil {
+			t.Fatalf("WriteVarInt(%d) error = %v", tt.value, err)
+		}
+		if !bytes.Equal(buf.Bytes(), tt.bytes) {
+			t.Errorf("WriteVarInt(%d) = %x, want %x", tt.value, buf.Bytes(), tt.bytes)
+		}
+		value, err := slp.ReadVarInt(bufio.NewReader(&buf))
+		if err != nil || value != tt.value {
+			t.Errorf("ReadVarInt(%x) = %d, %v; want %d", tt.bytes, value, err, tt.value)
+		}
+	}
+}
+
+func TestHandshake_RoundTrip(t *testing.T) {
+	handshake := slp.Handshake{ProtocolVersion: 767, ServerAddress: "survival.mc.home.lan", ServerPort: 25565, NextState: slp.StateLogin}
+
+	var buf bytes.Buffer
+	if err := slp.WritePacket(&buf, slp.PacketHandshake, handshake.Marshal()); err != nil {
+		t.Fatalf("WritePacket() error = %v", err)
+	}
+	id, payload, err := slp.ReadPacket(bufio.NewReader(&buf))
+	if err != nil || id != slp.PacketHandshake {
+		t.Fatalf("ReadPacket() = %d, %v", id, err)
+	}
+	parsed, err := slp.ParseHandshake(payload)
+	if err != nil {
+		t.Fatalf("ParseHandshake() error = %v", err)
+	}
+	if parsed != handshake {
+		t.Errorf("ParseHandshake() = %+v, want %+v", parsed, handshake)
+	}
+}
+
+func TestReadPacketLimit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := slp.WritePacket(&buf, slp.PacketLoginStart, bytes.Repeat([]byte{'a'}, 100)); err != nil {
+		t.Fatalf("WritePacket() error = %v", err)
+	}
+	packet := buf.Bytes()
+
+	if _, payload, err := slp.ReadPacketLimit(bufio.NewReader(bytes.NewReader(packet)), 101); err != nil || len(payload) != 100 {
+		t.Errorf("ReadPacketLimit() = %d bytes, %v; want the packet", len(payload), err)
+	}
+	if _, _, err := slp.ReadPacketLimit(bufio.NewReader(bytes.NewReader(packet)), 100); err == nil {
+		t.Error("ReadPacketLimit() expected an error for a packet over the limit")
+	}
+
+	// A length prefix is enough to refuse a packet, its payload is never waited for
+	var prefix bytes.Buffer
+	_ = slp.WriteVarInt(&prefix, 2097151)
+	if _, _, err := slp.ReadPacketLimit(bufio.NewReader(&prefix), slp.MaxHandshakeLength); err == nil || err == io.EOF {
+		t.Errorf("ReadPacketLimit() error = %v, want an invalid length", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Package slptest provides an in-process Minecraft status server for testing Server List Ping clients.
+package slptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/homecraft/backend/pkg/slp"
+)
+
+// Server is a fake Minecraft server answering status pings on a local port
+type Server struct {
+	// Addr is the host:port the server listens on
+	Addr string
+
+	listener net.Listener
+
+	mu         sync.Mutex
+	status     slp.Status
+	handshakes []slp.Handshake
+	conns      map[net.Conn]struct{}
+	wg         sync.WaitGroup
+}
+
+// NewServer starts a fake server reporting status
+func NewServer(status slp.Status) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("slptest: failed to listen: %v", err))
+	}
+
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		listener: listener,
+		status:   status,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// SetStatus changes the status reported to the next pings
+func (s *Server) SetStatus(status slp.Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// Handshakes returns the handshakes received so far, in order
+func (s *Server) Handshakes() []slp.Handshake {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]slp.Handshake(nil), s.handshakes...)
+}
+
+// Close stops the server, closes open connections and waits for their handlers to return
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle mimics the vanilla server: a status handshake is followed by a status request and a ping,
+// the connection is closed after the pong. Other handshakes are closed right away.
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	id, payload, err := slp.ReadPacket(reader)
+	if err != nil || id != slp.PacketHandshake {
+		return
+	}
+	handshake, err := slp.ParseHandshake(payload)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.handshakes = append(s.handshakes, handshake)
+	status := s.status
+	s.mu.Unlock()
+	if handshake.NextState != slp.StateStatus {
+		return
+	}
+
+	_ = slp.AnswerStatus(conn, reader, status)
+}
@@ -12,21 +12,45 @@ const (
 	UsernamePrefix = "mc"
 	// PasswordLength for generated passwords
 	PasswordLength = 16
+	// RCONPasswordLength for generated RCON passwords
+	RCONPasswordLength = 24
+
+	// SFTPUsernameSecretKey is the key of the SFTP username in a server's Secret
+	SFTPUsernameSecretKey = "username"
+	// SFTPPasswordSecretKey is the key of the SFTP password in a server's Secret
+	SFTPPasswordSecretKey = "password"
 )
 
 // GenerateSFTPCredentials generates a random username and password for SFTP access
 func GenerateSFTPCredentials(serverName string) (username, password string, err error) {
-	// Generate username from server name (sanitized)
-	sanitized := sanitizeServerName(serverName)
-	username = fmt.Sprintf("%s-%s", UsernamePrefix, sanitized)
+	password, err = GenerateSFTPPassword()
+	if err != nil {
+		return "", "", err
+	}
+	return SFTPUsername(serverName), password, nil
+}
+
+// SFTPUsername returns the SFTP username of a server, derived from its name
+func SFTPUsername(serverName string) string {
+	return fmt.Sprintf("%s-%s", UsernamePrefix, sanitizeServerName(serverName))
+}
 
-	// Generate secure random password
-	password, err = generateSecurePassword(PasswordLength)
+// GenerateSFTPPassword generates a random password for a server's SFTP access
+func GenerateSFTPPassword() (string, error) {
+	password, err := generateSecurePassword(PasswordLength)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to generate password: %w", err)
+		return "", fmt.Errorf("failed to generate password: %w", err)
 	}
+	return password, nil
+}
 
-	return username, password, nil
+// GenerateRCONPassword generates a random password for a server's RCON console
+func GenerateRCONPassword() (string, error) {
+	password, err := generateSecurePassword(RCONPasswordLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return password, nil
 }
 
 // sanitizeServerName removes invalid characters for usernames
@@ -214,6 +214,25 @@ func TestGenerateSecurePassword(t *testing.T) {
 	}
 }
 
+func TestGenerateRCONPassword(t *testing.T) {
+	password, err := GenerateRCONPassword()
+	if err != nil {
+		t.Fatalf("GenerateRCONPassword() error = %v", err)
+	}
+
+	if len(password) != RCONPasswordLength {
+		t.Errorf("GenerateRCONPassword() length = %d, want %d", len(password), RCONPasswordLength)
+	}
+
+	other, err := GenerateRCONPassword()
+	if err != nil {
+		t.Fatalf("GenerateRCONPassword() error = %v", err)
+	}
+	if password == other {
+		t.Error("GenerateRCONPassword() returned the same password twice")
+	}
+}
+
 func BenchmarkGenerateSFTPCredentials(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _, _ = GenerateSFTPCredentials("test-server")
@@ -225,3 +244,17 @@ func BenchmarkGenerateSecurePassword(b *testing.B) {
 		_, _ = generateSecurePassword(16)
 	}
 }
+
+func TestSFTPUsername(t *testing.T) {
+	// The operator and the API derive the same username from the server name
+	if got := SFTPUsername("My_Server.1"); got != "mc-my-server-1" {
+		t.Errorf("SFTPUsername() = %q, want %q", got, "mc-my-server-1")
+	}
+	username, _, err := GenerateSFTPCredentials("My_Server.1")
+	if err != nil {
+		t.Fatalf("GenerateSFTPCredentials() error = %v", err)
+	}
+	if username != SFTPUsername("My_Server.1") {
+		t.Errorf("GenerateSFTPCredentials() username = %q, want %q", username, SFTPUsername("My_Server.1"))
+	}
+}
@@ -0,0 +1,256 @@
+// Package wakeproxy answers players on the game port of a stopped server and starts the server
+// when one of them joins. Once the server has loaded its world, connections are passed through to it.
+package wakeproxy
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"time"
+
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// SleepingMOTD is shown in the server list while the server is stopped
+	SleepingMOTD = "Server is sleeping — join to start"
+	// StartingMOTD is shown in the server list while the server loads its world
+	StartingMOTD = "Server is starting, join again in a moment"
+
+	// DefaultHoldTimeout is how long a login is held while the server starts. It stays below the
+	// 30 second timeout after which the client gives up on a login itself.
+	DefaultHoldTimeout = 25 * time.Second
+
+	// pollInterval is how often a held login checks whether the server has loaded its world
+	pollInterval = time.Second
+	// handshakeTimeout bounds the packets read from a client before it is answered or passed through
+	handshakeTimeout = 10 * time.Second
+	// dialTimeout bounds connecting to the server once it is ready
+	dialTimeout = 5 * time.Second
+)
+
+// Servers is the part of the Kubernetes client the proxy uses, *k8s.Client implements it. Backed by
+// *k8s.Client, the proxy needs get and update on its minecraftservers, get on pods, and list on
+// nodes, pods and statefulsets in all namespaces for the capacity check.
+type Servers interface {
+	GetMinecraftServer(ctx context.Context, namespace, name string) (*v1alpha1.MinecraftServer, error)
+	UpdateMinecraftServer(ctx context.Context, namespace string, server *v1alpha1.MinecraftServer) (*v1alpha1.MinecraftServer, error)
+	CheckMemoryAvailability(ctx context.Context, requestedMemory int64) (bool, string, error)
+	GetGameAddress(ctx context.Context, namespace, name string) (string, error)
+}
+
+// Proxy stands in for a single MinecraftServer
+type Proxy struct {
+	Servers   Servers
+	Namespace string
+	Name      string
+
+	// HoldTimeout is how long a login is held while the server starts before the player is asked
+	// to join again, DefaultHoldTimeout when zero
+	HoldTimeout time.Duration
+	// PollInterval is how often held logins check on the server, one second when zero
+	PollInterval time.Duration
+}
+
+// Serve accepts connections on listener until ctx is done
+func (p *Proxy) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go p.handle(ctx, conn)
+	}
+}
+
+// handle answers a status ping or login itself while the server is not ready and passes the
+// connection through to the server once it is
+func (p *Proxy) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+
+	// Probes and port scanners close the connection without a handshake
+	reader := bufio.NewReader(conn)
+	handshake, handshakePacket, err := slp.ReadHandshake(reader)
+	if err != nil {
+		return
+	}
+	received := []slp.Packet{handshakePacket}
+
+	server, err := p.Servers.GetMinecraftServer(ctx, p.Namespace, p.Name)
+	if err != nil {
+		log.Printf("Failed to get server %s: %v", p.Name, err)
+		return
+	}
+
+	switch handshake.NextState {
+	case slp.StateStatus:
+		if isReady(server) {
+			if err := p.forward(ctx, conn, reader, received); err == nil {
+				return
+			}
+		}
+		p.answerStatus(conn, reader, handshake, server)
+	case slp.StateLogin, slp.StateTransfer:
+		id, payload, err := slp.ReadPacketLimit(reader, slp.MaxHandshakeLength)
+		if err != nil || id != slp.PacketLoginStart {
+			return
+		}
+		received = append(received, slp.Packet{ID: id, Payload: payload})
+		p.login(ctx, conn, reader, received, server)
+	}
+}
+
+// answerStatus answers a status ping with the state of the server in place of its MOTD
+func (p *Proxy) answerStatus(conn net.Conn, reader *bufio.Reader, handshake slp.Handshake, server *v1alpha1.MinecraftServer) {
+	motd := StartingMOTD
+	if server.Spec.Paused {
+		motd = SleepingMOTD
+	}
+	status := slp.Status{
+		// Reporting the protocol of the client keeps it from flagging the server as incompatible
+		Version:     slp.Version{Name: "HomeCraft", Protocol: handshake.ProtocolVersion},
+		Players:     slp.Players{Max: server.Spec.MaxPlayers},
+		Description: slp.Description{Text: motd},
+	}
+	_ = slp.AnswerStatus(conn, reader, status)
+}
+
+// login starts the server for a joining player and holds the login until the server is ready.
+// A server that takes longer than the hold timeout disconnects the player with a message to join again.
+func (p *Proxy) login(ctx context.Context, conn net.Conn, reader *bufio.Reader, received []slp.Packet, server *v1alpha1.MinecraftServer) {
+	if server.Spec.Paused {
+		if reason := p.wake(ctx, server); reason != "" {
+			_ = slp.WriteLoginDisconnect(conn, reason)
+			return
+		}
+	}
+
+	holdTimeout := p.HoldTimeout
+	if holdTimeout == 0 {
+		holdTimeout = DefaultHoldTimeout
+	}
+	interval := p.PollInterval
+	if interval == 0 {
+		interval = pollInterval
+	}
+	deadline := time.Now().Add(holdTimeout)
+
+	for {
+		if isReady(server) {
+			if err := p.forward(ctx, conn, reader, received); err != nil {
+				log.Printf("Failed to connect to server %s: %v", p.Name, err)
+				_ = slp.WriteLoginDisconnect(conn, StartingMOTD)
+			}
+			return
+		}
+		if time.Now().Add(interval).After(deadline) {
+			_ = slp.WriteLoginDisconnect(conn, StartingMOTD)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		latest, err := p.Servers.GetMinecraftServer(ctx, p.Namespace, p.Name)
+		if err != nil {
+			log.Printf("Failed to get server %s: %v", p.Name, err)
+			continue
+		}
+		server = latest
+	}
+}
+
+// wake starts a stopped server the way the API does. It returns why the server can't be started,
+// or an empty string once it is starting.
+func (p *Proxy) wake(ctx context.Context, server *v1alpha1.MinecraftServer) string {
+	if _, restoring := server.Annotations[v1alpha1.RestoreAnnotation]; restoring {
+		return "The world of this server is being restored, join again later"
+	}
+	// Only servers stopped for being empty are started by players, not ones their owner stopped
+	if server.Status.StopReason != v1alpha1.StopReasonIdle {
+		return "This server was stopped by its owner"
+	}
+
+	memory, err := resource.ParseQuantity(server.Spec.Memory)
+	if err != nil {
+		log.Printf("Invalid memory %q of server %s: %v", server.Spec.Memory, p.Name, err)
+		return "The server could not be started, try again later"
+	}
+	hasCapacity, message, err := p.Servers.CheckMemoryAvailability(ctx, memory.Value())
+	if err != nil {
+		log.Printf("Failed to check cluster capacity for server %s: %v", p.Name, err)
+		return "The server could not be started, try again later"
+	}
+	if !hasCapacity {
+		log.Printf("Not starting server %s: %s", p.Name, message)
+		return "There is not enough memory free to start this server, try again later"
+	}
+
+	// The operator updates the status all the time, so a conflict usually only means the server was
+	// read before its last status update. It is read again until someone has started it.
+	started := false
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !server.Spec.Paused {
+			return nil
+		}
+		server.Spec.Paused = false
+		_, err := p.Servers.UpdateMinecraftServer(ctx, p.Namespace, server)
+		if apierrors.IsConflict(err) {
+			latest, getErr := p.Servers.GetMinecraftServer(ctx, p.Namespace, p.Name)
+			if getErr != nil {
+				return getErr
+			}
+			*server = *latest
+		}
+		started = err == nil
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to start server %s: %v", p.Name, err)
+		return "The server could not be started, try again later"
+	}
+	if started {
+		log.Printf("Starting server %s for a joining player", p.Name)
+	}
+	return ""
+}
+
+// forward replays the packets read from the client to the server and copies the rest of the
+// connection both ways. It returns an error only when the server can't be reached.
+func (p *Proxy) forward(ctx context.Context, conn net.Conn, reader *bufio.Reader, received []slp.Packet) error {
+	address, err := p.Servers.GetGameAddress(ctx, p.Namespace, p.Name)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	upstream, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer upstream.Close()
+
+	return slp.Splice(conn, reader, upstream, received...)
+}
+
+// isReady reports whether the server has loaded its world and takes players itself
+func isReady(server *v1alpha1.MinecraftServer) bool {
+	return !server.Spec.Paused && meta.IsStatusConditionTrue(server.Status.Conditions, v1alpha1.ServerConditionGameReady)
+}
@@ -0,0 +1,289 @@
+package wakeproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeServers holds a single server, it loads its world as soon as it is started
+type fakeServers struct {
+	mu          sync.Mutex
+	server      *v1alpha1.MinecraftServer
+	hasCapacity bool
+	address     string
+	loads       bool
+	updates     int
+	// conflicts is how many updates fail as made on a stale version, startedElsewhere starts the
+	// server along with the first of them
+	conflicts        int
+	startedElsewhere bool
+}
+
+func (f *fakeServers) GetMinecraftServer(ctx context.Context, namespace, name string) (*v1alpha1.MinecraftServer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.server.DeepCopy(), nil
+}
+
+func (f *fakeServers) UpdateMinecraftServer(ctx context.Context, namespace string, server *v1alpha1.MinecraftServer) (*v1alpha1.MinecraftServer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conflicts > 0 {
+		f.conflicts--
+		if f.startedElsewhere {
+			f.server.Spec.Paused = false
+		}
+		return nil, apierrors.NewConflict(v1alpha1.Resource("minecraftservers"), server.Name, errors.New("the object has been modified"))
+	}
+	f.updates++
+	f.server = server.DeepCopy()
+	if f.loads && !server.Spec.Paused {
+		f.server.Status.Conditions = []metav1.Condition{{
+			Type:   v1alpha1.ServerConditionGameReady,
+			Status: metav1.ConditionTrue,
+		}}
+	}
+	return f.server.DeepCopy(), nil
+}
+
+func (f *fakeServers) CheckMemoryAvailability(ctx context.Context, requestedMemory int64) (bool, string, error) {
+	return f.hasCapacity, "Insufficient memory", nil
+}
+
+func (f *fakeServers) GetGameAddress(ctx context.Context, namespace, name string) (string, error) {
+	return f.address, nil
+}
+
+func idleServer() *v1alpha1.MinecraftServer {
+	return &v1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "minecraft-servers"},
+		Spec: v1alpha1.MinecraftServerSpec{
+			Memory:     "2Gi",
+			MaxPlayers: 20,
+			Paused:     true,
+		},
+		Status: v1alpha1.MinecraftServerStatus{StopReason: v1alpha1.StopReasonIdle},
+	}
+}
+
+// startProxy serves servers on a local port and returns its address
+func startProxy(t *testing.T, servers *fakeServers) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	proxy := &Proxy{
+		Servers:      servers,
+		Namespace:    "minecraft-servers",
+		Name:         "survival",
+		HoldTimeout:  200 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	}
+	go func() { _ = proxy.Serve(ctx, listener) }()
+	return listener.Addr().String()
+}
+
+// join sends the handshake and login start of a player and returns what the proxy answers
+func join(t *testing.T, address string) *bufio.Reader {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake := slp.Handshake{ProtocolVersion: 767, ServerAddress: "survival.example.com", ServerPort: 25565, NextState: slp.StateLogin}
+	if err := slp.WritePacket(conn, slp.PacketHandshake, handshake.Marshal()); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+	var loginStart bytes.Buffer
+	_ = slp.WriteString(&loginStart, "Steve")
+	if err := slp.WritePacket(conn, slp.PacketLoginStart, loginStart.Bytes()); err != nil {
+		t.Fatalf("Failed to send login start: %v", err)
+	}
+	return bufio.NewReader(conn)
+}
+
+// readDisconnect reads the login disconnect packet sent to a player and returns its message
+func readDisconnect(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	id, payload, err := slp.ReadPacket(reader)
+	if err != nil {
+		t.Fatalf("Failed to read disconnect: %v", err)
+	}
+	if id != slp.PacketLoginDisconnect {
+		t.Fatalf("Expected a disconnect, got packet %d", id)
+	}
+	message, err := slp.ReadString(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Invalid disconnect message: %v", err)
+	}
+	return message
+}
+
+func TestProxy_AnswersStatusPings(t *testing.T) {
+	servers := &fakeServers{server: idleServer()}
+	address := startProxy(t, servers)
+
+	status, err := slp.Ping(context.Background(), address)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if status.Description.Text != SleepingMOTD {
+		t.Errorf("MOTD = %q, want %q", status.Description.Text, SleepingMOTD)
+	}
+	if status.Players.Max != 20 || status.Players.Online != 0 {
+		t.Errorf("players = %d/%d, want 0/20", status.Players.Online, status.Players.Max)
+	}
+	if servers.updates != 0 {
+		t.Error("A status ping must not start the server")
+	}
+}
+
+func TestProxy_WakesAndHandsOver(t *testing.T) {
+	// The server only has to read what the proxy replays
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer upstream.Close()
+	replayed := make(chan []int32, 1)
+	go func() {
+		conn, err := upstream.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var ids []int32
+		for i := 0; i < 2; i++ {
+			id, _, err := slp.ReadPacket(reader)
+			if err != nil {
+				break
+			}
+			ids = append(ids, id)
+		}
+		replayed <- ids
+		_ = slp.WriteLoginDisconnect(conn, "Hello from the server")
+	}()
+
+	servers := &fakeServers{server: idleServer(), hasCapacity: true, address: upstream.Addr().String(), loads: true}
+	address := startProxy(t, servers)
+
+	reader := join(t, address)
+	if message := readDisconnect(t, reader); !strings.Contains(message, "Hello from the server") {
+		t.Errorf("Expected the server to answer the login, got %q", message)
+	}
+	if ids := <-replayed; len(ids) != 2 || ids[0] != slp.PacketHandshake || ids[1] != slp.PacketLoginStart {
+		t.Errorf("Expected the handshake and login start to be replayed, got %v", ids)
+	}
+	if servers.server.Spec.Paused {
+		t.Error("Expected the server to be started")
+	}
+}
+
+func TestProxy_AsksToJoinAgainWhileStarting(t *testing.T) {
+	servers := &fakeServers{server: idleServer(), hasCapacity: true}
+	address := startProxy(t, servers)
+
+	reader := join(t, address)
+	if message := readDisconnect(t, reader); !strings.Contains(message, StartingMOTD) {
+		t.Errorf("Expected to be asked to join again, got %q", message)
+	}
+	if servers.server.Spec.Paused {
+		t.Error("Expected the server to be started")
+	}
+}
+
+func TestProxy_RefusesToWake(t *testing.T) {
+	tests := []struct {
+		name        string
+		mutate      func(*v1alpha1.MinecraftServer)
+		hasCapacity bool
+		want        string
+	}{
+		{
+			name:   "not enough memory",
+			mutate: func(*v1alpha1.MinecraftServer) {},
+			want:   "not enough memory",
+		},
+		{
+			name:        "stopped by its owner",
+			mutate:      func(s *v1alpha1.MinecraftServer) { s.Status.StopReason = "" },
+			hasCapacity: true,
+			want:        "stopped by its owner",
+		},
+		{
+			name: "being restored",
+			mutate: func(s *v1alpha1.MinecraftServer) {
+				s.Annotations = map[string]string{v1alpha1.RestoreAnnotation: "survival-restore"}
+			},
+			hasCapacity: true,
+			want:        "being restored",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := idleServer()
+			tt.mutate(server)
+			servers := &fakeServers{server: server, hasCapacity: tt.hasCapacity}
+			address := startProxy(t, servers)
+
+			reader := join(t, address)
+			if message := readDisconnect(t, reader); !strings.Contains(message, tt.want) {
+				t.Errorf("Expected a disconnect mentioning %q, got %q", tt.want, message)
+			}
+			if servers.updates != 0 || !servers.server.Spec.Paused {
+				t.Error("Expected the server to stay stopped")
+			}
+		})
+	}
+}
+
+func TestProxy_WakeRetriesConflicts(t *testing.T) {
+	tests := []struct {
+		name             string
+		conflicts        int
+		startedElsewhere bool
+		wantUpdates      int
+	}{
+		{name: "status updated since the server was read", conflicts: 2, wantUpdates: 1},
+		{name: "started by someone else", conflicts: 1, startedElsewhere: true, wantUpdates: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := &fakeServers{server: idleServer(), hasCapacity: true, conflicts: tt.conflicts, startedElsewhere: tt.startedElsewhere}
+			proxy := &Proxy{Servers: servers, Namespace: "minecraft-servers", Name: "survival"}
+
+			server := idleServer()
+			if reason := proxy.wake(context.Background(), server); reason != "" {
+				t.Fatalf("wake() = %q, want the server to be starting", reason)
+			}
+			if servers.server.Spec.Paused || server.Spec.Paused {
+				t.Error("Expected the server to be started")
+			}
+			if servers.updates != tt.wantUpdates {
+				t.Errorf("Expected %d successful updates, got %d", tt.wantUpdates, servers.updates)
+			}
+		})
+	}
+}
@@ -5,6 +5,37 @@ import { ofetch } from 'ofetch'
 // This will make requests to the same host/domain that served the frontend
 const API_BASE_URL = import.meta.env.VITE_API_URL || ''
 
+// Bearer token of an OIDC login. Local accounts are authenticated by the session cookie instead,
+// which is sent because every request includes credentials.
+let accessToken: string | null = null
+
+export function setAccessToken(token: string | null) {
+  accessToken = token
+}
+
+const api = ofetch.create({
+  baseURL: API_BASE_URL,
+  credentials: 'include',
+  onRequest({ options }) {
+    if (accessToken) {
+      const headers = new Headers(options.headers)
+      headers.set('Authorization', `Bearer ${accessToken}`)
+      options.headers = headers
+    }
+  },
+})
+
+// openEventStream opens a Server-Sent Events stream. EventSource can't set headers, so the bearer
+// token is passed as the access_token query parameter the API accepts on event streams.
+function openEventStream(path: string, params: Record<string, string> = {}): EventSource {
+  const query = new URLSearchParams(params)
+  if (accessToken) {
+    query.set('access_token', accessToken)
+  }
+  const search = query.toString()
+  return new EventSource(`${API_BASE_URL}${path}${search ? `?${search}` : ''}`, { withCredentials: true })
+}
+
 export interface MinecraftServer {
   name: string
   namespace: string
@@ -17,15 +48,57 @@ export interface MinecraftServer {
   difficulty: string
   gamemode: string
   phase?: string
+  message?: string
+  conditions?: ServerCondition[]
+  game?: GameStatus
+  idleShutdown?: IdleShutdown
+  expose?: Expose
+  emptySince?: string
+  stopReason?: string
   endpoint?: string
   publicEndpoint?: string
+  publicTunnel?: boolean
   sftpEndpoint?: string
   sftpUsername?: string
-  sftpPassword?: string
+  sftpPasswordRotatedAt?: string
+  authorizedKeys?: string[]
+  disableSFTPPassword?: boolean
   allocatedMemory?: string
   createdAt?: string
 }
 
+export interface IdleShutdown {
+  timeoutMinutes: number
+  wakeOnConnect?: boolean
+}
+
+export interface Expose {
+  game?: ServiceExposure
+  sftp?: ServiceExposure
+}
+
+export interface ServiceExposure {
+  type: 'LoadBalancer' | 'NodePort' | 'ClusterIP'
+  nodePort?: number
+}
+
+export interface ServerCondition {
+  type: string
+  status: 'True' | 'False' | 'Unknown'
+  reason?: string
+  message?: string
+  lastTransitionTime?: string
+}
+
+export interface GameStatus {
+  motd: string
+  version: string
+  playersOnline: number
+  playersMax: number
+  latencyMs: number
+  lastPingAt: string
+}
+
 export interface CreateServerRequest {
   name: string
   eula: boolean
@@ -37,6 +110,7 @@ export interface CreateServerRequest {
   difficulty?: string
   gamemode?: string
   publicEndpoint?: string
+  publicTunnel?: boolean
 }
 
 export function useMinecraftApi() {
@@ -47,7 +121,7 @@ export function useMinecraftApi() {
     loading.value = true
     error.value = null
     try {
-      const response = await ofetch<MinecraftServer>(`${API_BASE_URL}/api/v1/servers`, {
+      const response = await api<MinecraftServer>('/api/v1/servers', {
         method: 'POST',
         body: request,
       })
@@ -64,7 +138,7 @@ export function useMinecraftApi() {
     loading.value = true
     error.value = null
     try {
-      const response = await ofetch<{ count: number; items: MinecraftServer[] }>(`${API_BASE_URL}/api/v1/servers`)
+      const response = await api<{ count: number; items: MinecraftServer[] }>('/api/v1/servers')
       return response.items
     } catch (e: any) {
       error.value = e.data?.message || e.message || 'Failed to fetch servers'
@@ -78,7 +152,7 @@ export function useMinecraftApi() {
     loading.value = true
     error.value = null
     try {
-      const response = await ofetch<MinecraftServer>(`${API_BASE_URL}/api/v1/servers/${name}`)
+      const response = await api<MinecraftServer>(`/api/v1/servers/${name}`)
       return response
     } catch (e: any) {
       error.value = e.data?.message || e.message || 'Failed to fetch server'
@@ -92,7 +166,7 @@ export function useMinecraftApi() {
     loading.value = true
     error.value = null
     try {
-      await ofetch(`${API_BASE_URL}/api/v1/servers/${name}`, {
+      await api(`/api/v1/servers/${name}`, {
         method: 'DELETE',
       })
     } catch (e: any) {
@@ -103,6 +177,35 @@ export function useMinecraftApi() {
     }
   }
 
+  // watchServers calls onChange with every server that is added, modified or deleted until the
+  // returned function is called. The browser reconnects on its own, resuming from the last event.
+  const watchServers = (
+    onChange: (type: 'ADDED' | 'MODIFIED' | 'DELETED', server: MinecraftServer) => void,
+  ): (() => void) => {
+    const source = openEventStream('/api/v1/servers/watch')
+    for (const type of ['ADDED', 'MODIFIED', 'DELETED'] as const) {
+      source.addEventListener(type, (event) => onChange(type, JSON.parse((event as MessageEvent).data)))
+    }
+    source.addEventListener('ERROR', (event) => {
+      error.value = JSON.parse((event as MessageEvent).data).message
+    })
+    return () => source.close()
+  }
+
+  // followServerLogs calls onLine with the last lines of the server log and then every new one,
+  // until the log ends or the returned function is called
+  const followServerLogs = (name: string, onLine: (line: string) => void, tail = 100): (() => void) => {
+    const source = openEventStream(`/api/v1/servers/${name}/logs`, { follow: 'true', tail: String(tail) })
+    source.addEventListener('log', (event) => onLine((event as MessageEvent).data))
+    source.addEventListener('error', (event) => {
+      if (event instanceof MessageEvent) {
+        error.value = event.data
+      }
+    })
+    source.addEventListener('end', () => source.close())
+    return () => source.close()
+  }
+
   return {
     loading,
     error,
@@ -110,5 +213,7 @@ export function useMinecraftApi() {
     listServers,
     getServer,
     deleteServer,
+    watchServers,
+    followServerLogs,
   }
 }
@@ -10,8 +10,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/playit"
+	"github.com/homecraft/backend/pkg/slp"
 	"github.com/homecraft/operator/controllers"
 )
 
@@ -29,9 +32,26 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var backupPVCName string
+	var wakeProxyImage string
+	var wakeProxyServiceAccount string
+	var gatewayDomain string
+	var playitAgentID string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&backupPVCName, "backup-pvc", controllers.DefaultBackupPVCName,
+		"The PersistentVolumeClaim in the servers' namespace that backup archives are written to and restored from.")
+	flag.StringVar(&wakeProxyImage, "wake-proxy-image", "",
+		"The image of the proxies that start stopped servers when a player joins. Empty disables wakeOnConnect.")
+	flag.StringVar(&wakeProxyServiceAccount, "wake-proxy-service-account", controllers.DefaultWakeProxyServiceAccount,
+		"The service account in the servers' namespace that wake proxies run as.")
+	flag.StringVar(&gatewayDomain, "gateway-domain", "",
+		"The parent domain of the hostnames the Minecraft gateway routes to servers, e.g. mc.home.lan. "+
+			"Empty gives every server a LoadBalancer service of its own.")
+	flag.StringVar(&playitAgentID, "playit-agent-id", "",
+		"The Playit.gg agent that serves the public tunnels of servers with publicTunnel set. "+
+			"Its secret key is read from PLAYIT_SECRET_KEY. Empty disables public tunnels.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -46,6 +66,7 @@ func main() {
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "minecraftserver.homecraft.io",
@@ -55,15 +76,53 @@ func main() {
 		os.Exit(1)
 	}
 
+	var tunnels controllers.TunnelProvider
+	if playitAgentID != "" {
+		secretKey := os.Getenv("PLAYIT_SECRET_KEY")
+		if secretKey == "" {
+			setupLog.Error(nil, "PLAYIT_SECRET_KEY must be set with --playit-agent-id")
+			os.Exit(1)
+		}
+		tunnels = &controllers.PlayitTunnels{Client: playit.NewClient(secretKey), AgentID: playitAgentID}
+	}
+
 	if err = (&controllers.MinecraftServerReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MinecraftServer"),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MinecraftServer"),
+		Ping:     slp.Ping,
+		Recorder: mgr.GetEventRecorderFor("minecraftserver-controller"),
+
+		WakeProxyImage:          wakeProxyImage,
+		WakeProxyServiceAccount: wakeProxyServiceAccount,
+		GatewayDomain:           gatewayDomain,
+		Tunnels:                 tunnels,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MinecraftServer")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.MinecraftBackupReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Log:           ctrl.Log.WithName("controllers").WithName("MinecraftBackup"),
+		BackupPVCName: backupPVCName,
+		Console:       controllers.RCONConsole,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MinecraftBackup")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.MinecraftRestoreReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Log:           ctrl.Log.WithName("controllers").WithName("MinecraftRestore"),
+		BackupPVCName: backupPVCName,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MinecraftRestore")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileBackupSchedule creates the scheduled backups of a server that are due, prunes the ones its
+// retention rules no longer keep and records the backup times in its status. It returns how long it is
+// until the next scheduled backup, or zero when the server has no backup schedule.
+func (r *MinecraftServerReconciler) reconcileBackupSchedule(ctx context.Context, m *homecraftv1alpha1.MinecraftServer) (time.Duration, error) {
+	backups := &homecraftv1alpha1.MinecraftBackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(m.Namespace),
+		client.MatchingLabels{homecraftv1alpha1.ServerNameLabel: m.Name}); err != nil {
+		return 0, err
+	}
+	m.Status.LastSuccessfulBackupTime = lastSuccessfulBackupTime(backups.Items)
+
+	if m.Spec.BackupSchedule == nil {
+		return 0, nil
+	}
+
+	schedule, err := cron.ParseStandard(m.Spec.BackupSchedule.Schedule)
+	if err != nil {
+		// Nothing to retry until the spec changes
+		r.Log.Error(err, "Invalid backup schedule", "minecraftserver", m.Name, "schedule", m.Spec.BackupSchedule.Schedule)
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	last := m.CreationTimestamp.Time
+	if m.Status.LastScheduledBackupTime != nil {
+		last = m.Status.LastScheduledBackupTime.Time
+	}
+
+	// Runs missed while the operator was down are caught up with a single backup. It is named after
+	// the run, so when the time of the run couldn't be recorded the retry finds the same backup.
+	next := schedule.Next(last.UTC())
+	if !now.Before(next) {
+		backup := scheduledBackupForServer(m, next)
+		err := r.Create(ctx, backup)
+		switch {
+		case err == nil:
+			r.Log.Info("Created scheduled backup", "minecraftserver", m.Name, "backup", backup.Name)
+		case !errors.IsAlreadyExists(err):
+			return 0, err
+		}
+
+		started := metav1.NewTime(now)
+		m.Status.LastScheduledBackupTime = &started
+		next = schedule.Next(now)
+	}
+
+	scheduled := make([]homecraftv1alpha1.MinecraftBackup, 0, len(backups.Items))
+	for _, backup := range backups.Items {
+		if backup.Labels[homecraftv1alpha1.ScheduledBackupLabel] == "true" {
+			scheduled = append(scheduled, backup)
+		}
+	}
+	for _, backup := range backupsToPrune(scheduled, m.Spec.BackupSchedule) {
+		if err := r.Delete(ctx, &backup); err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+		r.Log.Info("Pruned scheduled backup", "minecraftserver", m.Name, "backup", backup.Name)
+	}
+
+	return next.Sub(now), nil
+}
+
+// scheduledBackupForServer returns the MinecraftBackup of the scheduled run of a server at the given time.
+// It is named like the backups created through the API.
+func scheduledBackupForServer(m *homecraftv1alpha1.MinecraftServer, at time.Time) *homecraftv1alpha1.MinecraftBackup {
+	return &homecraftv1alpha1.MinecraftBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.Name + "-" + at.UTC().Format("20060102-150405"),
+			Namespace: m.Namespace,
+			Labels: map[string]string{
+				homecraftv1alpha1.ServerNameLabel:      m.Name,
+				homecraftv1alpha1.ScheduledBackupLabel: "true",
+			},
+		},
+		Spec: homecraftv1alpha1.MinecraftBackupSpec{
+			ServerName: m.Name,
+		},
+	}
+}
+
+// lastSuccessfulBackupTime returns when the newest completed backup finished, or nil without one
+func lastSuccessfulBackupTime(backups []homecraftv1alpha1.MinecraftBackup) *metav1.Time {
+	var last *metav1.Time
+	for i := range backups {
+		completion := backups[i].Status.CompletionTime
+		if backups[i].Status.Phase != homecraftv1alpha1.BackupPhaseCompleted || completion == nil {
+			continue
+		}
+		if last == nil || last.Before(completion) {
+			last = completion.DeepCopy()
+		}
+	}
+	return last
+}
+
+// backupsToPrune returns the scheduled backups the retention rules of a schedule don't keep.
+// A completed backup is kept when any rule keeps it, failed backups are dropped once a newer backup
+// completed and backups that are still running are never pruned. Without rules nothing is pruned.
+func backupsToPrune(backups []homecraftv1alpha1.MinecraftBackup, schedule *homecraftv1alpha1.BackupSchedule) []homecraftv1alpha1.MinecraftBackup {
+	if schedule.KeepLast <= 0 && schedule.KeepDaily <= 0 && schedule.KeepWeekly <= 0 {
+		return nil
+	}
+
+	sorted := make([]homecraftv1alpha1.MinecraftBackup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[j].CreationTimestamp.Before(&sorted[i].CreationTimestamp)
+	})
+
+	var completed []homecraftv1alpha1.MinecraftBackup
+	for _, backup := range sorted {
+		if backup.Status.Phase == homecraftv1alpha1.BackupPhaseCompleted {
+			completed = append(completed, backup)
+		}
+	}
+
+	keep := map[string]bool{}
+	for i := 0; i < schedule.KeepLast && i < len(completed); i++ {
+		keep[completed[i].Name] = true
+	}
+	keepNewestPerPeriod(completed, schedule.KeepDaily, keep, func(t time.Time) string {
+		return t.UTC().Format("2006-01-02")
+	})
+	keepNewestPerPeriod(completed, schedule.KeepWeekly, keep, func(t time.Time) string {
+		year, week := t.UTC().ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+
+	var prune []homecraftv1alpha1.MinecraftBackup
+	newerCompleted := false
+	for _, backup := range sorted {
+		switch backup.Status.Phase {
+		case homecraftv1alpha1.BackupPhaseCompleted:
+			if !keep[backup.Name] {
+				prune = append(prune, backup)
+			}
+			newerCompleted = true
+		case homecraftv1alpha1.BackupPhaseFailed:
+			if newerCompleted {
+				prune = append(prune, backup)
+			}
+		}
+	}
+	return prune
+}
+
+// keepNewestPerPeriod marks the newest backup of each of the n most recent periods that have a backup.
+// backups must be sorted newest first.
+func keepNewestPerPeriod(backups []homecraftv1alpha1.MinecraftBackup, n int, keep map[string]bool, period func(time.Time) string) {
+	seen := map[string]bool{}
+	for _, backup := range backups {
+		if len(seen) >= n {
+			return
+		}
+		p := period(backup.CreationTimestamp.Time)
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		keep[backup.Name] = true
+	}
+}
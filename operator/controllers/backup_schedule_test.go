@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// scheduledBackup returns a scheduled backup of test-server created at the given time
+func scheduledBackup(name, phase string, createdAt time.Time) homecraftv1alpha1.MinecraftBackup {
+	backup := homecraftv1alpha1.MinecraftBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(createdAt),
+			Labels: map[string]string{
+				homecraftv1alpha1.ServerNameLabel:      "test-server",
+				homecraftv1alpha1.ScheduledBackupLabel: "true",
+			},
+		},
+		Spec:   homecraftv1alpha1.MinecraftBackupSpec{ServerName: "test-server"},
+		Status: homecraftv1alpha1.MinecraftBackupStatus{Phase: phase},
+	}
+	if phase == homecraftv1alpha1.BackupPhaseCompleted {
+		completion := metav1.NewTime(createdAt.Add(time.Minute))
+		backup.Status.CompletionTime = &completion
+	}
+	return backup
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	// Sunday 2026-10-18, so the 12th to the 18th are one ISO week
+	day := func(d, hour int) time.Time { return time.Date(2026, 10, d, hour, 0, 0, 0, time.UTC) }
+	backups := []homecraftv1alpha1.MinecraftBackup{
+		scheduledBackup("b-18-12", homecraftv1alpha1.BackupPhaseRunning, day(18, 12)),
+		scheduledBackup("b-18-06", homecraftv1alpha1.BackupPhaseCompleted, day(18, 6)),
+		scheduledBackup("b-18-00", homecraftv1alpha1.BackupPhaseCompleted, day(18, 0)),
+		scheduledBackup("b-17-12", homecraftv1alpha1.BackupPhaseFailed, day(17, 12)),
+		scheduledBackup("b-17-00", homecraftv1alpha1.BackupPhaseCompleted, day(17, 0)),
+		scheduledBackup("b-11-00", homecraftv1alpha1.BackupPhaseCompleted, day(11, 0)),
+		scheduledBackup("b-04-00", homecraftv1alpha1.BackupPhaseCompleted, day(4, 0)),
+	}
+
+	tests := []struct {
+		name      string
+		schedule  homecraftv1alpha1.BackupSchedule
+		wantPrune []string
+	}{
+		{
+			name:      "no retention rules keeps everything",
+			schedule:  homecraftv1alpha1.BackupSchedule{},
+			wantPrune: nil,
+		},
+		{
+			name:      "keep last",
+			schedule:  homecraftv1alpha1.BackupSchedule{KeepLast: 2},
+			wantPrune: []string{"b-17-12", "b-17-00", "b-11-00", "b-04-00"},
+		},
+		{
+			name:      "keep daily",
+			schedule:  homecraftv1alpha1.BackupSchedule{KeepDaily: 2},
+			wantPrune: []string{"b-18-00", "b-17-12", "b-11-00", "b-04-00"},
+		},
+		{
+			name:      "keep weekly",
+			schedule:  homecraftv1alpha1.BackupSchedule{KeepWeekly: 3},
+			wantPrune: []string{"b-18-00", "b-17-12", "b-17-00"},
+		},
+		{
+			name:      "rules are combined",
+			schedule:  homecraftv1alpha1.BackupSchedule{KeepLast: 1, KeepDaily: 2, KeepWeekly: 2},
+			wantPrune: []string{"b-18-00", "b-17-12", "b-04-00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, backup := range backupsToPrune(backups, &tt.schedule) {
+				got = append(got, backup.Name)
+			}
+			if !reflect.DeepEqual(got, tt.wantPrune) {
+				t.Errorf("backupsToPrune() = %v, want %v", got, tt.wantPrune)
+			}
+		})
+	}
+}
+
+func TestLastSuccessfulBackupTime(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	backups := []homecraftv1alpha1.MinecraftBackup{
+		scheduledBackup("older", homecraftv1alpha1.BackupPhaseCompleted, now.Add(-2*time.Hour)),
+		scheduledBackup("newest", homecraftv1alpha1.BackupPhaseCompleted, now.Add(-time.Hour)),
+		scheduledBackup("failed", homecraftv1alpha1.BackupPhaseFailed, now),
+	}
+
+	got := lastSuccessfulBackupTime(backups)
+	if got == nil || !got.Time.Equal(now.Add(-time.Hour+time.Minute)) {
+		t.Errorf("lastSuccessfulBackupTime() = %v, want completion of the newest completed backup", got)
+	}
+	if lastSuccessfulBackupTime(backups[2:]) != nil {
+		t.Error("lastSuccessfulBackupTime() without completed backups should be nil")
+	}
+}
+
+func TestReconcileBackupSchedule(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name            string
+		lastScheduled   time.Time
+		backups         []homecraftv1alpha1.MinecraftBackup
+		wantCreated     bool
+		wantRemaining   []string
+		wantMaxDuration time.Duration
+	}{
+		{
+			name:            "backup is due",
+			lastScheduled:   now.Add(-2 * time.Hour),
+			wantCreated:     true,
+			wantMaxDuration: time.Hour,
+		},
+		{
+			name:            "backup is not due yet",
+			lastScheduled:   now,
+			wantCreated:     false,
+			wantMaxDuration: time.Hour,
+		},
+		{
+			name:          "old scheduled backups are pruned",
+			lastScheduled: now,
+			backups: []homecraftv1alpha1.MinecraftBackup{
+				scheduledBackup("newest", homecraftv1alpha1.BackupPhaseCompleted, now.Add(-time.Hour)),
+				scheduledBackup("oldest", homecraftv1alpha1.BackupPhaseCompleted, now.Add(-2*time.Hour)),
+			},
+			wantRemaining:   []string{"newest"},
+			wantMaxDuration: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := runtime.NewScheme()
+			_ = scheme.AddToScheme(s)
+			_ = homecraftv1alpha1.AddToScheme(s)
+
+			lastScheduled := metav1.NewTime(tt.lastScheduled)
+			server := &homecraftv1alpha1.MinecraftServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-server",
+					Namespace:         "default",
+					CreationTimestamp: metav1.NewTime(now.Add(-24 * time.Hour)),
+				},
+				Spec: homecraftv1alpha1.MinecraftServerSpec{
+					Memory: "2Gi",
+					BackupSchedule: &homecraftv1alpha1.BackupSchedule{
+						Schedule: "@hourly",
+						KeepLast: 1,
+					},
+				},
+				Status: homecraftv1alpha1.MinecraftServerStatus{LastScheduledBackupTime: &lastScheduled},
+			}
+
+			objects := []client.Object{server}
+			for i := range tt.backups {
+				objects = append(objects, &tt.backups[i])
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+			reconciler := &MinecraftServerReconciler{
+				Client: fakeClient,
+				Log:    zap.New(zap.UseDevMode(true)),
+				Scheme: s,
+			}
+
+			untilNext, err := reconciler.reconcileBackupSchedule(context.Background(), server)
+			if err != nil {
+				t.Fatalf("reconcileBackupSchedule() error = %v", err)
+			}
+			if untilNext <= 0 || untilNext > tt.wantMaxDuration {
+				t.Errorf("reconcileBackupSchedule() next backup in %v, want within %v", untilNext, tt.wantMaxDuration)
+			}
+
+			list := &homecraftv1alpha1.MinecraftBackupList{}
+			if err := fakeClient.List(context.Background(), list); err != nil {
+				t.Fatalf("Failed to list backups: %v", err)
+			}
+
+			if tt.wantCreated {
+				if len(list.Items) != 1 {
+					t.Fatalf("Expected 1 scheduled backup, got %d", len(list.Items))
+				}
+				created := list.Items[0]
+				if created.Spec.ServerName != "test-server" || created.Labels[homecraftv1alpha1.ScheduledBackupLabel] != "true" {
+					t.Errorf("Unexpected scheduled backup %+v", created.ObjectMeta)
+				}
+				if !server.Status.LastScheduledBackupTime.After(tt.lastScheduled) {
+					t.Error("Expected LastScheduledBackupTime to advance")
+				}
+				return
+			}
+
+			var remaining []string
+			for _, backup := range list.Items {
+				remaining = append(remaining, backup.Name)
+			}
+			sort.Strings(remaining)
+			if !reflect.DeepEqual(remaining, tt.wantRemaining) {
+				t.Errorf("Remaining backups = %v, want %v", remaining, tt.wantRemaining)
+			}
+			if len(tt.backups) > 0 && server.Status.LastSuccessfulBackupTime == nil {
+				t.Error("Expected LastSuccessfulBackupTime to be set")
+			}
+		})
+	}
+}
+
+func TestReconcileBackupSchedule_RetryAfterStatusWasLost(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	lastScheduled := metav1.NewTime(time.Now().UTC().Add(-2 * time.Hour))
+	server := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			Memory:         "2Gi",
+			BackupSchedule: &homecraftv1alpha1.BackupSchedule{Schedule: "@hourly"},
+		},
+		Status: homecraftv1alpha1.MinecraftServerStatus{LastScheduledBackupTime: &lastScheduled},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(server).Build()
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+
+	// Each attempt starts from the stored server, as if writing the status had failed in between
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := reconciler.reconcileBackupSchedule(context.Background(), server.DeepCopy()); err != nil {
+			t.Fatalf("reconcileBackupSchedule() error = %v", err)
+		}
+	}
+
+	list := &homecraftv1alpha1.MinecraftBackupList{}
+	if err := fakeClient.List(context.Background(), list); err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("Expected the retry to find the scheduled backup, got %d backups", len(list.Items))
+	}
+	run := lastScheduled.Truncate(time.Hour).Add(time.Hour)
+	if want := "test-server-" + run.Format("20060102-150405"); list.Items[0].Name != want {
+		t.Errorf("Expected the backup to be named after its run %s, got %s", want, list.Items[0].Name)
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/rcon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errServerNotRunning is returned when a server has no running pod whose console can be used
+var errServerNotRunning = fmt.Errorf("server is not running")
+
+// ConsoleFunc runs commands one after another on the console of the server at address
+// and returns the output of every command
+type ConsoleFunc func(ctx context.Context, address, password string, commands ...string) ([]string, error)
+
+// RCONConsole is the ConsoleFunc that talks to servers over RCON
+func RCONConsole(ctx context.Context, address, password string, commands ...string) ([]string, error) {
+	conn, err := rcon.Dial(ctx, address, password)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	outputs := make([]string, 0, len(commands))
+	for _, command := range commands {
+		output, err := conn.Command(ctx, command)
+		if err != nil {
+			return outputs, fmt.Errorf("command %q failed: %w", command, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// runConsoleCommands runs commands on the console of a server through its pod,
+// using the RCON password the MinecraftServer controller stored in the server's Secret
+func runConsoleCommands(ctx context.Context, c client.Client, console ConsoleFunc, m *homecraftv1alpha1.MinecraftServer, commands ...string) ([]string, error) {
+	pod, err := runningServerPod(ctx, c, m)
+	if err != nil {
+		return nil, err
+	}
+	return runPodConsoleCommands(ctx, c, console, m, pod, commands...)
+}
+
+// runningServerPod returns the pod of a server, or errServerNotRunning when it has none running
+func runningServerPod(ctx context.Context, c client.Client, m *homecraftv1alpha1.MinecraftServer) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	err := c.Get(ctx, types.NamespacedName{Name: m.Name + "-0", Namespace: m.Namespace}, pod)
+	if errors.IsNotFound(err) {
+		return nil, errServerNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		return nil, errServerNotRunning
+	}
+	return pod, nil
+}
+
+// runPodConsoleCommands runs commands on the console of the server running in pod
+func runPodConsoleCommands(ctx context.Context, c client.Client, console ConsoleFunc, m *homecraftv1alpha1.MinecraftServer, pod *corev1.Pod, commands ...string) ([]string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: m.Name + "-sftp", Namespace: m.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	password := string(secret.Data[rcon.PasswordSecretKey])
+	if password == "" {
+		return nil, fmt.Errorf("secret %s has no RCON password", secret.Name)
+	}
+
+	address := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(rcon.DefaultPort))
+	return console(ctx, address, password, commands...)
+}
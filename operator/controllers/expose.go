@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/gateway"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gameExposure returns how the game port of a server is exposed. Servers that don't set it get a
+// LoadBalancer service, or a ClusterIP service the gateway routes players to.
+func (r *MinecraftServerReconciler) gameExposure(m *homecraftv1alpha1.MinecraftServer) homecraftv1alpha1.ServiceExposure {
+	if m.Spec.Expose != nil && m.Spec.Expose.Game != nil {
+		return *m.Spec.Expose.Game
+	}
+	if r.GatewayDomain != "" {
+		return homecraftv1alpha1.ServiceExposure{Type: homecraftv1alpha1.ExposeClusterIP}
+	}
+	return homecraftv1alpha1.ServiceExposure{Type: homecraftv1alpha1.ExposeLoadBalancer}
+}
+
+// sftpExposure returns how the SFTP port of a server is exposed, with a LoadBalancer service by default
+func sftpExposure(m *homecraftv1alpha1.MinecraftServer) homecraftv1alpha1.ServiceExposure {
+	if m.Spec.Expose != nil && m.Spec.Expose.SFTP != nil {
+		return *m.Spec.Expose.SFTP
+	}
+	return homecraftv1alpha1.ServiceExposure{Type: homecraftv1alpha1.ExposeLoadBalancer}
+}
+
+// exposedServiceType returns the service type and the fixed node port, if any, of an exposure
+func exposedServiceType(exposure homecraftv1alpha1.ServiceExposure) (corev1.ServiceType, int32) {
+	switch exposure.Type {
+	case homecraftv1alpha1.ExposeNodePort:
+		return corev1.ServiceTypeNodePort, exposure.NodePort
+	case homecraftv1alpha1.ExposeClusterIP:
+		return corev1.ServiceTypeClusterIP, 0
+	default:
+		return corev1.ServiceTypeLoadBalancer, 0
+	}
+}
+
+// gameEndpoint returns the address players join a server with, the gateway hostname for game
+// services only reachable in the cluster while the operator has a gateway
+func (r *MinecraftServerReconciler) gameEndpoint(ctx context.Context, m *homecraftv1alpha1.MinecraftServer, svc *corev1.Service, pod *corev1.Pod) (string, error) {
+	if r.GatewayDomain != "" && svc.Spec.Type == corev1.ServiceTypeClusterIP {
+		return gateway.Hostname(m.Name, r.GatewayDomain), nil
+	}
+	return r.serviceEndpoint(ctx, svc, pod)
+}
+
+// serviceEndpoint returns the address the first port of a service is reachable on, or "" until it
+// has one: the load balancer IP or hostname, a node address and the node port, or the in-cluster
+// DNS name of a ClusterIP service
+func (r *MinecraftServerReconciler) serviceEndpoint(ctx context.Context, svc *corev1.Service, pod *corev1.Pod) (string, error) {
+	if len(svc.Spec.Ports) == 0 {
+		return "", nil
+	}
+	port := svc.Spec.Ports[0]
+
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				return joinHostPort(ingress.IP, port.Port), nil
+			}
+			if ingress.Hostname != "" {
+				return joinHostPort(ingress.Hostname, port.Port), nil
+			}
+		}
+		return "", nil
+	case corev1.ServiceTypeNodePort:
+		if port.NodePort == 0 {
+			return "", nil
+		}
+		address, err := r.nodeAddress(ctx, pod)
+		if address == "" || err != nil {
+			return "", err
+		}
+		return joinHostPort(address, port.NodePort), nil
+	case corev1.ServiceTypeClusterIP:
+		return joinHostPort(fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace), port.Port), nil
+	}
+	return "", nil
+}
+
+// nodeAddress returns an address node ports are reachable on: the one of the node running pod, or
+// of the first ready node while the pod is missing or unscheduled. External addresses are preferred.
+func (r *MinecraftServerReconciler) nodeAddress(ctx context.Context, pod *corev1.Pod) (string, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return "", err
+	}
+	sort.Slice(nodes.Items, func(i, j int) bool { return nodes.Items[i].Name < nodes.Items[j].Name })
+
+	var fallback *corev1.Node
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if pod != nil && node.Name == pod.Spec.NodeName {
+			return preferredNodeAddress(node), nil
+		}
+		if fallback == nil && nodeReady(node) && preferredNodeAddress(node) != "" {
+			fallback = node
+		}
+	}
+	if fallback == nil {
+		return "", nil
+	}
+	return preferredNodeAddress(fallback), nil
+}
+
+// preferredNodeAddress returns the external IP of a node, or its internal IP when it has none
+func preferredNodeAddress(node *corev1.Node) string {
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType && address.Address != "" {
+				return address.Address
+			}
+		}
+	}
+	return ""
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func joinHostPort(host string, port int32) string {
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func testNode(name string, ready bool, addresses ...corev1.NodeAddress) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Addresses:  addresses,
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func TestServiceEndpoint(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(
+			testNode("node-a", false, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}),
+			testNode("node-b", true, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.2"}),
+			testNode("node-c", true,
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.3"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "203.0.113.3"}),
+		).
+		Build()
+	reconciler := &MinecraftServerReconciler{Client: fakeClient}
+
+	service := func(serviceType corev1.ServiceType, nodePort int32, ingress ...corev1.LoadBalancerIngress) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-server-minecraft", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type:  serviceType,
+				Ports: []corev1.ServicePort{{Name: "minecraft", Port: 25565, NodePort: nodePort}},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+		}
+	}
+	scheduledOn := func(node string) *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: node}}
+	}
+
+	tests := []struct {
+		name    string
+		service *corev1.Service
+		pod     *corev1.Pod
+		want    string
+	}{
+		{
+			name:    "load balancer IP",
+			service: service(corev1.ServiceTypeLoadBalancer, 30565, corev1.LoadBalancerIngress{IP: "192.168.1.240"}),
+			want:    "192.168.1.240:25565",
+		},
+		{
+			name:    "load balancer hostname",
+			service: service(corev1.ServiceTypeLoadBalancer, 30565, corev1.LoadBalancerIngress{Hostname: "lb.example.com"}),
+			want:    "lb.example.com:25565",
+		},
+		{
+			name:    "load balancer without an address yet",
+			service: service(corev1.ServiceTypeLoadBalancer, 30565),
+			want:    "",
+		},
+		{
+			name:    "node port on the node running the server",
+			service: service(corev1.ServiceTypeNodePort, 30565),
+			pod:     scheduledOn("node-c"),
+			want:    "203.0.113.3:30565",
+		},
+		{
+			name:    "node port of a stopped server",
+			service: service(corev1.ServiceTypeNodePort, 30565),
+			want:    "10.0.0.2:30565",
+		},
+		{
+			name:    "node port not allocated yet",
+			service: service(corev1.ServiceTypeNodePort, 0),
+			want:    "",
+		},
+		{
+			name:    "cluster IP",
+			service: service(corev1.ServiceTypeClusterIP, 0),
+			want:    "test-server-minecraft.default.svc:25565",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reconciler.serviceEndpoint(context.Background(), tt.service, tt.pod)
+			if err != nil {
+				t.Fatalf("serviceEndpoint() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("serviceEndpoint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcile_ExposeNodePort(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "test-user",
+			SFTPPassword: "test-pass",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+			Expose: &homecraftv1alpha1.ExposeSpec{
+				Game: &homecraftv1alpha1.ServiceExposure{Type: homecraftv1alpha1.ExposeNodePort, NodePort: 30565},
+				SFTP: &homecraftv1alpha1.ServiceExposure{Type: homecraftv1alpha1.ExposeClusterIP},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer, testNode("node-a", true, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"})).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	// The gateway only takes over game services that don't choose how they are exposed
+	reconciler := &MinecraftServerReconciler{
+		Client:        fakeClient,
+		Log:           zap.New(zap.UseDevMode(true)),
+		Scheme:        s,
+		GatewayDomain: "mc.home.lan",
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	minecraftSvc := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-minecraft", Namespace: "default"}, minecraftSvc); err != nil {
+		t.Fatalf("Failed to get game Service: %v", err)
+	}
+	if minecraftSvc.Spec.Type != corev1.ServiceTypeNodePort || minecraftSvc.Spec.Ports[0].NodePort != 30565 {
+		t.Errorf("Expected a NodePort game Service on 30565, got %s on %d", minecraftSvc.Spec.Type, minecraftSvc.Spec.Ports[0].NodePort)
+	}
+	sftpSvc := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-sftp", Namespace: "default"}, sftpSvc); err != nil {
+		t.Fatalf("Failed to get SFTP Service: %v", err)
+	}
+	if sftpSvc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("Expected a ClusterIP SFTP Service, got %s", sftpSvc.Spec.Type)
+	}
+
+	updated := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Status.Endpoint != "10.0.0.1:30565" {
+		t.Errorf("Expected endpoint 10.0.0.1:30565, got %s", updated.Status.Endpoint)
+	}
+	if updated.Status.SFTPEndpoint != "test-server-sftp.default.svc:22" {
+		t.Errorf("Expected SFTP endpoint test-server-sftp.default.svc:22, got %s", updated.Status.SFTPEndpoint)
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// statusPingTimeout bounds a status ping, a server with a loaded world answers in milliseconds
+const statusPingTimeout = 5 * time.Second
+
+// StatusPingFunc asks the Minecraft server at address for its status
+type StatusPingFunc func(ctx context.Context, address string) (*slp.Status, error)
+
+// pingResult is the outcome of a status ping of a server
+type pingResult struct {
+	status *slp.Status
+	err    error
+}
+
+// pingServer pings the game port of pod. It returns nil when the server was not pinged, because
+// the reconciler has no StatusPingFunc or the minecraft container is not running.
+func (r *MinecraftServerReconciler) pingServer(ctx context.Context, pod *corev1.Pod) *pingResult {
+	if r.Ping == nil || pod == nil || pod.Status.PodIP == "" {
+		return nil
+	}
+	if condition := containerReadyCondition(homecraftv1alpha1.ServerConditionGameReady, pod, "minecraft"); condition.Status != metav1.ConditionTrue {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statusPingTimeout)
+	defer cancel()
+	status, err := r.Ping(ctx, net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(slp.DefaultPort)))
+	return &pingResult{status: status, err: err}
+}
+
+// gameStatus converts the answer to a status ping to the game status of a server,
+// it is nil when the server did not answer
+func gameStatus(ping *pingResult, now metav1.Time) *homecraftv1alpha1.GameStatus {
+	if ping == nil || ping.err != nil {
+		return nil
+	}
+	return &homecraftv1alpha1.GameStatus{
+		MOTD:                ping.status.Description.Text,
+		Version:             ping.status.Version.Name,
+		Protocol:            ping.status.Version.Protocol,
+		PlayersOnline:       ping.status.Players.Online,
+		PlayersMax:          ping.status.Players.Max,
+		LatencyMilliseconds: ping.status.Latency.Milliseconds(),
+		LastPingTime:        now,
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileIdleShutdown stops a server that has been empty for the timeout of its idle shutdown policy.
+// The server is paused like a user would, and the reason is recorded in its status. It returns how long
+// the server can stay empty before it is stopped, or zero when it is not counting down.
+func (r *MinecraftServerReconciler) reconcileIdleShutdown(ctx context.Context, m *homecraftv1alpha1.MinecraftServer) (time.Duration, error) {
+	policy := m.Spec.IdleShutdown
+	if policy == nil || m.Spec.Paused || isRestoring(m) || m.Status.EmptySince == nil {
+		return 0, nil
+	}
+
+	timeout := time.Duration(policy.TimeoutMinutes) * time.Minute
+	idle := time.Now().UTC().Sub(m.Status.EmptySince.Time)
+	if idle < timeout {
+		return timeout - idle, nil
+	}
+
+	// The reason is stored before the server is paused, paused servers aren't looked at again here
+	// and a reason only kept in memory would be lost if writing the status failed later on
+	m.Status.StopReason = homecraftv1alpha1.StopReasonIdle
+	if err := r.Status().Update(ctx, m); err != nil {
+		return 0, err
+	}
+	m.Spec.Paused = true
+	if err := r.Update(ctx, m); err != nil {
+		return 0, err
+	}
+	m.Status.EmptySince = nil
+
+	r.Log.Info("Stopped idle server", "minecraftserver", m.Name, "timeoutMinutes", policy.TimeoutMinutes)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(m, corev1.EventTypeNormal, "IdleShutdown",
+			"Stopped the server after %d minutes without players", policy.TimeoutMinutes)
+	}
+	return 0, nil
+}
+
+// emptySince returns when a server with an idle shutdown policy was first seen running without players.
+// Any player online, a failed status ping or the server leaving the Running phase resets it, so a
+// server is never stopped while it is unclear whether somebody is playing.
+func emptySince(m *homecraftv1alpha1.MinecraftServer, phase string, game *homecraftv1alpha1.GameStatus, now metav1.Time) *metav1.Time {
+	if m.Spec.IdleShutdown == nil || phase != "Running" || game == nil || game.PlayersOnline > 0 {
+		return nil
+	}
+	if m.Status.EmptySince != nil {
+		return m.Status.EmptySince
+	}
+	return &now
+}
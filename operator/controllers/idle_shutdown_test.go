@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestReconcileIdleShutdown(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name            string
+		emptyFor        time.Duration // zero when players are online
+		paused          bool
+		wantStopped     bool
+		wantMaxDuration time.Duration
+	}{
+		{
+			name: "players online",
+		},
+		{
+			name:            "empty for less than the timeout",
+			emptyFor:        5 * time.Minute,
+			wantMaxDuration: 10 * time.Minute,
+		},
+		{
+			name:        "empty for longer than the timeout",
+			emptyFor:    20 * time.Minute,
+			wantStopped: true,
+		},
+		{
+			name:     "already stopped",
+			emptyFor: 20 * time.Minute,
+			paused:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := runtime.NewScheme()
+			_ = scheme.AddToScheme(s)
+			_ = homecraftv1alpha1.AddToScheme(s)
+
+			server := &homecraftv1alpha1.MinecraftServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"},
+				Spec: homecraftv1alpha1.MinecraftServerSpec{
+					Memory:       "2Gi",
+					Paused:       tt.paused,
+					IdleShutdown: &homecraftv1alpha1.IdleShutdownPolicy{TimeoutMinutes: 15},
+				},
+			}
+			if tt.emptyFor > 0 {
+				emptySince := metav1.NewTime(now.Add(-tt.emptyFor))
+				server.Status.EmptySince = &emptySince
+			}
+
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(server).
+				WithStatusSubresource(server).Build()
+			recorder := record.NewFakeRecorder(1)
+			reconciler := &MinecraftServerReconciler{
+				Client:   fakeClient,
+				Log:      zap.New(zap.UseDevMode(true)),
+				Scheme:   s,
+				Recorder: recorder,
+			}
+
+			untilShutdown, err := reconciler.reconcileIdleShutdown(context.Background(), server)
+			if err != nil {
+				t.Fatalf("reconcileIdleShutdown() error = %v", err)
+			}
+			if tt.wantMaxDuration > 0 && (untilShutdown <= 0 || untilShutdown > tt.wantMaxDuration) {
+				t.Errorf("reconcileIdleShutdown() shutdown in %v, want within %v", untilShutdown, tt.wantMaxDuration)
+			}
+			if tt.wantMaxDuration == 0 && untilShutdown != 0 {
+				t.Errorf("reconcileIdleShutdown() shutdown in %v, want no countdown", untilShutdown)
+			}
+
+			stored := &homecraftv1alpha1.MinecraftServer{}
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-server", Namespace: "default"}, stored); err != nil {
+				t.Fatalf("Failed to get server: %v", err)
+			}
+
+			if !tt.wantStopped {
+				if stored.Spec.Paused != tt.paused {
+					t.Errorf("Expected paused to stay %v", tt.paused)
+				}
+				if len(recorder.Events) != 0 {
+					t.Errorf("Expected no event, got %q", <-recorder.Events)
+				}
+				return
+			}
+
+			if !stored.Spec.Paused {
+				t.Error("Expected the idle server to be paused")
+			}
+			if server.Status.StopReason != homecraftv1alpha1.StopReasonIdle {
+				t.Errorf("Expected stop reason %q, got %q", homecraftv1alpha1.StopReasonIdle, server.Status.StopReason)
+			}
+			if server.Status.EmptySince != nil {
+				t.Error("Expected emptySince to be cleared")
+			}
+			select {
+			case event := <-recorder.Events:
+				if want := "Normal IdleShutdown Stopped the server after 15 minutes without players"; event != want {
+					t.Errorf("Expected event %q, got %q", want, event)
+				}
+			default:
+				t.Error("Expected an IdleShutdown event")
+			}
+		})
+	}
+}
+
+func TestReconcileIdleShutdown_StatusWriteFails(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	emptySince := metav1.NewTime(time.Now().UTC().Add(-20 * time.Minute))
+	server := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			Memory:       "2Gi",
+			IdleShutdown: &homecraftv1alpha1.IdleShutdownPolicy{TimeoutMinutes: 15},
+		},
+		Status: homecraftv1alpha1.MinecraftServerStatus{EmptySince: &emptySince},
+	}
+
+	failStatus := true
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(server).
+		WithStatusSubresource(server).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if failStatus {
+					failStatus = false
+					return errors.New("etcd unavailable")
+				}
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "test-server", Namespace: "default"}
+
+	stored := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, key, stored); err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if _, err := reconciler.reconcileIdleShutdown(ctx, stored); err == nil {
+		t.Fatal("Expected the failed status write to be returned")
+	}
+	if err := fakeClient.Get(ctx, key, stored); err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if stored.Spec.Paused {
+		t.Fatal("Expected the server to keep running until its stop reason is stored")
+	}
+
+	// The retry stops the server with the reason already stored
+	if _, err := reconciler.reconcileIdleShutdown(ctx, stored); err != nil {
+		t.Fatalf("reconcileIdleShutdown() error = %v", err)
+	}
+	if err := fakeClient.Get(ctx, key, stored); err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if !stored.Spec.Paused || stored.Status.StopReason != homecraftv1alpha1.StopReasonIdle {
+		t.Errorf("Expected a paused server stopped for %q, got paused=%v stopped for %q",
+			homecraftv1alpha1.StopReasonIdle, stored.Spec.Paused, stored.Status.StopReason)
+	}
+}
+
+func TestEmptySince(t *testing.T) {
+	now := metav1.NewTime(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	earlier := metav1.NewTime(now.Add(-10 * time.Minute))
+	empty := &homecraftv1alpha1.GameStatus{PlayersOnline: 0, PlayersMax: 20}
+	busy := &homecraftv1alpha1.GameStatus{PlayersOnline: 3, PlayersMax: 20}
+	policy := &homecraftv1alpha1.IdleShutdownPolicy{TimeoutMinutes: 15}
+
+	tests := []struct {
+		name       string
+		policy     *homecraftv1alpha1.IdleShutdownPolicy
+		emptySince *metav1.Time
+		phase      string
+		game       *homecraftv1alpha1.GameStatus
+		want       *metav1.Time
+	}{
+		{name: "without a policy", phase: "Running", game: empty},
+		{name: "starts counting", policy: policy, phase: "Running", game: empty, want: &now},
+		{name: "keeps counting", policy: policy, emptySince: &earlier, phase: "Running", game: empty, want: &earlier},
+		{name: "players online", policy: policy, emptySince: &earlier, phase: "Running", game: busy},
+		{name: "ping failed", policy: policy, emptySince: &earlier, phase: "Starting"},
+		{name: "stopped", policy: policy, emptySince: &earlier, phase: "Stopped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &homecraftv1alpha1.MinecraftServer{
+				Spec:   homecraftv1alpha1.MinecraftServerSpec{IdleShutdown: tt.policy},
+				Status: homecraftv1alpha1.MinecraftServerStatus{EmptySince: tt.emptySince},
+			}
+			got := emptySince(server, tt.phase, tt.game, now)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(tt.want)) {
+				t.Errorf("emptySince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// serverPhases are the phases a MinecraftServer can report. Every one of them gets a series,
+// so alerts can match on a phase that is currently 0.
+var serverPhases = []string{"Pending", "Starting", "Running", "Stopping", "Stopped", "Restoring", "Failed"}
+
+// Reconcile counts, errors and durations are recorded by controller-runtime as
+// controller_runtime_reconcile_total, controller_runtime_reconcile_errors_total and
+// controller_runtime_reconcile_time_seconds, labeled by controller.
+var (
+	serverPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "homecraft_server_phase",
+		Help: "Phase of a MinecraftServer, 1 for its current phase and 0 for the others.",
+	}, []string{"namespace", "server", "phase"})
+
+	serverPlayersOnline = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "homecraft_server_players_online",
+		Help: "Players connected to a MinecraftServer, as reported by its last status ping.",
+	}, []string{"namespace", "server"})
+
+	serverPlayersMax = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "homecraft_server_players_max",
+		Help: "Player slots of a MinecraftServer, as reported by its last status ping.",
+	}, []string{"namespace", "server"})
+
+	serverAllocatedMemory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "homecraft_server_allocated_memory_bytes",
+		Help: "Memory reserved in the cluster for a MinecraftServer, 0 while it is stopped.",
+	}, []string{"namespace", "server"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(serverPhase, serverPlayersOnline, serverPlayersMax, serverAllocatedMemory)
+}
+
+// recordServerMetrics exports the status of a server
+func recordServerMetrics(m *homecraftv1alpha1.MinecraftServer) {
+	for _, phase := range serverPhases {
+		value := 0.0
+		if phase == m.Status.Phase {
+			value = 1
+		}
+		serverPhase.WithLabelValues(m.Namespace, m.Name, phase).Set(value)
+	}
+
+	// A server that doesn't answer status pings has nobody online
+	players, slots := 0, 0
+	if m.Status.Game != nil {
+		players, slots = m.Status.Game.PlayersOnline, m.Status.Game.PlayersMax
+	}
+	serverPlayersOnline.WithLabelValues(m.Namespace, m.Name).Set(float64(players))
+	serverPlayersMax.WithLabelValues(m.Namespace, m.Name).Set(float64(slots))
+
+	memory := 0.0
+	if quantity, err := resource.ParseQuantity(m.Status.AllocatedMemory); err == nil {
+		memory = quantity.AsApproximateFloat64()
+	}
+	serverAllocatedMemory.WithLabelValues(m.Namespace, m.Name).Set(memory)
+}
+
+// deleteServerMetrics drops the series of a deleted server
+func deleteServerMetrics(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "server": name}
+	serverPhase.DeletePartialMatch(labels)
+	serverPlayersOnline.DeletePartialMatch(labels)
+	serverPlayersMax.DeletePartialMatch(labels)
+	serverAllocatedMemory.DeletePartialMatch(labels)
+}
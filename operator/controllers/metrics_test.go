@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"testing"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordServerMetrics(t *testing.T) {
+	server := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-server", Namespace: "minecraft-servers"},
+		Status: homecraftv1alpha1.MinecraftServerStatus{
+			Phase:           "Running",
+			AllocatedMemory: "2Gi",
+			Game:            &homecraftv1alpha1.GameStatus{PlayersOnline: 3, PlayersMax: 20},
+		},
+	}
+
+	recordServerMetrics(server)
+
+	if got := testutil.ToFloat64(serverPhase.WithLabelValues("minecraft-servers", "metrics-server", "Running")); got != 1 {
+		t.Errorf("Running phase = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(serverPhase.WithLabelValues("minecraft-servers", "metrics-server", "Failed")); got != 0 {
+		t.Errorf("Failed phase = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(serverPlayersOnline.WithLabelValues("minecraft-servers", "metrics-server")); got != 3 {
+		t.Errorf("players online = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(serverAllocatedMemory.WithLabelValues("minecraft-servers", "metrics-server")); got != 2*1024*1024*1024 {
+		t.Errorf("allocated memory = %v, want 2Gi in bytes", got)
+	}
+
+	// Stopping the server releases its memory and empties it
+	server.Status = homecraftv1alpha1.MinecraftServerStatus{Phase: "Stopped"}
+	recordServerMetrics(server)
+	if got := testutil.ToFloat64(serverPlayersOnline.WithLabelValues("minecraft-servers", "metrics-server")); got != 0 {
+		t.Errorf("players online of a stopped server = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(serverAllocatedMemory.WithLabelValues("minecraft-servers", "metrics-server")); got != 0 {
+		t.Errorf("allocated memory of a stopped server = %v, want 0", got)
+	}
+
+	before := testutil.CollectAndCount(serverPhase)
+	deleteServerMetrics("minecraft-servers", "metrics-server")
+	if got := testutil.CollectAndCount(serverPhase); got != before-len(serverPhases) {
+		t.Errorf("phase series after delete = %d, want %d", got, before-len(serverPhases))
+	}
+}
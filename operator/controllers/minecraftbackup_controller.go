@@ -0,0 +1,519 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"time"
+
+	"github.com/go-logr/logr"
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	backupFinalizerName = "minecraftbackup.homecraft.io/finalizer"
+
+	// DefaultBackupPVCName is the PersistentVolumeClaim backup archives are written to
+	DefaultBackupPVCName = "homecraft-backups"
+
+	// backupJobImage runs the archive and cleanup jobs, it only needs a shell, tar and sha256sum
+	backupJobImage = "busybox:1.36"
+
+	// backupsMountPath is where the backup volume is mounted in backup jobs
+	backupsMountPath = "/backups"
+
+	// backupSchedulingTimeout is how long an archive job may wait for a node before the backup fails,
+	// e.g. when the backup volume can't be mounted on the node of the server
+	backupSchedulingTimeout = 5 * time.Minute
+)
+
+// backupScript archives the world and reports the archive size and checksum as the termination message
+const backupScript = `set -e
+mkdir -p "$(dirname "$ARCHIVE")"
+tar -czf "$ARCHIVE.tmp" -C /data .
+mv "$ARCHIVE.tmp" "$ARCHIVE"
+size=$(stat -c %s "$ARCHIVE")
+checksum=$(sha256sum "$ARCHIVE" | cut -d ' ' -f 1)
+printf '{"size":%s,"checksum":"sha256:%s"}' "$size" "$checksum" > /dev/termination-log
+`
+
+// backupResult is the termination message written by backupScript
+type backupResult struct {
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// MinecraftBackupReconciler reconciles a MinecraftBackup object
+type MinecraftBackupReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// BackupPVCName is the claim in the backup's namespace that archives are written to
+	BackupPVCName string
+
+	// Console flushes the world of a running server before it is archived, defaults to RCONConsole
+	Console ConsoleFunc
+}
+
+// +kubebuilder:rbac:groups=homecraft.io,resources=minecraftbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=homecraft.io,resources=minecraftbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=homecraft.io,resources=minecraftbackups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+func (r *MinecraftBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("minecraftbackup", req.NamespacedName)
+
+	backup := &homecraftv1alpha1.MinecraftBackup{}
+	err := r.Get(ctx, req.NamespacedName, backup)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("MinecraftBackup resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get MinecraftBackup")
+		return ctrl.Result{}, err
+	}
+
+	// Handle deletion, the archive goes away together with the backup
+	if !backup.ObjectMeta.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(backup, backupFinalizerName) {
+			done, err := r.deleteArchive(ctx, backup)
+			if err != nil || !done {
+				return ctrl.Result{}, err
+			}
+
+			controllerutil.RemoveFinalizer(backup, backupFinalizerName)
+			if err := r.Update(ctx, backup); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Add finalizer and server label if not present
+	if !controllerutil.ContainsFinalizer(backup, backupFinalizerName) ||
+		backup.Labels[homecraftv1alpha1.ServerNameLabel] != backup.Spec.ServerName {
+		controllerutil.AddFinalizer(backup, backupFinalizerName)
+		backup.Labels = mergeStringMaps(backup.Labels, map[string]string{
+			homecraftv1alpha1.ServerNameLabel: backup.Spec.ServerName,
+		})
+		if err := r.Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if backup.Status.Phase == homecraftv1alpha1.BackupPhaseCompleted || backup.Status.Phase == homecraftv1alpha1.BackupPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	server := &homecraftv1alpha1.MinecraftServer{}
+	err = r.Get(ctx, types.NamespacedName{Name: backup.Spec.ServerName, Namespace: backup.Namespace}, server)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, r.finishBackup(ctx, backup, homecraftv1alpha1.BackupPhaseFailed,
+			fmt.Sprintf("MinecraftServer %s not found", backup.Spec.ServerName))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: backupJobName(backup), Namespace: backup.Namespace}, job)
+	if errors.IsNotFound(err) {
+		// The world of a server is incomplete while a restore replaces it
+		if restore := server.Annotations[homecraftv1alpha1.RestoreAnnotation]; restore != "" {
+			backup.Status.Message = fmt.Sprintf("Waiting for restore %s to finish", restore)
+			return ctrl.Result{RequeueAfter: restorePollInterval}, r.Status().Update(ctx, backup)
+		}
+		return ctrl.Result{}, r.startBackup(ctx, backup, server)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	finished, failureMessage := jobFinished(job)
+	if !finished {
+		unschedulable, recheckIn, err := r.unschedulableMessage(ctx, job)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if unschedulable == "" {
+			return ctrl.Result{RequeueAfter: recheckIn}, nil
+		}
+		// The job would wait forever, give up on it instead of leaving the backup running
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		failureMessage = fmt.Sprintf("its pod cannot be scheduled: %s", unschedulable)
+	}
+
+	// Saving was turned off for the archive, turn it back on unless another backup is still running
+	if err := r.resumeSaving(ctx, backup, server); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if failureMessage != "" {
+		return ctrl.Result{}, r.finishBackup(ctx, backup, homecraftv1alpha1.BackupPhaseFailed,
+			fmt.Sprintf("Archive job failed: %s", failureMessage))
+	}
+
+	result, err := r.readBackupResult(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	backup.Status.Size = result.Size
+	backup.Status.Checksum = result.Checksum
+	log.Info("Backup completed", "path", backup.Status.Path, "size", result.Size)
+	return ctrl.Result{}, r.finishBackup(ctx, backup, homecraftv1alpha1.BackupPhaseCompleted, "Backup completed")
+}
+
+// startBackup flushes the world of a running server to disk and starts the job archiving it
+func (r *MinecraftBackupReconciler) startBackup(ctx context.Context, backup *homecraftv1alpha1.MinecraftBackup, server *homecraftv1alpha1.MinecraftServer) error {
+	// Fail fast instead of leaving an unschedulable job behind
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: r.backupPVCName(), Namespace: backup.Namespace}, pvc)
+	if errors.IsNotFound(err) {
+		return r.finishBackup(ctx, backup, homecraftv1alpha1.BackupPhaseFailed,
+			fmt.Sprintf("Backup volume %s not found", r.backupPVCName()))
+	}
+	if err != nil {
+		return err
+	}
+
+	message := "Archiving world"
+	nodeName := ""
+	if !server.Spec.Paused {
+		pod, err := runningServerPod(ctx, r.Client, server)
+		switch {
+		case stderrors.Is(err, errServerNotRunning):
+			message = "Archiving world, it was not flushed because the server is not running"
+		case err != nil:
+			return err
+		default:
+			// Stop autosaving so the files do not change while they are archived
+			if _, err := runPodConsoleCommands(ctx, r.Client, r.console(), server, pod, "save-off", "save-all flush"); err != nil {
+				return fmt.Errorf("failed to flush world of %s: %w", server.Name, err)
+			}
+			nodeName = pod.Spec.NodeName
+		}
+	}
+
+	job := r.jobForBackup(backup, server, nodeName)
+	if err := controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	r.Log.Info("Started backup job", "minecraftbackup", backup.Name, "job", job.Name)
+
+	now := metav1.Now()
+	backup.Status.Phase = homecraftv1alpha1.BackupPhaseRunning
+	backup.Status.Path = backupArchivePath(backup)
+	backup.Status.StartTime = &now
+	backup.Status.Message = message
+	return r.Status().Update(ctx, backup)
+}
+
+// unschedulableMessage returns why the pod of a job has not found a node for backupSchedulingTimeout,
+// or "" and when to look again while it may still be scheduled. Archive jobs share one backup volume,
+// which servers on other nodes can't mount unless it is ReadWriteMany.
+func (r *MinecraftBackupReconciler) unschedulableMessage(ctx context.Context, job *batchv1.Job) (string, time.Duration, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", 0, err
+	}
+
+	var recheckIn time.Duration
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse || condition.Reason != corev1.PodReasonUnschedulable {
+				continue
+			}
+			waiting := time.Since(condition.LastTransitionTime.Time)
+			if waiting >= backupSchedulingTimeout {
+				return condition.Message, 0, nil
+			}
+			if remaining := backupSchedulingTimeout - waiting; recheckIn == 0 || remaining < recheckIn {
+				recheckIn = remaining
+			}
+		}
+	}
+	return "", recheckIn, nil
+}
+
+// resumeSaving turns autosaving back on after an archive job finished.
+// Other backups of the same server that are still running keep it off until they finish too.
+func (r *MinecraftBackupReconciler) resumeSaving(ctx context.Context, backup *homecraftv1alpha1.MinecraftBackup, server *homecraftv1alpha1.MinecraftServer) error {
+	if server.Spec.Paused {
+		return nil
+	}
+
+	backups := &homecraftv1alpha1.MinecraftBackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{homecraftv1alpha1.ServerNameLabel: server.Name}); err != nil {
+		return err
+	}
+	for _, other := range backups.Items {
+		if other.Name != backup.Name && other.Status.Phase == homecraftv1alpha1.BackupPhaseRunning {
+			return nil
+		}
+	}
+
+	_, err := runConsoleCommands(ctx, r.Client, r.console(), server, "save-on")
+	if err != nil && !stderrors.Is(err, errServerNotRunning) {
+		return fmt.Errorf("failed to turn saving back on for %s: %w", server.Name, err)
+	}
+	return nil
+}
+
+// finishBackup records the final phase of a backup
+func (r *MinecraftBackupReconciler) finishBackup(ctx context.Context, backup *homecraftv1alpha1.MinecraftBackup, phase, message string) error {
+	now := metav1.Now()
+	backup.Status.Phase = phase
+	backup.Status.Message = message
+	backup.Status.CompletionTime = &now
+	return r.Status().Update(ctx, backup)
+}
+
+// readBackupResult reads the archive size and checksum reported by the pod of a completed backup job
+func (r *MinecraftBackupReconciler) readBackupResult(ctx context.Context, job *batchv1.Job) (*backupResult, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return nil, err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.Message == "" {
+				continue
+			}
+			result := &backupResult{}
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), result); err != nil {
+				return nil, fmt.Errorf("invalid result reported by job %s: %w", job.Name, err)
+			}
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("no result reported by job %s", job.Name)
+}
+
+// deleteArchive removes the archive of a deleted backup from the backup volume.
+// It returns true once there is nothing left to clean up.
+func (r *MinecraftBackupReconciler) deleteArchive(ctx context.Context, backup *homecraftv1alpha1.MinecraftBackup) (bool, error) {
+	if backup.Status.Path == "" {
+		return true, nil
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: boundedName(backup.Name, "-cleanup"), Namespace: backup.Namespace}, job)
+	if errors.IsNotFound(err) {
+		job = r.cleanupJobForBackup(backup)
+		if err := controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+			return false, err
+		}
+		return false, r.Create(ctx, job)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	finished, failureMessage := jobFinished(job)
+	if finished && failureMessage != "" {
+		// Keeping the backup around would not bring the volume back, let it go
+		r.Log.Info("Failed to delete backup archive", "minecraftbackup", backup.Name, "path", backup.Status.Path, "reason", failureMessage)
+	}
+	return finished, nil
+}
+
+// jobForBackup returns the job archiving the world of server. nodeName is the node of the running
+// server pod, "" when the server has none.
+func (r *MinecraftBackupReconciler) jobForBackup(backup *homecraftv1alpha1.MinecraftBackup, server *homecraftv1alpha1.MinecraftServer, nodeName string) *batchv1.Job {
+	job := r.backupVolumeJob(backup, backupJobName(backup), backupScript)
+	podSpec := &job.Spec.Template.Spec
+
+	podSpec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "ARCHIVE", Value: path.Join(backupsMountPath, backupArchivePath(backup))},
+	}
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "data",
+		MountPath: "/data",
+		ReadOnly:  true,
+	})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: server.Name + "-data",
+				ReadOnly:  true,
+			},
+		},
+	})
+
+	// The world volume is ReadWriteOnce, so while the server pod mounts it the job has to run on its node
+	if nodeName != "" {
+		podSpec.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchFields: []corev1.NodeSelectorRequirement{{
+							Key:      "metadata.name",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{nodeName},
+						}},
+					}},
+				},
+			},
+		}
+	}
+
+	return job
+}
+
+func (r *MinecraftBackupReconciler) cleanupJobForBackup(backup *homecraftv1alpha1.MinecraftBackup) *batchv1.Job {
+	job := r.backupVolumeJob(backup, boundedName(backup.Name, "-cleanup"), `rm -f "$ARCHIVE" "$ARCHIVE.tmp"`)
+	job.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "ARCHIVE", Value: path.Join(backupsMountPath, backup.Status.Path)},
+	}
+	return job
+}
+
+// backupVolumeJob returns a job of the backup running script with the backup volume mounted
+func (r *MinecraftBackupReconciler) backupVolumeJob(backup *homecraftv1alpha1.MinecraftBackup, name, script string) *batchv1.Job {
+	labels := map[string]string{
+		homecraftv1alpha1.ServerNameLabel: backup.Spec.ServerName,
+		"homecraft.io/backup":             backup.Name,
+		"app.kubernetes.io/managed-by":    "homecraft-operator",
+	}
+	return backupVolumeJob(backup.Namespace, name, r.backupPVCName(), script, labels)
+}
+
+// backupVolumeJob returns a job running script with the backup volume mounted at backupsMountPath
+func backupVolumeJob(namespace, name, backupPVCName, script string, labels map[string]string) *batchv1.Job {
+	backoffLimit := int32(2)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "backup",
+							Image:   backupJobImage,
+							Command: []string{"/bin/sh", "-c", script},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "backups",
+									MountPath: backupsMountPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "backups",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: backupPVCName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *MinecraftBackupReconciler) backupPVCName() string {
+	if r.BackupPVCName == "" {
+		return DefaultBackupPVCName
+	}
+	return r.BackupPVCName
+}
+
+func (r *MinecraftBackupReconciler) console() ConsoleFunc {
+	if r.Console == nil {
+		return RCONConsole
+	}
+	return r.Console
+}
+
+// backupJobName returns the name of the job archiving a backup
+func backupJobName(backup *homecraftv1alpha1.MinecraftBackup) string {
+	return boundedName(backup.Name, "-archive")
+}
+
+// boundedName appends suffix to name. Job names end up in pod labels, so when the result would be
+// longer than a label value allows, name is shortened and a hash of it keeps the result unique.
+func boundedName(name, suffix string) string {
+	if len(name)+len(suffix) <= validation.LabelValueMaxLength {
+		return name + suffix
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	hashSuffix := fmt.Sprintf("-%08x", hash.Sum32())
+	return name[:validation.LabelValueMaxLength-len(suffix)-len(hashSuffix)] + hashSuffix + suffix
+}
+
+// backupArchivePath returns the location of a backup archive relative to the root of the backup volume
+func backupArchivePath(backup *homecraftv1alpha1.MinecraftBackup) string {
+	return path.Join(backup.Spec.ServerName, backup.Name+".tar.gz")
+}
+
+// jobFinished reports whether a job completed or failed, and the failure message if it failed
+func jobFinished(job *batchv1.Job) (bool, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, ""
+		case batchv1.JobFailed:
+			if condition.Message != "" {
+				return true, condition.Message
+			}
+			if condition.Reason != "" {
+				return true, condition.Reason
+			}
+			return true, "job failed"
+		}
+	}
+	return false, ""
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MinecraftBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&homecraftv1alpha1.MinecraftBackup{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
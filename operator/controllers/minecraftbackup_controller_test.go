@@ -0,0 +1,505 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// fakeConsole records the console commands sent to servers
+type fakeConsole struct {
+	addresses []string
+	commands  []string
+	err       error
+}
+
+func (f *fakeConsole) run(ctx context.Context, address, password string, commands ...string) ([]string, error) {
+	if password != "rcon-secret" {
+		return nil, fmt.Errorf("unexpected password %q", password)
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.addresses = append(f.addresses, address)
+	f.commands = append(f.commands, commands...)
+	return make([]string, len(commands)), nil
+}
+
+// backupTestObjects returns a running server with its pod and secret, the backup volume and a backup of the server
+func backupTestObjects() (*homecraftv1alpha1.MinecraftServer, *homecraftv1alpha1.MinecraftBackup, []client.Object) {
+	server := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:        true,
+			Memory:      "2Gi",
+			StorageSize: "5Gi",
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-0", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.42.0.15"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-sftp", Namespace: "default"},
+		Data:       map[string][]byte{"rcon-password": []byte("rcon-secret")},
+	}
+	backupPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultBackupPVCName, Namespace: "default"},
+	}
+	backup := &homecraftv1alpha1.MinecraftBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-20261016-120000", Namespace: "default"},
+		Spec:       homecraftv1alpha1.MinecraftBackupSpec{ServerName: "test-server"},
+	}
+
+	return server, backup, []client.Object{server, pod, secret, backupPVC, backup}
+}
+
+func newBackupReconciler(objects []client.Object, console *fakeConsole) (*MinecraftBackupReconciler, client.Client) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(objects...).
+		WithStatusSubresource(&homecraftv1alpha1.MinecraftServer{}, &homecraftv1alpha1.MinecraftBackup{}).
+		Build()
+
+	return &MinecraftBackupReconciler{
+		Client:  fakeClient,
+		Log:     zap.New(zap.UseDevMode(true)),
+		Scheme:  s,
+		Console: console.run,
+	}, fakeClient
+}
+
+// completeJob marks a job as succeeded, with a pod that reported the given termination message
+func completeJob(t *testing.T, ctx context.Context, c client.Client, name, message string) {
+	t.Helper()
+
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, job); err != nil {
+		t.Fatalf("Failed to get Job %s: %v", name, err)
+	}
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue})
+	if err := c.Status().Update(ctx, job); err != nil {
+		t.Fatalf("Failed to update Job status: %v", err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name + "-abcde", Namespace: "default", Labels: map[string]string{"job-name": name}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "backup", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}}},
+			},
+		},
+	}
+	if err := c.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create job pod: %v", err)
+	}
+}
+
+func TestJobForBackup(t *testing.T) {
+	server, backup, _ := backupTestObjects()
+	reconciler := &MinecraftBackupReconciler{BackupPVCName: "world-backups"}
+
+	job := reconciler.jobForBackup(backup, server, "node-1")
+
+	if job.Name != "test-server-20261016-120000-archive" {
+		t.Errorf("Expected job name 'test-server-20261016-120000-archive', got %s", job.Name)
+	}
+	if job.Labels[homecraftv1alpha1.ServerNameLabel] != "test-server" {
+		t.Errorf("Expected server label 'test-server', got %s", job.Labels[homecraftv1alpha1.ServerNameLabel])
+	}
+
+	podSpec := job.Spec.Template.Spec
+	if podSpec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("Expected restart policy Never, got %s", podSpec.RestartPolicy)
+	}
+
+	claims := map[string]*corev1.PersistentVolumeClaimVolumeSource{}
+	for _, volume := range podSpec.Volumes {
+		claims[volume.Name] = volume.PersistentVolumeClaim
+	}
+	if claims["data"] == nil || claims["data"].ClaimName != "test-server-data" || !claims["data"].ReadOnly {
+		t.Errorf("Expected world volume to mount claim test-server-data read-only, got %+v", claims["data"])
+	}
+	if claims["backups"] == nil || claims["backups"].ClaimName != "world-backups" {
+		t.Errorf("Expected backup volume to mount claim world-backups, got %+v", claims["backups"])
+	}
+
+	container := podSpec.Containers[0]
+	wantEnv := []corev1.EnvVar{{Name: "ARCHIVE", Value: "/backups/test-server/test-server-20261016-120000.tar.gz"}}
+	if !reflect.DeepEqual(container.Env, wantEnv) {
+		t.Errorf("Expected env %v, got %v", wantEnv, container.Env)
+	}
+
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil {
+		t.Fatal("Expected the job to be scheduled on the node of the running server pod")
+	}
+	term := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0]
+	wantTerm := corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+		{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}},
+	}}
+	if !reflect.DeepEqual(term, wantTerm) {
+		t.Errorf("Unexpected node affinity term: %+v", term)
+	}
+
+	// Without a running pod the volume is free to be mounted anywhere
+	job = reconciler.jobForBackup(backup, server, "")
+	if job.Spec.Template.Spec.Affinity != nil {
+		t.Errorf("Expected no affinity without a server pod, got %+v", job.Spec.Template.Spec.Affinity)
+	}
+}
+
+func TestBoundedName(t *testing.T) {
+	if got := boundedName("test-server-20261016-120000", "-archive"); got != "test-server-20261016-120000-archive" {
+		t.Errorf("Expected short names to be kept, got %s", got)
+	}
+
+	long := strings.Repeat("a", 60) + "-20261016-120000"
+	got := boundedName(long, "-archive")
+	if len(got) != 63 {
+		t.Errorf("Expected name of 63 characters, got %d (%s)", len(got), got)
+	}
+	if !strings.HasSuffix(got, "-archive") {
+		t.Errorf("Expected suffix to be kept, got %s", got)
+	}
+	if other := boundedName(strings.Repeat("a", 60)+"-20261016-120001", "-archive"); other == got {
+		t.Errorf("Expected different names for different backups, both are %s", got)
+	}
+}
+
+func TestReconcileBackup_FlushesAndArchivesWorld(t *testing.T) {
+	_, backup, objects := backupTestObjects()
+	console := &fakeConsole{}
+	reconciler, fakeClient := newBackupReconciler(objects, console)
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	wantCommands := []string{"save-off", "save-all flush"}
+	if !reflect.DeepEqual(console.commands, wantCommands) {
+		t.Errorf("Expected console commands %v before archiving, got %v", wantCommands, console.commands)
+	}
+	if len(console.addresses) == 0 || console.addresses[0] != "10.42.0.15:25575" {
+		t.Errorf("Expected console address 10.42.0.15:25575, got %v", console.addresses)
+	}
+
+	job := &batchv1.Job{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: backup.Name + "-archive", Namespace: "default"}, job); err != nil {
+		t.Fatalf("Failed to get archive Job: %v", err)
+	}
+	if affinity := job.Spec.Template.Spec.Affinity; affinity == nil || affinity.NodeAffinity == nil {
+		t.Error("Expected the archive job to run on the node of the server pod")
+	}
+
+	updated := &homecraftv1alpha1.MinecraftBackup{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftBackup: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.BackupPhaseRunning {
+		t.Errorf("Expected phase Running, got %s", updated.Status.Phase)
+	}
+	if updated.Status.Path != "test-server/test-server-20261016-120000.tar.gz" {
+		t.Errorf("Expected archive path test-server/test-server-20261016-120000.tar.gz, got %s", updated.Status.Path)
+	}
+	if updated.Status.StartTime == nil {
+		t.Error("Expected start time to be set")
+	}
+	if updated.Labels[homecraftv1alpha1.ServerNameLabel] != "test-server" {
+		t.Errorf("Expected server label to be added, got labels %v", updated.Labels)
+	}
+
+	// Nothing happens until the job is done
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if len(console.commands) != 2 {
+		t.Errorf("Expected no console commands while the job runs, got %v", console.commands[2:])
+	}
+
+	completeJob(t, ctx, fakeClient, job.Name, `{"size":52428800,"checksum":"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}`)
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if console.commands[len(console.commands)-1] != "save-on" {
+		t.Errorf("Expected saving to be turned back on, got commands %v", console.commands)
+	}
+
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftBackup: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.BackupPhaseCompleted {
+		t.Errorf("Expected phase Completed, got %s (%s)", updated.Status.Phase, updated.Status.Message)
+	}
+	if updated.Status.Size != 52428800 {
+		t.Errorf("Expected size 52428800, got %d", updated.Status.Size)
+	}
+	if updated.Status.Checksum != "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("Unexpected checksum %s", updated.Status.Checksum)
+	}
+	if updated.Status.CompletionTime == nil {
+		t.Error("Expected completion time to be set")
+	}
+}
+
+func TestReconcileBackup_StoppedServerIsNotFlushed(t *testing.T) {
+	server, backup, objects := backupTestObjects()
+	server.Spec.Paused = true
+	console := &fakeConsole{err: fmt.Errorf("console must not be used")}
+	reconciler, fakeClient := newBackupReconciler(objects, console)
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	job := &batchv1.Job{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: backup.Name + "-archive", Namespace: "default"}, job); err != nil {
+		t.Fatalf("Failed to get archive Job: %v", err)
+	}
+
+	completeJob(t, ctx, fakeClient, job.Name, `{"size":1024,"checksum":"sha256:abc"}`)
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	updated := &homecraftv1alpha1.MinecraftBackup{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftBackup: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.BackupPhaseCompleted {
+		t.Errorf("Expected phase Completed, got %s (%s)", updated.Status.Phase, updated.Status.Message)
+	}
+}
+
+func TestReconcileBackup_ServerWithoutPod(t *testing.T) {
+	// The server is meant to run, but its pod is gone, e.g. while its world is being restored
+	_, backup, objects := backupTestObjects()
+	var withoutPod []client.Object
+	for _, obj := range objects {
+		if _, isPod := obj.(*corev1.Pod); !isPod {
+			withoutPod = append(withoutPod, obj)
+		}
+	}
+	console := &fakeConsole{err: fmt.Errorf("console must not be used")}
+	reconciler, fakeClient := newBackupReconciler(withoutPod, console)
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	job := &batchv1.Job{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: backup.Name + "-archive", Namespace: "default"}, job); err != nil {
+		t.Fatalf("Failed to get archive Job: %v", err)
+	}
+	if affinity := job.Spec.Template.Spec.Affinity; affinity != nil {
+		t.Errorf("Expected the job to be schedulable on any node without a server pod, got %+v", affinity)
+	}
+	updated := &homecraftv1alpha1.MinecraftBackup{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftBackup: %v", err)
+	}
+	if !strings.Contains(updated.Status.Message, "not flushed") {
+		t.Errorf("Expected the backup to say the world was not flushed, got %q", updated.Status.Message)
+	}
+}
+
+func TestReconcileBackup_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(backup *homecraftv1alpha1.MinecraftBackup, objects []client.Object) []client.Object
+		wantMessage string
+	}{
+		{
+			name: "server not found",
+			modify: func(backup *homecraftv1alpha1.MinecraftBackup, objects []client.Object) []client.Object {
+				backup.Spec.ServerName = "missing-server"
+				return objects
+			},
+			wantMessage: "MinecraftServer missing-server not found",
+		},
+		{
+			name: "backup volume not found",
+			modify: func(backup *homecraftv1alpha1.MinecraftBackup, objects []client.Object) []client.Object {
+				var withoutVolume []client.Object
+				for _, obj := range objects {
+					if _, isPVC := obj.(*corev1.PersistentVolumeClaim); !isPVC {
+						withoutVolume = append(withoutVolume, obj)
+					}
+				}
+				return withoutVolume
+			},
+			wantMessage: "Backup volume homecraft-backups not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, backup, objects := backupTestObjects()
+			objects = tt.modify(backup, objects)
+			reconciler, fakeClient := newBackupReconciler(objects, &fakeConsole{})
+
+			ctx := context.Background()
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: "default"}}
+
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			updated := &homecraftv1alpha1.MinecraftBackup{}
+			if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get MinecraftBackup: %v", err)
+			}
+			if updated.Status.Phase != homecraftv1alpha1.BackupPhaseFailed {
+				t.Errorf("Expected phase Failed, got %s", updated.Status.Phase)
+			}
+			if updated.Status.Message != tt.wantMessage {
+				t.Errorf("Expected message %q, got %q", tt.wantMessage, updated.Status.Message)
+			}
+		})
+	}
+}
+
+func TestReconcileBackup_UnschedulableJob(t *testing.T) {
+	_, backup, objects := backupTestObjects()
+	console := &fakeConsole{}
+	reconciler, fakeClient := newBackupReconciler(objects, console)
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	// The backup volume is attached to another node than the server
+	jobName := backup.Name + "-archive"
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName + "-abcde", Namespace: "default", Labels: map[string]string{"job-name": jobName}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             corev1.PodReasonUnschedulable,
+				Message:            "0/2 nodes are available: 1 node(s) didn't match pod affinity rules, 1 node(s) had volume node affinity conflict.",
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+			}},
+		},
+	}
+	if err := fakeClient.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create job pod: %v", err)
+	}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > backupSchedulingTimeout {
+		t.Errorf("Expected the job to be looked at again within %v, got %v", backupSchedulingTimeout, result.RequeueAfter)
+	}
+	updated := &homecraftv1alpha1.MinecraftBackup{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftBackup: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.BackupPhaseRunning {
+		t.Fatalf("Expected the backup to keep running while the pod may be scheduled, got %s", updated.Status.Phase)
+	}
+
+	pod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-backupSchedulingTimeout))
+	if err := fakeClient.Status().Update(ctx, pod); err != nil {
+		t.Fatalf("Failed to update job pod: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftBackup: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.BackupPhaseFailed || !strings.Contains(updated.Status.Message, "volume node affinity conflict") {
+		t.Errorf("Expected the backup to fail with the scheduling message, got %s (%s)", updated.Status.Phase, updated.Status.Message)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: jobName, Namespace: "default"}, &batchv1.Job{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the unschedulable job to be deleted, got %v", err)
+	}
+	if console.commands[len(console.commands)-1] != "save-on" {
+		t.Errorf("Expected saving to be turned back on, got commands %v", console.commands)
+	}
+}
+
+func TestReconcileBackup_DeletionRemovesArchive(t *testing.T) {
+	_, backup, objects := backupTestObjects()
+	backup.Finalizers = []string{backupFinalizerName}
+	backup.Status = homecraftv1alpha1.MinecraftBackupStatus{
+		Phase: homecraftv1alpha1.BackupPhaseCompleted,
+		Path:  "test-server/test-server-20261016-120000.tar.gz",
+	}
+	reconciler, fakeClient := newBackupReconciler(objects, &fakeConsole{})
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: "default"}}
+
+	if err := fakeClient.Delete(ctx, backup); err != nil {
+		t.Fatalf("Failed to delete MinecraftBackup: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	job := &batchv1.Job{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: backup.Name + "-cleanup", Namespace: "default"}, job); err != nil {
+		t.Fatalf("Failed to get cleanup Job: %v", err)
+	}
+	wantEnv := []corev1.EnvVar{{Name: "ARCHIVE", Value: "/backups/test-server/test-server-20261016-120000.tar.gz"}}
+	if !reflect.DeepEqual(job.Spec.Template.Spec.Containers[0].Env, wantEnv) {
+		t.Errorf("Expected env %v, got %v", wantEnv, job.Spec.Template.Spec.Containers[0].Env)
+	}
+
+	// The backup stays until its archive is gone
+	if err := fakeClient.Get(ctx, req.NamespacedName, &homecraftv1alpha1.MinecraftBackup{}); err != nil {
+		t.Fatalf("Expected MinecraftBackup to still exist, got %v", err)
+	}
+
+	completeJob(t, ctx, fakeClient, job.Name, "")
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	err := fakeClient.Get(ctx, req.NamespacedName, &homecraftv1alpha1.MinecraftBackup{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected MinecraftBackup to be gone, got %v", err)
+	}
+}
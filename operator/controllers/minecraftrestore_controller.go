@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// restorePollInterval is how often a restore checks whether its server pod is gone
+	restorePollInterval = 5 * time.Second
+
+	// restoreServerGracePeriod is how long a restore waits for a server that was created together with it
+	restoreServerGracePeriod = time.Minute
+)
+
+// restoreScript verifies the archive and swaps it in for the world. The archive is extracted next to the
+// current world first, so a corrupt archive fails the job while the old world is still in place.
+const restoreScript = `set -e
+if [ -n "$CHECKSUM" ]; then
+  echo "$CHECKSUM  $ARCHIVE" | sha256sum -c -
+fi
+rm -rf /data/.restore
+mkdir /data/.restore
+tar -xzf "$ARCHIVE" -C /data/.restore
+find /data -mindepth 1 -maxdepth 1 ! -name .restore -exec rm -rf {} +
+find /data/.restore -mindepth 1 -maxdepth 1 -exec mv {} /data/ \;
+rmdir /data/.restore
+`
+
+// MinecraftRestoreReconciler reconciles a MinecraftRestore object
+type MinecraftRestoreReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// BackupPVCName is the claim in the restore's namespace that archives are read from
+	BackupPVCName string
+}
+
+// +kubebuilder:rbac:groups=homecraft.io,resources=minecraftrestores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=homecraft.io,resources=minecraftrestores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *MinecraftRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("minecraftrestore", req.NamespacedName)
+
+	restore := &homecraftv1alpha1.MinecraftRestore{}
+	err := r.Get(ctx, req.NamespacedName, restore)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("MinecraftRestore resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get MinecraftRestore")
+		return ctrl.Result{}, err
+	}
+
+	if restore.Status.Phase == homecraftv1alpha1.RestorePhaseCompleted || restore.Status.Phase == homecraftv1alpha1.RestorePhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	server := &homecraftv1alpha1.MinecraftServer{}
+	err = r.Get(ctx, types.NamespacedName{Name: restore.Spec.ServerName, Namespace: restore.Namespace}, server)
+	if errors.IsNotFound(err) {
+		// A server restored as a copy is created right before its restore
+		if time.Since(restore.CreationTimestamp.Time) < restoreServerGracePeriod {
+			return ctrl.Result{RequeueAfter: restorePollInterval}, nil
+		}
+		return ctrl.Result{}, r.finishRestore(ctx, restore, nil, homecraftv1alpha1.RestorePhaseFailed,
+			fmt.Sprintf("MinecraftServer %s not found", restore.Spec.ServerName))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A restore is of no use once its server is gone
+	if metav1.GetControllerOf(restore) == nil {
+		if err := controllerutil.SetControllerReference(server, restore, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Update(ctx, restore); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	backup := &homecraftv1alpha1.MinecraftBackup{}
+	err = r.Get(ctx, types.NamespacedName{Name: restore.Spec.BackupName, Namespace: restore.Namespace}, backup)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, r.finishRestore(ctx, restore, server, homecraftv1alpha1.RestorePhaseFailed,
+			fmt.Sprintf("MinecraftBackup %s not found", restore.Spec.BackupName))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if backup.Status.Phase != homecraftv1alpha1.BackupPhaseCompleted {
+		return ctrl.Result{}, r.finishRestore(ctx, restore, server, homecraftv1alpha1.RestorePhaseFailed,
+			fmt.Sprintf("MinecraftBackup %s is not completed", backup.Name))
+	}
+
+	// Only one restore at a time replaces the world of a server
+	if current := server.Annotations[homecraftv1alpha1.RestoreAnnotation]; current != restore.Name {
+		if current != "" {
+			return ctrl.Result{RequeueAfter: restorePollInterval}, r.setRestorePhase(ctx, restore, homecraftv1alpha1.RestorePhasePending,
+				fmt.Sprintf("Waiting for restore %s to finish", current))
+		}
+
+		// The MinecraftServer controller scales the server down while the annotation is set
+		if server.Annotations == nil {
+			server.Annotations = map[string]string{}
+		}
+		server.Annotations[homecraftv1alpha1.RestoreAnnotation] = restore.Name
+		if err := r.Update(ctx, server); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info("Stopping server for restore", "server", server.Name, "backup", backup.Name)
+	}
+
+	if restore.Status.StartTime == nil {
+		now := metav1.Now()
+		restore.Status.StartTime = &now
+	}
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: restoreJobName(restore), Namespace: restore.Namespace}, job)
+	if errors.IsNotFound(err) {
+		return r.startRestore(ctx, restore, server, backup)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	finished, failureMessage := jobFinished(job)
+	if !finished {
+		return ctrl.Result{}, nil
+	}
+	if failureMessage != "" {
+		return ctrl.Result{}, r.finishRestore(ctx, restore, server, homecraftv1alpha1.RestorePhaseFailed,
+			fmt.Sprintf("Restore job failed: %s", failureMessage))
+	}
+
+	log.Info("Restore completed", "server", server.Name, "backup", backup.Name)
+	return ctrl.Result{}, r.finishRestore(ctx, restore, server, homecraftv1alpha1.RestorePhaseCompleted,
+		fmt.Sprintf("Restored backup %s", backup.Name))
+}
+
+// startRestore waits for the server pod to be gone and starts the job extracting the backup into the world volume
+func (r *MinecraftRestoreReconciler) startRestore(ctx context.Context, restore *homecraftv1alpha1.MinecraftRestore,
+	server *homecraftv1alpha1.MinecraftServer, backup *homecraftv1alpha1.MinecraftBackup) (ctrl.Result, error) {
+
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, types.NamespacedName{Name: server.Name + "-0", Namespace: server.Namespace}, pod)
+	if err == nil {
+		message := fmt.Sprintf("Stopping server to restore backup %s", backup.Name)
+		if err := r.setServerRestoringCondition(ctx, server, metav1.ConditionTrue, "Stopping", message); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: restorePollInterval}, r.setRestorePhase(ctx, restore, homecraftv1alpha1.RestorePhaseStopping, message)
+	}
+	if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	// A new server gets its volume from the MinecraftServer controller
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = r.Get(ctx, types.NamespacedName{Name: server.Name + "-data", Namespace: server.Namespace}, pvc)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{RequeueAfter: restorePollInterval}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	job := r.jobForRestore(restore, server, backup)
+	if err := controllerutil.SetControllerReference(restore, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	r.Log.Info("Started restore job", "minecraftrestore", restore.Name, "job", job.Name)
+
+	message := fmt.Sprintf("Extracting backup %s", backup.Name)
+	if err := r.setServerRestoringCondition(ctx, server, metav1.ConditionTrue, "Extracting", message); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.setRestorePhase(ctx, restore, homecraftv1alpha1.RestorePhaseRestoring, message)
+}
+
+// finishRestore records the outcome of a restore and lets the server start again
+func (r *MinecraftRestoreReconciler) finishRestore(ctx context.Context, restore *homecraftv1alpha1.MinecraftRestore,
+	server *homecraftv1alpha1.MinecraftServer, phase, message string) error {
+
+	if server != nil && server.Annotations[homecraftv1alpha1.RestoreAnnotation] == restore.Name {
+		reason := "Restored"
+		if phase == homecraftv1alpha1.RestorePhaseFailed {
+			reason = "RestoreFailed"
+		}
+		if err := r.setServerRestoringCondition(ctx, server, metav1.ConditionFalse, reason, message); err != nil {
+			return err
+		}
+
+		delete(server.Annotations, homecraftv1alpha1.RestoreAnnotation)
+		if err := r.Update(ctx, server); err != nil {
+			return err
+		}
+	}
+
+	now := metav1.Now()
+	restore.Status.CompletionTime = &now
+	return r.setRestorePhase(ctx, restore, phase, message)
+}
+
+func (r *MinecraftRestoreReconciler) setRestorePhase(ctx context.Context, restore *homecraftv1alpha1.MinecraftRestore, phase, message string) error {
+	restore.Status.Phase = phase
+	restore.Status.Message = message
+	return r.Status().Update(ctx, restore)
+}
+
+// setServerRestoringCondition reports the progress of a restore on the MinecraftServer it replaces the world of
+func (r *MinecraftRestoreReconciler) setServerRestoringCondition(ctx context.Context, server *homecraftv1alpha1.MinecraftServer,
+	status metav1.ConditionStatus, reason, message string) error {
+
+	changed := meta.SetStatusCondition(&server.Status.Conditions, metav1.Condition{
+		Type:               homecraftv1alpha1.ServerConditionRestoring,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: server.Generation,
+	})
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, server)
+}
+
+func (r *MinecraftRestoreReconciler) jobForRestore(restore *homecraftv1alpha1.MinecraftRestore,
+	server *homecraftv1alpha1.MinecraftServer, backup *homecraftv1alpha1.MinecraftBackup) *batchv1.Job {
+
+	labels := map[string]string{
+		homecraftv1alpha1.ServerNameLabel: server.Name,
+		"homecraft.io/restore":            restore.Name,
+		"app.kubernetes.io/managed-by":    "homecraft-operator",
+	}
+	backupPVCName := r.BackupPVCName
+	if backupPVCName == "" {
+		backupPVCName = DefaultBackupPVCName
+	}
+
+	job := backupVolumeJob(restore.Namespace, restoreJobName(restore), backupPVCName, restoreScript, labels)
+	podSpec := &job.Spec.Template.Spec
+
+	podSpec.Containers[0].Name = "restore"
+	podSpec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "ARCHIVE", Value: path.Join(backupsMountPath, backup.Status.Path)},
+		{Name: "CHECKSUM", Value: strings.TrimPrefix(backup.Status.Checksum, "sha256:")},
+	}
+	podSpec.Containers[0].VolumeMounts[0].ReadOnly = true
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "data",
+		MountPath: "/data",
+	})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: server.Name + "-data",
+			},
+		},
+	})
+
+	return job
+}
+
+// restoreJobName returns the name of the job extracting a restore
+func restoreJobName(restore *homecraftv1alpha1.MinecraftRestore) string {
+	return boundedName(restore.Name, "-restore")
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MinecraftRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&homecraftv1alpha1.MinecraftRestore{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
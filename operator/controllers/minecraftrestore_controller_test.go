@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// restoreTestObjects returns a running server with its volumes, a completed backup of it and a restore of that backup
+func restoreTestObjects() (*homecraftv1alpha1.MinecraftServer, *homecraftv1alpha1.MinecraftRestore, []client.Object) {
+	server, backup, objects := backupTestObjects()
+	backup.Status = homecraftv1alpha1.MinecraftBackupStatus{
+		Phase:    homecraftv1alpha1.BackupPhaseCompleted,
+		Path:     "test-server/test-server-20261016-120000.tar.gz",
+		Size:     1024,
+		Checksum: "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	}
+	dataPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-data", Namespace: "default"},
+	}
+	restore := &homecraftv1alpha1.MinecraftRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-server-20261016-130000",
+			Namespace:         "default",
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: homecraftv1alpha1.MinecraftRestoreSpec{
+			BackupName: backup.Name,
+			ServerName: server.Name,
+		},
+	}
+
+	return server, restore, append(objects, dataPVC, restore)
+}
+
+func newRestoreReconciler(objects []client.Object) (*MinecraftRestoreReconciler, client.Client) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(objects...).
+		WithStatusSubresource(&homecraftv1alpha1.MinecraftServer{}, &homecraftv1alpha1.MinecraftBackup{}, &homecraftv1alpha1.MinecraftRestore{}).
+		Build()
+
+	return &MinecraftRestoreReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}, fakeClient
+}
+
+func TestJobForRestore(t *testing.T) {
+	server, restore, _ := restoreTestObjects()
+	backup := &homecraftv1alpha1.MinecraftBackup{
+		Status: homecraftv1alpha1.MinecraftBackupStatus{
+			Path:     "test-server/test-server-20261016-120000.tar.gz",
+			Checksum: "sha256:abc123",
+		},
+	}
+	reconciler := &MinecraftRestoreReconciler{BackupPVCName: "world-backups"}
+
+	job := reconciler.jobForRestore(restore, server, backup)
+
+	if job.Name != "test-server-20261016-130000-restore" {
+		t.Errorf("Expected job name 'test-server-20261016-130000-restore', got %s", job.Name)
+	}
+
+	container := job.Spec.Template.Spec.Containers[0]
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env["ARCHIVE"] != "/backups/test-server/test-server-20261016-120000.tar.gz" {
+		t.Errorf("Expected ARCHIVE under /backups, got %q", env["ARCHIVE"])
+	}
+	if env["CHECKSUM"] != "abc123" {
+		t.Errorf("Expected CHECKSUM without algorithm prefix, got %q", env["CHECKSUM"])
+	}
+
+	mounts := map[string]corev1.VolumeMount{}
+	for _, mount := range container.VolumeMounts {
+		mounts[mount.Name] = mount
+	}
+	if !mounts["backups"].ReadOnly {
+		t.Error("Expected backups volume to be mounted read-only")
+	}
+	if mounts["data"].MountPath != "/data" || mounts["data"].ReadOnly {
+		t.Errorf("Expected data volume mounted writable at /data, got %+v", mounts["data"])
+	}
+
+	claims := map[string]string{}
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		claims[volume.Name] = volume.PersistentVolumeClaim.ClaimName
+	}
+	if claims["backups"] != "world-backups" || claims["data"] != "test-server-data" {
+		t.Errorf("Unexpected volume claims: %v", claims)
+	}
+}
+
+func TestReconcileRestore_StopsServerAndExtractsBackup(t *testing.T) {
+	ctx := context.Background()
+	server, restore, objects := restoreTestObjects()
+	reconciler, c := newRestoreReconciler(objects)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: restore.Name, Namespace: "default"}}
+
+	// The server is still running, so the restore asks for it to stop first
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("Expected a requeue while the server pod is running")
+	}
+
+	updatedServer := &homecraftv1alpha1.MinecraftServer{}
+	if err := c.Get(ctx, types.NamespacedName{Name: server.Name, Namespace: "default"}, updatedServer); err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if updatedServer.Annotations[homecraftv1alpha1.RestoreAnnotation] != restore.Name {
+		t.Errorf("Expected restore annotation %q, got %q", restore.Name, updatedServer.Annotations[homecraftv1alpha1.RestoreAnnotation])
+	}
+	condition := meta.FindStatusCondition(updatedServer.Status.Conditions, homecraftv1alpha1.ServerConditionRestoring)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "Stopping" {
+		t.Errorf("Expected Restoring condition with reason Stopping, got %+v", condition)
+	}
+
+	updated := &homecraftv1alpha1.MinecraftRestore{}
+	if err := c.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get restore: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.RestorePhaseStopping {
+		t.Errorf("Expected phase Stopping, got %s", updated.Status.Phase)
+	}
+	if !metav1.IsControlledBy(updated, updatedServer) {
+		t.Error("Expected restore to be owned by its server")
+	}
+
+	// Once the pod is gone the backup is extracted
+	if err := c.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-server-0", Namespace: "default"}}); err != nil {
+		t.Fatalf("Failed to delete pod: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{Name: restoreJobName(restore), Namespace: "default"}, job); err != nil {
+		t.Fatalf("Expected restore job to be created: %v", err)
+	}
+	if err := c.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get restore: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.RestorePhaseRestoring {
+		t.Errorf("Expected phase Restoring, got %s", updated.Status.Phase)
+	}
+
+	completeJob(t, ctx, c, job.Name, "")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if err := c.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get restore: %v", err)
+	}
+	if updated.Status.Phase != homecraftv1alpha1.RestorePhaseCompleted {
+		t.Errorf("Expected phase Completed, got %s (%s)", updated.Status.Phase, updated.Status.Message)
+	}
+	if updated.Status.StartTime == nil || updated.Status.CompletionTime == nil {
+		t.Error("Expected start and completion time to be set")
+	}
+
+	if err := c.Get(ctx, types.NamespacedName{Name: server.Name, Namespace: "default"}, updatedServer); err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if _, ok := updatedServer.Annotations[homecraftv1alpha1.RestoreAnnotation]; ok {
+		t.Error("Expected restore annotation to be removed so the server starts again")
+	}
+	condition = meta.FindStatusCondition(updatedServer.Status.Conditions, homecraftv1alpha1.ServerConditionRestoring)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "Restored" {
+		t.Errorf("Expected Restoring=False with reason Restored, got %+v", condition)
+	}
+}
+
+func TestReconcileRestore_WaitsForOtherRestore(t *testing.T) {
+	ctx := context.Background()
+	server, restore, objects := restoreTestObjects()
+	server.Annotations = map[string]string{homecraftv1alpha1.RestoreAnnotation: "earlier-restore"}
+	reconciler, c := newRestoreReconciler(objects)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: restore.Name, Namespace: "default"}}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("Expected a requeue while another restore runs")
+	}
+
+	updatedServer := &homecraftv1alpha1.MinecraftServer{}
+	if err := c.Get(ctx, types.NamespacedName{Name: server.Name, Namespace: "default"}, updatedServer); err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if updatedServer.Annotations[homecraftv1alpha1.RestoreAnnotation] != "earlier-restore" {
+		t.Errorf("Expected the other restore to keep the server, got %q", updatedServer.Annotations[homecraftv1alpha1.RestoreAnnotation])
+	}
+
+	job := &batchv1.Job{}
+	err = c.Get(ctx, types.NamespacedName{Name: restoreJobName(restore), Namespace: "default"}, job)
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected no restore job, got %v", err)
+	}
+}
+
+func TestReconcileRestore_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(server *homecraftv1alpha1.MinecraftServer, restore *homecraftv1alpha1.MinecraftRestore, objects []client.Object) []client.Object
+		wantMessage string
+	}{
+		{
+			name: "missing backup",
+			modify: func(server *homecraftv1alpha1.MinecraftServer, restore *homecraftv1alpha1.MinecraftRestore, objects []client.Object) []client.Object {
+				restore.Spec.BackupName = "missing"
+				return objects
+			},
+			wantMessage: "MinecraftBackup missing not found",
+		},
+		{
+			name: "backup not completed",
+			modify: func(server *homecraftv1alpha1.MinecraftServer, restore *homecraftv1alpha1.MinecraftRestore, objects []client.Object) []client.Object {
+				for _, obj := range objects {
+					if backup, ok := obj.(*homecraftv1alpha1.MinecraftBackup); ok {
+						backup.Status.Phase = homecraftv1alpha1.BackupPhaseRunning
+					}
+				}
+				return objects
+			},
+			wantMessage: "MinecraftBackup test-server-20261016-120000 is not completed",
+		},
+		{
+			name: "server never created",
+			modify: func(server *homecraftv1alpha1.MinecraftServer, restore *homecraftv1alpha1.MinecraftRestore, objects []client.Object) []client.Object {
+				restore.Spec.ServerName = "missing"
+				restore.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * restoreServerGracePeriod))
+				return objects
+			},
+			wantMessage: "MinecraftServer missing not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server, restore, objects := restoreTestObjects()
+			objects = tt.modify(server, restore, objects)
+			reconciler, c := newRestoreReconciler(objects)
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: restore.Name, Namespace: "default"}}
+
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			updated := &homecraftv1alpha1.MinecraftRestore{}
+			if err := c.Get(ctx, req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get restore: %v", err)
+			}
+			if updated.Status.Phase != homecraftv1alpha1.RestorePhaseFailed {
+				t.Errorf("Expected phase Failed, got %s", updated.Status.Phase)
+			}
+			if updated.Status.Message != tt.wantMessage {
+				t.Errorf("Expected message %q, got %q", tt.wantMessage, updated.Status.Message)
+			}
+
+			updatedServer := &homecraftv1alpha1.MinecraftServer{}
+			if err := c.Get(ctx, types.NamespacedName{Name: server.Name, Namespace: "default"}, updatedServer); err != nil {
+				t.Fatalf("Failed to get server: %v", err)
+			}
+			if _, ok := updatedServer.Annotations[homecraftv1alpha1.RestoreAnnotation]; ok {
+				t.Error("Expected a failed restore not to stop the server")
+			}
+		})
+	}
+}
@@ -3,25 +3,41 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/rcon"
+	"github.com/homecraft/backend/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const (
 	finalizerName = "minecraftserver.homecraft.io/finalizer"
+
+	// sftpCredentialsMountPath is where the sftp container sees the current SFTP password and keys
+	sftpCredentialsMountPath = "/etc/homecraft/sftp"
+
+	// authorizedKeysSecretKey is the key of the SSH public keys allowed to log in over SFTP in a server's Secret
+	authorizedKeysSecretKey = "authorized-keys"
+
+	// startingRequeueAfter is how often a starting server is checked for having loaded its world
+	startingRequeueAfter = 5 * time.Second
 )
 
 // MinecraftServerReconciler reconciles a MinecraftServer object
@@ -29,15 +45,35 @@ type MinecraftServerReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// Ping tells whether a server has loaded its world. Without it a running container counts as ready.
+	Ping StatusPingFunc
+	// Recorder emits the events of servers the operator stops on its own
+	Recorder record.EventRecorder
+	// WakeProxyImage runs the proxies that start servers when a player joins. Without it servers
+	// with wakeOnConnect are stopped like any other.
+	WakeProxyImage string
+	// WakeProxyServiceAccount is the service account of the wake proxies, DefaultWakeProxyServiceAccount when empty
+	WakeProxyServiceAccount string
+	// GatewayDomain is the parent domain of the hostnames the shared gateway routes to servers. With it
+	// game services are only reachable in the cluster and players join through the gateway instead.
+	GatewayDomain string
+	// Tunnels opens the public tunnels of servers with publicTunnel set, nil leaves them without one
+	Tunnels TunnelProvider
 }
 
 // +kubebuilder:rbac:groups=homecraft.io,resources=minecraftservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=homecraft.io,resources=minecraftservers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=homecraft.io,resources=minecraftservers/finalizers,verbs=update
+// +kubebuilder:rbac:groups=homecraft.io,resources=minecraftbackups,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *MinecraftServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("minecraftserver", req.NamespacedName)
@@ -48,6 +84,7 @@ func (r *MinecraftServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	if err != nil {
 		if errors.IsNotFound(err) {
 			log.Info("MinecraftServer resource not found. Ignoring since object must be deleted")
+			deleteServerMetrics(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "Failed to get MinecraftServer")
@@ -57,8 +94,11 @@ func (r *MinecraftServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	// Handle deletion
 	if !minecraftServer.ObjectMeta.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(minecraftServer, finalizerName) {
-			// Cleanup logic here if needed
 			log.Info("Cleaning up resources for MinecraftServer")
+			// The tunnel lives outside the cluster, owner references don't remove it
+			if err := r.deleteTunnel(ctx, minecraftServer); err != nil {
+				return ctrl.Result{}, err
+			}
 
 			controllerutil.RemoveFinalizer(minecraftServer, finalizerName)
 			err := r.Update(ctx, minecraftServer)
@@ -78,12 +118,31 @@ func (r *MinecraftServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 	}
 
-	// Create or update Secret for SFTP credentials
+	// Create or update Secret for SFTP credentials and the RCON password
 	secret := r.secretForMinecraftServer(minecraftServer)
+	if err := r.ensureSecretPasswords(ctx, secret); err != nil {
+		return ctrl.Result{}, err
+	}
 	if err := r.createOrUpdateResource(ctx, secret, minecraftServer); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Servers created before the Secret held the only copy of the SFTP password carry it in
+	// their spec; now that the Secret has it, drop it from the spec
+	if minecraftServer.Spec.SFTPPassword != "" {
+		log.Info("Removing SFTP password from the MinecraftServer spec")
+		minecraftServer.Spec.SFTPPassword = ""
+		if err := r.Update(ctx, minecraftServer); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Stop the server when it has been empty for longer than its idle timeout
+	untilIdleShutdown, err := r.reconcileIdleShutdown(ctx, minecraftServer)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Create or update PVC
 	pvc := r.pvcForMinecraftServer(minecraftServer)
 	if err := r.createOrUpdateResource(ctx, pvc, minecraftServer); err != nil {
@@ -96,6 +155,11 @@ func (r *MinecraftServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
+	// Run the proxy that stands in for the server while it is stopped
+	if err := r.reconcileWakeProxy(ctx, minecraftServer); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Create or update Service for Minecraft (game port)
 	minecraftSvc := r.serviceForMinecraft(minecraftServer)
 	if err := r.createOrUpdateResource(ctx, minecraftSvc, minecraftServer); err != nil {
@@ -108,54 +172,280 @@ func (r *MinecraftServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
+	// Create scheduled backups that are due and prune old ones
+	untilNextBackup, err := r.reconcileBackupSchedule(ctx, minecraftServer)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Open or close the public tunnel to the game port
+	r.reconcileTunnel(ctx, minecraftServer, minecraftSvc)
+
 	// Update status
-	if err := r.updateStatus(ctx, minecraftServer, statefulSet, minecraftSvc, sftpSvc); err != nil {
+	if err := r.updateStatus(ctx, minecraftServer, secret, statefulSet, minecraftSvc, sftpSvc); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	requeueAfter := 30 * time.Second
+	if minecraftServer.Status.Phase == "Starting" {
+		// Nothing changes on the cluster when the world finishes loading, check on it sooner
+		requeueAfter = startingRequeueAfter
+	}
+	if untilNextBackup > 0 && untilNextBackup < requeueAfter {
+		requeueAfter = untilNextBackup
+	}
+	if untilIdleShutdown > 0 && untilIdleShutdown < requeueAfter {
+		requeueAfter = untilIdleShutdown
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
+// createOrUpdateResource makes the live object match the desired one built from the MinecraftServer spec.
+// Missing objects are created; existing ones only get the fields owned by the operator overwritten,
+// so spec changes roll out and manual edits are reverted without fighting API server defaults.
 func (r *MinecraftServerReconciler) createOrUpdateResource(ctx context.Context, obj client.Object, owner *homecraftv1alpha1.MinecraftServer) error {
-	// Set owner reference for garbage collection
-	if err := controllerutil.SetControllerReference(owner, obj, r.Scheme); err != nil {
+	desired := obj.DeepCopyObject().(client.Object)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if err := mutateResource(obj, desired); err != nil {
+			return err
+		}
+		// Set owner reference for garbage collection
+		return controllerutil.SetControllerReference(owner, obj, r.Scheme)
+	})
+	if err != nil {
 		return err
 	}
 
-	// Try to get the resource
-	key := types.NamespacedName{
-		Name:      obj.GetName(),
-		Namespace: obj.GetNamespace(),
+	if result != controllerutil.OperationResultNone {
+		r.Log.Info("Reconciled resource", "kind", fmt.Sprintf("%T", desired), "name", obj.GetName(), "operation", result)
 	}
+	return nil
+}
 
-	existing := obj.DeepCopyObject().(client.Object)
-	err := r.Get(ctx, key, existing)
+// mutateResource copies the operator-owned fields of desired into existing.
+// existing has an empty resourceVersion when it does not exist in the cluster yet.
+func mutateResource(existing, desired client.Object) error {
+	existing.SetLabels(mergeStringMaps(existing.GetLabels(), desired.GetLabels()))
 
-	if err != nil && errors.IsNotFound(err) {
-		// Create the resource
-		r.Log.Info("Creating resource", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "name", obj.GetName())
-		return r.Create(ctx, obj)
-	} else if err != nil {
-		return err
+	switch e := existing.(type) {
+	case *corev1.Secret:
+		d := desired.(*corev1.Secret)
+		if e.Data == nil {
+			e.Data = map[string][]byte{}
+		}
+		for k, v := range d.Data {
+			e.Data[k] = v
+		}
+		for k, v := range d.StringData {
+			e.Data[k] = []byte(v)
+		}
+		e.StringData = nil
+	case *corev1.PersistentVolumeClaim:
+		d := desired.(*corev1.PersistentVolumeClaim)
+		if e.ResourceVersion == "" {
+			e.Spec = d.Spec
+			return nil
+		}
+		// A bound claim is immutable apart from growing its storage request
+		desiredSize := d.Spec.Resources.Requests[corev1.ResourceStorage]
+		currentSize := e.Spec.Resources.Requests[corev1.ResourceStorage]
+		if desiredSize.Cmp(currentSize) > 0 {
+			if e.Spec.Resources.Requests == nil {
+				e.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			e.Spec.Resources.Requests[corev1.ResourceStorage] = desiredSize
+		}
+	case *appsv1.StatefulSet:
+		d := desired.(*appsv1.StatefulSet)
+		if e.ResourceVersion == "" {
+			e.Spec = d.Spec
+			return nil
+		}
+		// The selector and service name are immutable, everything else we own is rewritten
+		e.Spec.Replicas = d.Spec.Replicas
+		e.Spec.Template.Labels = mergeStringMaps(e.Spec.Template.Labels, d.Spec.Template.Labels)
+		e.Spec.Template.Annotations = mergeStringMaps(e.Spec.Template.Annotations, d.Spec.Template.Annotations)
+		mergePodSpec(&e.Spec.Template.Spec, &d.Spec.Template.Spec)
+	case *appsv1.Deployment:
+		d := desired.(*appsv1.Deployment)
+		if e.ResourceVersion == "" {
+			e.Spec = d.Spec
+			return nil
+		}
+		// The selector is immutable
+		e.Spec.Replicas = d.Spec.Replicas
+		e.Spec.Template.Labels = mergeStringMaps(e.Spec.Template.Labels, d.Spec.Template.Labels)
+		mergePodSpec(&e.Spec.Template.Spec, &d.Spec.Template.Spec)
+	case *corev1.Service:
+		d := desired.(*corev1.Service)
+		if e.ResourceVersion == "" {
+			e.Spec = d.Spec
+			return nil
+		}
+		if d.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			// These may only be set on load balancers
+			e.Spec.AllocateLoadBalancerNodePorts = nil
+			e.Spec.HealthCheckNodePort = 0
+			e.Spec.LoadBalancerClass = nil
+		}
+		if d.Spec.Type == corev1.ServiceTypeClusterIP {
+			// This may only be set on services exposed outside the cluster
+			e.Spec.ExternalTrafficPolicy = ""
+		}
+		e.Spec.Type = d.Spec.Type
+		e.Spec.Selector = d.Spec.Selector
+		e.Spec.Ports = mergeServicePorts(e.Spec.Ports, d.Spec.Ports, d.Spec.Type)
+	default:
+		return fmt.Errorf("unsupported resource type %T", existing)
 	}
-
-	r.Log.Info("Resource already exists", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "name", obj.GetName())
 	return nil
 }
 
+// mergePodSpec copies the operator-owned fields of a desired pod template into an existing one
+func mergePodSpec(existing, desired *corev1.PodSpec) {
+	existing.ServiceAccountName = desired.ServiceAccountName
+	existing.Volumes = desired.Volumes
+	// The API server stores an empty security context when none is set
+	existing.SecurityContext = desired.SecurityContext
+	if existing.SecurityContext == nil {
+		existing.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	existing.Containers = mergeContainers(existing.Containers, desired.Containers)
+}
+
+// mergeContainers returns the desired containers, keeping the API server defaulted fields
+// (pull policy, termination message settings, ...) of containers that already exist.
+func mergeContainers(existing, desired []corev1.Container) []corev1.Container {
+	merged := make([]corev1.Container, 0, len(desired))
+	for _, d := range desired {
+		container := d
+		for _, e := range existing {
+			if e.Name != d.Name {
+				continue
+			}
+			container = e
+			container.Image = d.Image
+			container.Command = d.Command
+			container.Args = d.Args
+			container.Env = d.Env
+			container.Ports = d.Ports
+			container.VolumeMounts = d.VolumeMounts
+			container.Resources = d.Resources
+			container.ReadinessProbe = d.ReadinessProbe
+			container.LivenessProbe = d.LivenessProbe
+			container.SecurityContext = d.SecurityContext
+			break
+		}
+		merged = append(merged, container)
+	}
+	return merged
+}
+
+// mergeServicePorts returns the desired ports, keeping the node ports allocated by the cluster
+// unless a specific one is requested or the service no longer exposes node ports.
+func mergeServicePorts(existing, desired []corev1.ServicePort, serviceType corev1.ServiceType) []corev1.ServicePort {
+	merged := make([]corev1.ServicePort, 0, len(desired))
+	for _, d := range desired {
+		port := d
+		if port.NodePort == 0 && serviceType != corev1.ServiceTypeClusterIP {
+			for _, e := range existing {
+				if e.Name == d.Name {
+					port.NodePort = e.NodePort
+					break
+				}
+			}
+		}
+		merged = append(merged, port)
+	}
+	return merged
+}
+
+// mergeStringMaps returns base overlaid with the entries of overrides
+func mergeStringMaps(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// secretForMinecraftServer builds the Secret holding a server's SFTP and RCON credentials.
+// Only a password still set in the spec is filled in here, ensureSecretPasswords adds the rest.
 func (r *MinecraftServerReconciler) secretForMinecraftServer(m *homecraftv1alpha1.MinecraftServer) *corev1.Secret {
+	stringData := map[string]string{
+		utils.SFTPUsernameSecretKey: sftpUsername(m),
+		// Always set so the sftp container can mount it, without a trailing newline
+		// so the liveness probe's comparison with the environment holds
+		authorizedKeysSecretKey: strings.Join(m.Spec.AuthorizedKeys, "\n"),
+	}
+	if m.Spec.SFTPPassword != "" {
+		stringData[utils.SFTPPasswordSecretKey] = m.Spec.SFTPPassword
+	}
+
 	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      m.Name + "-sftp",
 			Namespace: m.Namespace,
 		},
-		StringData: map[string]string{
-			"username": m.Spec.SFTPUsername,
-			"password": m.Spec.SFTPPassword,
+		StringData: stringData,
+	}
+}
+
+// ensureSecretPasswords generates the SFTP and RCON passwords of a new server into the desired Secret.
+// Passwords already stored in the cluster are left out, the merge into the existing Secret keeps them,
+// so the running server, API clients and a password rotated by the API are not overwritten.
+func (r *MinecraftServerReconciler) ensureSecretPasswords(ctx context.Context, secret *corev1.Secret) error {
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(secret), existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	generators := map[string]func() (string, error){
+		utils.SFTPPasswordSecretKey: utils.GenerateSFTPPassword,
+		rcon.PasswordSecretKey:      utils.GenerateRCONPassword,
+	}
+	for key, generate := range generators {
+		if secret.StringData[key] != "" || len(existing.Data[key]) > 0 {
+			continue
+		}
+		password, err := generate()
+		if err != nil {
+			return err
+		}
+		secret.StringData[key] = password
+	}
+	return nil
+}
+
+// secretEnvVar returns an environment variable set from a key of a Secret
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
 		},
 	}
 }
 
+// sftpUsername returns the SFTP username of a server, derived from its name unless the spec sets one
+func sftpUsername(m *homecraftv1alpha1.MinecraftServer) string {
+	if m.Spec.SFTPUsername != "" {
+		return m.Spec.SFTPUsername
+	}
+	return utils.SFTPUsername(m.Name)
+}
+
 func (r *MinecraftServerReconciler) pvcForMinecraftServer(m *homecraftv1alpha1.MinecraftServer) *corev1.PersistentVolumeClaim {
 	storageQuantity := resource.MustParse(m.Spec.StorageSize)
 
@@ -178,7 +468,12 @@ func (r *MinecraftServerReconciler) pvcForMinecraftServer(m *homecraftv1alpha1.M
 }
 
 func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1alpha1.MinecraftServer) *appsv1.StatefulSet {
+	// A paused server keeps its StatefulSet and data but runs no pod, and neither
+	// does a server whose world is being replaced by a restore
 	replicas := int32(1)
+	if m.Spec.Paused || isRestoring(m) {
+		replicas = 0
+	}
 	memoryQuantity := resource.MustParse(m.Spec.Memory)
 
 	// Default values
@@ -208,6 +503,17 @@ func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1
 		{Name: "VERSION", Value: version},
 		{Name: "TYPE", Value: serverType},
 		{Name: "MEMORY", Value: minecraftMemory},
+		{Name: "ENABLE_RCON", Value: "true"},
+		{Name: "RCON_PORT", Value: fmt.Sprintf("%d", rcon.DefaultPort)},
+		{
+			Name: "RCON_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: m.Name + "-sftp"},
+					Key:                  rcon.PasswordSecretKey,
+				},
+			},
+		},
 	}
 
 	if m.Spec.MaxPlayers > 0 {
@@ -229,8 +535,37 @@ func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1
 		})
 	}
 
-	// SFTP user format: username:password:uid:gid:dir
-	sftpUser := fmt.Sprintf("%s:%s:1000:1000:/data", m.Spec.SFTPUsername, m.Spec.SFTPPassword)
+	// The SFTP credentials come from the server Secret; SFTP_USERS is expanded by the kubelet
+	// into the user format of atmoz/sftp: username:password:uid:gid:dir. An empty password
+	// turns off password logins.
+	sftpUsers := "$(SFTP_USERNAME):$(SFTP_PASSWORD):1000:1000:/data"
+	if m.Spec.DisableSFTPPassword {
+		sftpUsers = "$(SFTP_USERNAME)::1000:1000:/data"
+	}
+	sftpEnv := []corev1.EnvVar{
+		secretEnvVar("SFTP_USERNAME", m.Name+"-sftp", utils.SFTPUsernameSecretKey),
+		secretEnvVar("SFTP_PASSWORD", m.Name+"-sftp", utils.SFTPPasswordSecretKey),
+		secretEnvVar("SFTP_AUTHORIZED_KEYS", m.Name+"-sftp", authorizedKeysSecretKey),
+		{Name: "SFTP_USERS", Value: sftpUsers},
+	}
+
+	// The password and keys in the environment are only read when the container starts. The Secret
+	// is also mounted, where the kubelet keeps it up to date, so once the password is rotated or the
+	// keys change the probe fails and only the sftp container is restarted, the game keeps running.
+	sftpCredentialsMode := int32(0o400)
+	sftpLivenessProbe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"sh", "-c", fmt.Sprintf(
+					`test "$(cat %[1]s/%[2]s)" = "$SFTP_PASSWORD" && test "$(cat %[1]s/%[3]s)" = "$SFTP_AUTHORIZED_KEYS"`,
+					sftpCredentialsMountPath, utils.SFTPPasswordSecretKey, authorizedKeysSecretKey)},
+			},
+		},
+		PeriodSeconds:    30,
+		TimeoutSeconds:   5,
+		SuccessThreshold: 1,
+		FailureThreshold: 1,
+	}
 
 	return &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -259,6 +594,11 @@ func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1
 									ContainerPort: 25565,
 									Protocol:      corev1.ProtocolTCP,
 								},
+								{
+									Name:          "rcon",
+									ContainerPort: rcon.DefaultPort,
+									Protocol:      corev1.ProtocolTCP,
+								},
 							},
 							Env: minecraftEnv,
 							VolumeMounts: []corev1.VolumeMount{
@@ -277,9 +617,10 @@ func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1
 							},
 						},
 						{
-							Name:  "sftp",
-							Image: "atmoz/sftp:latest",
-							Args:  []string{sftpUser},
+							Name:          "sftp",
+							Image:         "atmoz/sftp:latest",
+							Env:           sftpEnv,
+							LivenessProbe: sftpLivenessProbe,
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "sftp",
@@ -290,7 +631,18 @@ func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "data",
-									MountPath: "/home/" + m.Spec.SFTPUsername + "/data",
+									MountPath: "/home/" + sftpUsername(m) + "/data",
+								},
+								{
+									Name:      "sftp-credentials",
+									MountPath: sftpCredentialsMountPath,
+									ReadOnly:  true,
+								},
+								{
+									// atmoz/sftp adds the keys in this directory to the user's authorized_keys
+									Name:      "sftp-authorized-keys",
+									MountPath: "/home/" + sftpUsername(m) + "/.ssh/keys",
+									ReadOnly:  true,
 								},
 							},
 						},
@@ -304,6 +656,31 @@ func (r *MinecraftServerReconciler) statefulSetForMinecraftServer(m *homecraftv1
 								},
 							},
 						},
+						{
+							Name: "sftp-credentials",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: m.Name + "-sftp",
+									Items: []corev1.KeyToPath{
+										{Key: utils.SFTPPasswordSecretKey, Path: utils.SFTPPasswordSecretKey},
+										{Key: authorizedKeysSecretKey, Path: authorizedKeysSecretKey},
+									},
+									DefaultMode: &sftpCredentialsMode,
+								},
+							},
+						},
+						{
+							Name: "sftp-authorized-keys",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: m.Name + "-sftp",
+									Items: []corev1.KeyToPath{
+										{Key: authorizedKeysSecretKey, Path: "homecraft.pub"},
+									},
+									DefaultMode: &sftpCredentialsMode,
+								},
+							},
+						},
 					},
 				},
 			},
@@ -316,6 +693,12 @@ func (r *MinecraftServerReconciler) serviceForMinecraft(m *homecraftv1alpha1.Min
 		"app":             "minecraft",
 		"minecraftserver": m.Name,
 	}
+	// Players reach the wake proxy instead of the server until it has loaded its world
+	selector := labels
+	if r.wakeProxyServing(m) {
+		selector = wakeProxyLabels(m)
+	}
+	serviceType, nodePort := exposedServiceType(r.gameExposure(m))
 
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -324,13 +707,14 @@ func (r *MinecraftServerReconciler) serviceForMinecraft(m *homecraftv1alpha1.Min
 			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
-			Type:     corev1.ServiceTypeLoadBalancer,
-			Selector: labels,
+			Type:     serviceType,
+			Selector: selector,
 			Ports: []corev1.ServicePort{
 				{
 					Name:       "minecraft",
 					Port:       25565,
 					TargetPort: intstr.FromInt(25565),
+					NodePort:   nodePort,
 					Protocol:   corev1.ProtocolTCP,
 				},
 			},
@@ -343,6 +727,7 @@ func (r *MinecraftServerReconciler) serviceForSFTP(m *homecraftv1alpha1.Minecraf
 		"app":             "minecraft",
 		"minecraftserver": m.Name,
 	}
+	serviceType, nodePort := exposedServiceType(sftpExposure(m))
 
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -351,13 +736,14 @@ func (r *MinecraftServerReconciler) serviceForSFTP(m *homecraftv1alpha1.Minecraf
 			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
-			Type:     corev1.ServiceTypeLoadBalancer,
+			Type:     serviceType,
 			Selector: labels,
 			Ports: []corev1.ServicePort{
 				{
 					Name:       "sftp",
 					Port:       22,
 					TargetPort: intstr.FromInt(22),
+					NodePort:   nodePort,
 					Protocol:   corev1.ProtocolTCP,
 				},
 			},
@@ -366,7 +752,7 @@ func (r *MinecraftServerReconciler) serviceForSFTP(m *homecraftv1alpha1.Minecraf
 }
 
 func (r *MinecraftServerReconciler) updateStatus(ctx context.Context, m *homecraftv1alpha1.MinecraftServer,
-	sts *appsv1.StatefulSet, minecraftSvc *corev1.Service, sftpSvc *corev1.Service) error {
+	secret *corev1.Secret, sts *appsv1.StatefulSet, minecraftSvc *corev1.Service, sftpSvc *corev1.Service) error {
 
 	// Get the actual StatefulSet to check status
 	actualSts := &appsv1.StatefulSet{}
@@ -388,50 +774,115 @@ func (r *MinecraftServerReconciler) updateStatus(ctx context.Context, m *homecra
 		return err
 	}
 
+	// The claim and pod are missing while they are created, and the pod while the server is stopped
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: m.Name + "-data", Namespace: m.Namespace}, pvc); errors.IsNotFound(err) {
+		pvc = nil
+	} else if err != nil {
+		return err
+	}
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: m.Name + "-0", Namespace: m.Namespace}, pod); errors.IsNotFound(err) {
+		pod = nil
+	} else if err != nil {
+		return err
+	}
+
+	minecraftEndpoint, err := r.gameEndpoint(ctx, m, actualMinecraftSvc, pod)
+	if err != nil {
+		return err
+	}
+	sftpEndpoint, err := r.serviceEndpoint(ctx, actualSftpSvc, pod)
+	if err != nil {
+		return err
+	}
+
+	ping := r.pingServer(ctx, pod)
+	waitForConsumer, err := r.waitsForFirstConsumer(ctx, pvc)
+	if err != nil {
+		return err
+	}
+	conditions := serverConditions(m, pvc, waitForConsumer, pod, ping, minecraftEndpoint)
+
 	// Determine phase
 	phase := "Pending"
 	message := "Creating resources"
 
-	if actualSts.Status.ReadyReplicas > 0 {
+	if isRestoring(m) {
+		phase = "Restoring"
+		message = "Restoring world from backup"
+	} else if m.Spec.Paused {
+		if actualSts.Status.Replicas > 0 {
+			phase = "Stopping"
+			message = "Server is shutting down"
+		} else {
+			phase = "Stopped"
+			message = "Server is stopped"
+			if m.Status.StopReason == homecraftv1alpha1.StopReasonIdle {
+				message = "Server was stopped because nobody was online"
+			}
+		}
+	} else if failure := failureMessage(conditions); failure != "" {
+		phase = "Failed"
+		message = failure
+	} else if meta.IsStatusConditionTrue(conditions, homecraftv1alpha1.ServerConditionGameReady) {
 		phase = "Running"
 		message = "Server is running"
 	} else if actualSts.Status.Replicas > 0 {
 		phase = "Starting"
 		message = "Server is starting"
 	}
-
-	// Build endpoints using LoadBalancer IPs
-	minecraftEndpoint := ""
-	sftpEndpoint := ""
-
-	// Get LoadBalancer IP for Minecraft service
-	if len(actualMinecraftSvc.Status.LoadBalancer.Ingress) > 0 {
-		lbIP := actualMinecraftSvc.Status.LoadBalancer.Ingress[0].IP
-		if lbIP != "" && len(actualMinecraftSvc.Spec.Ports) > 0 {
-			minecraftEndpoint = fmt.Sprintf("%s:%d", lbIP, actualMinecraftSvc.Spec.Ports[0].Port)
-		}
-	}
-
-	// Get LoadBalancer IP for SFTP service
-	if len(actualSftpSvc.Status.LoadBalancer.Ingress) > 0 {
-		lbIP := actualSftpSvc.Status.LoadBalancer.Ingress[0].IP
-		if lbIP != "" && len(actualSftpSvc.Spec.Ports) > 0 {
-			sftpEndpoint = fmt.Sprintf("%s:%d", lbIP, actualSftpSvc.Spec.Ports[0].Port)
-		}
+	if (phase == "Pending" || phase == "Starting") && pvc != nil && pvc.Status.Phase != corev1.ClaimBound {
+		message = "Waiting for storage for the world"
 	}
 
 	// Update status
 	m.Status.Phase = phase
 	m.Status.Message = message
 	m.Status.Endpoint = minecraftEndpoint
-	m.Status.PublicEndpoint = m.Spec.PublicEndpoint
+	if m.Status.TunnelID == "" {
+		m.Status.PublicEndpoint = m.Spec.PublicEndpoint
+	}
 	m.Status.SFTPEndpoint = sftpEndpoint
-	m.Status.SFTPUsername = m.Spec.SFTPUsername
-	m.Status.SFTPPassword = m.Spec.SFTPPassword
+	m.Status.SFTPUsername = sftpUsername(m)
+	// The password is only kept in the server Secret
+	m.Status.SFTPPassword = ""
+	m.Status.SFTPPasswordRotatedAt = sftpPasswordRotatedAt(secret)
 	m.Status.AllocatedMemory = m.Spec.Memory
+	if phase == "Stopped" {
+		// A stopped server holds no memory in the cluster
+		m.Status.AllocatedMemory = ""
+	}
+	for _, condition := range conditions {
+		condition.ObservedGeneration = m.Generation
+		meta.SetStatusCondition(&m.Status.Conditions, condition)
+	}
 	m.Status.LastUpdated = metav1.Now()
+	m.Status.Game = gameStatus(ping, m.Status.LastUpdated)
+	m.Status.EmptySince = emptySince(m, phase, m.Status.Game, m.Status.LastUpdated)
+	if !m.Spec.Paused {
+		m.Status.StopReason = ""
+	}
 
-	return r.Status().Update(ctx, m)
+	if err := r.Status().Update(ctx, m); err != nil {
+		return err
+	}
+	recordServerMetrics(m)
+	return nil
+}
+
+// sftpPasswordRotatedAt returns when the API last rotated the SFTP password stored in secret
+func sftpPasswordRotatedAt(secret *corev1.Secret) *metav1.Time {
+	rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[homecraftv1alpha1.SFTPPasswordRotatedAnnotation])
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: rotatedAt}
+}
+
+// isRestoring reports whether a MinecraftRestore has claimed the server's world
+func isRestoring(m *homecraftv1alpha1.MinecraftServer) bool {
+	return m.Annotations[homecraftv1alpha1.RestoreAnnotation] != ""
 }
 
 // convertMemoryFormat converts Kubernetes memory format (e.g., "2Gi", "512Mi")
@@ -449,7 +900,20 @@ func (r *MinecraftServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&homecraftv1alpha1.MinecraftServer{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&corev1.Secret{}).
+		// Backups outlive their server, so they are matched to it by label instead of ownership
+		Watches(&homecraftv1alpha1.MinecraftBackup{}, handler.EnqueueRequestsFromMapFunc(backupToServerRequests)).
 		Complete(r)
 }
+
+// backupToServerRequests maps a MinecraftBackup to the MinecraftServer it belongs to
+func backupToServerRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[homecraftv1alpha1.ServerNameLabel]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
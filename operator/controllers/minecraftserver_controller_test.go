@@ -2,12 +2,16 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	"github.com/homecraft/backend/pkg/slp/slptest"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -145,6 +149,7 @@ func TestStatefulSetForMinecraftServer(t *testing.T) {
 		server         *homecraftv1alpha1.MinecraftServer
 		wantReplicas   int32
 		wantMemory     string
+		wantJavaMemory string
 		wantVersion    string
 		wantType       string
 		wantContainers int
@@ -169,6 +174,7 @@ func TestStatefulSetForMinecraftServer(t *testing.T) {
 			},
 			wantReplicas:   1,
 			wantMemory:     "2Gi",
+			wantJavaMemory: "2G",
 			wantVersion:    "LATEST",
 			wantType:       "VANILLA",
 			wantContainers: 2,
@@ -195,6 +201,7 @@ func TestStatefulSetForMinecraftServer(t *testing.T) {
 			},
 			wantReplicas:   1,
 			wantMemory:     "4Gi",
+			wantJavaMemory: "4G",
 			wantVersion:    "1.19.4",
 			wantType:       "PAPER",
 			wantContainers: 2,
@@ -202,6 +209,52 @@ func TestStatefulSetForMinecraftServer(t *testing.T) {
 			wantDifficulty: "hard",
 			wantGamemode:   "creative",
 		},
+		{
+			name: "paused server",
+			server: &homecraftv1alpha1.MinecraftServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "paused-server",
+					Namespace: "default",
+				},
+				Spec: homecraftv1alpha1.MinecraftServerSpec{
+					EULA:         true,
+					SFTPUsername: "paused-user",
+					SFTPPassword: "paused-pass",
+					Memory:       "2Gi",
+					StorageSize:  "5Gi",
+					Paused:       true,
+				},
+			},
+			wantReplicas:   0,
+			wantMemory:     "2Gi",
+			wantJavaMemory: "2G",
+			wantVersion:    "LATEST",
+			wantType:       "VANILLA",
+			wantContainers: 2,
+		},
+		{
+			name: "server being restored",
+			server: &homecraftv1alpha1.MinecraftServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "restored-server",
+					Namespace:   "default",
+					Annotations: map[string]string{homecraftv1alpha1.RestoreAnnotation: "restored-server-20261016-120000"},
+				},
+				Spec: homecraftv1alpha1.MinecraftServerSpec{
+					EULA:         true,
+					SFTPUsername: "restored-user",
+					SFTPPassword: "restored-pass",
+					Memory:       "2Gi",
+					StorageSize:  "5Gi",
+				},
+			},
+			wantReplicas:   0,
+			wantMemory:     "2Gi",
+			wantJavaMemory: "2G",
+			wantVersion:    "LATEST",
+			wantType:       "VANILLA",
+			wantContainers: 2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -242,8 +295,8 @@ func TestStatefulSetForMinecraftServer(t *testing.T) {
 			if envMap["TYPE"] != tt.wantType {
 				t.Errorf("Expected TYPE %s, got %s", tt.wantType, envMap["TYPE"])
 			}
-			if envMap["MEMORY"] != tt.wantMemory {
-				t.Errorf("Expected MEMORY %s, got %s", tt.wantMemory, envMap["MEMORY"])
+			if envMap["MEMORY"] != tt.wantJavaMemory {
+				t.Errorf("Expected MEMORY %s, got %s", tt.wantJavaMemory, envMap["MEMORY"])
 			}
 
 			// Check optional env vars if set
@@ -257,20 +310,109 @@ func TestStatefulSetForMinecraftServer(t *testing.T) {
 				t.Errorf("Expected MODE %s, got %s", tt.wantGamemode, envMap["MODE"])
 			}
 
+			// Check RCON is enabled with the password from the server Secret
+			if envMap["ENABLE_RCON"] != "true" {
+				t.Errorf("Expected ENABLE_RCON true, got %s", envMap["ENABLE_RCON"])
+			}
+			foundRCONPassword := false
+			for _, env := range minecraftContainer.Env {
+				if env.Name != "RCON_PASSWORD" {
+					continue
+				}
+				foundRCONPassword = true
+				if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+					t.Fatalf("Expected RCON_PASSWORD to come from a secret")
+				}
+				if env.ValueFrom.SecretKeyRef.Name != tt.server.Name+"-sftp" || env.ValueFrom.SecretKeyRef.Key != "rcon-password" {
+					t.Errorf("Unexpected RCON_PASSWORD secret reference %+v", env.ValueFrom.SecretKeyRef)
+				}
+			}
+			if !foundRCONPassword {
+				t.Error("Expected RCON_PASSWORD env var")
+			}
+
 			// Check SFTP container
 			sftpContainer := sts.Spec.Template.Spec.Containers[1]
 			if sftpContainer.Name != "sftp" {
 				t.Errorf("Expected container name 'sftp', got %s", sftpContainer.Name)
 			}
+			if len(sftpContainer.Args) != 0 {
+				t.Errorf("Expected no SFTP args carrying credentials, got %v", sftpContainer.Args)
+			}
+			sftpEnv := make(map[string]corev1.EnvVar)
+			for _, env := range sftpContainer.Env {
+				sftpEnv[env.Name] = env
+			}
+			for name, key := range map[string]string{"SFTP_USERNAME": "username", "SFTP_PASSWORD": "password"} {
+				ref := sftpEnv[name].ValueFrom
+				if ref == nil || ref.SecretKeyRef == nil || ref.SecretKeyRef.Name != tt.server.Name+"-sftp" || ref.SecretKeyRef.Key != key {
+					t.Errorf("Expected %s to come from key %s of the server Secret, got %+v", name, key, sftpEnv[name])
+				}
+			}
+			if sftpEnv["SFTP_USERS"].Value != "$(SFTP_USERNAME):$(SFTP_PASSWORD):1000:1000:/data" {
+				t.Errorf("Unexpected SFTP_USERS %s", sftpEnv["SFTP_USERS"].Value)
+			}
+
+			// The liveness probe compares the mounted password with the one the container started with
+			probe := sftpContainer.LivenessProbe
+			if probe == nil || probe.Exec == nil || !strings.Contains(strings.Join(probe.Exec.Command, " "), "/etc/homecraft/sftp/password") {
+				t.Errorf("Expected an SFTP liveness probe checking the mounted password, got %+v", probe)
+			}
 
 			// Check volumes
-			if len(sts.Spec.Template.Spec.Volumes) != 1 {
-				t.Errorf("Expected 1 volume, got %d", len(sts.Spec.Template.Spec.Volumes))
+			if len(sts.Spec.Template.Spec.Volumes) != 3 {
+				t.Errorf("Expected 3 volumes, got %d", len(sts.Spec.Template.Spec.Volumes))
+			}
+			credentials := sts.Spec.Template.Spec.Volumes[1].Secret
+			if credentials == nil || credentials.SecretName != tt.server.Name+"-sftp" {
+				t.Errorf("Expected the server Secret to be mounted, got %+v", sts.Spec.Template.Spec.Volumes[1])
 			}
 		})
 	}
 }
 
+func TestStatefulSetForMinecraftServer_AuthorizedKeys(t *testing.T) {
+	reconciler := &MinecraftServerReconciler{}
+	server := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "keys-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:                true,
+			SFTPUsername:        "mc-keys-server",
+			Memory:              "2Gi",
+			StorageSize:         "5Gi",
+			AuthorizedKeys:      []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGsnqMCTnj4LRyX6LoQ8yZn1v7Qs9zW5VnT+3ZS3bp0q deploy"},
+			DisableSFTPPassword: true,
+		},
+	}
+
+	secret := reconciler.secretForMinecraftServer(server)
+	if secret.StringData["authorized-keys"] != server.Spec.AuthorizedKeys[0] {
+		t.Errorf("Expected the authorized keys in the Secret, got %q", secret.StringData["authorized-keys"])
+	}
+
+	sts := reconciler.statefulSetForMinecraftServer(server)
+	sftpContainer := sts.Spec.Template.Spec.Containers[1]
+
+	for _, env := range sftpContainer.Env {
+		if env.Name == "SFTP_USERS" && env.Value != "$(SFTP_USERNAME)::1000:1000:/data" {
+			t.Errorf("Expected SFTP_USERS without a password, got %s", env.Value)
+		}
+	}
+
+	foundKeysMount := false
+	for _, mount := range sftpContainer.VolumeMounts {
+		if mount.MountPath == "/home/mc-keys-server/.ssh/keys" {
+			foundKeysMount = mount.Name == "sftp-authorized-keys" && mount.ReadOnly
+		}
+	}
+	if !foundKeysMount {
+		t.Errorf("Expected the authorized keys mounted read-only in the user's .ssh/keys, got %+v", sftpContainer.VolumeMounts)
+	}
+}
+
 func TestServiceForMinecraft(t *testing.T) {
 	s := runtime.NewScheme()
 	_ = scheme.AddToScheme(s)
@@ -298,8 +440,8 @@ func TestServiceForMinecraft(t *testing.T) {
 	}
 
 	// Verify service type
-	if svc.Spec.Type != corev1.ServiceTypeNodePort {
-		t.Errorf("Expected service type NodePort, got %s", svc.Spec.Type)
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("Expected service type LoadBalancer, got %s", svc.Spec.Type)
 	}
 
 	// Verify ports
@@ -338,8 +480,8 @@ func TestServiceForSFTP(t *testing.T) {
 	}
 
 	// Verify service type
-	if svc.Spec.Type != corev1.ServiceTypeNodePort {
-		t.Errorf("Expected service type NodePort, got %s", svc.Spec.Type)
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("Expected service type LoadBalancer, got %s", svc.Spec.Type)
 	}
 
 	// Verify ports
@@ -440,6 +582,453 @@ func TestReconcile_CreateResources(t *testing.T) {
 	}
 }
 
+func TestReconcile_GeneratesStablePasswords(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "test-user",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+	secretKey := types.NamespacedName{Name: "test-server-sftp", Namespace: "default"}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, secretKey, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	password := string(secret.Data["rcon-password"])
+	if password == "" {
+		t.Fatal("Expected an RCON password to be generated")
+	}
+	sftpPassword := string(secret.Data["password"])
+	if sftpPassword == "" {
+		t.Fatal("Expected an SFTP password to be generated")
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Second reconcile failed: %v", err)
+	}
+
+	if err := fakeClient.Get(ctx, secretKey, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	if string(secret.Data["rcon-password"]) != password {
+		t.Error("Expected the RCON password to stay the same across reconciles")
+	}
+	if string(secret.Data["password"]) != sftpPassword {
+		t.Error("Expected the SFTP password to stay the same across reconciles")
+	}
+}
+
+func TestReconcile_MovesSFTPPasswordToSecret(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	// A server created by an older API, with the password in its spec and status
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "legacy-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "mc-legacy-server",
+			SFTPPassword: "legacy-pass",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+		},
+		Status: homecraftv1alpha1.MinecraftServerStatus{
+			SFTPUsername: "mc-legacy-server",
+			SFTPPassword: "legacy-pass",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy-server", Namespace: "default"}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile %d failed: %v", i+1, err)
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "legacy-server-sftp", Namespace: "default"}, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	if string(secret.Data["username"]) != "mc-legacy-server" || string(secret.Data["password"]) != "legacy-pass" {
+		t.Errorf("Expected the existing SFTP credentials in the Secret, got %s/%s", secret.Data["username"], secret.Data["password"])
+	}
+
+	updated := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Spec.SFTPPassword != "" {
+		t.Errorf("Expected the SFTP password to be removed from the spec, got %s", updated.Spec.SFTPPassword)
+	}
+	if updated.Status.SFTPPassword != "" {
+		t.Errorf("Expected the SFTP password to be removed from the status, got %s", updated.Status.SFTPPassword)
+	}
+	if updated.Status.SFTPUsername != "mc-legacy-server" {
+		t.Errorf("Expected status SFTP username mc-legacy-server, got %s", updated.Status.SFTPUsername)
+	}
+}
+
+func TestReconcile_KeepsRotatedSFTPPassword(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "test-user",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+	secretKey := types.NamespacedName{Name: "test-server-sftp", Namespace: "default"}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	// Rotate the password the way the API does
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, secretKey, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	secret.Data["password"] = []byte("rotated-pass")
+	secret.Annotations = map[string]string{homecraftv1alpha1.SFTPPasswordRotatedAnnotation: "2026-10-16T12:00:00Z"}
+	if err := fakeClient.Update(ctx, secret); err != nil {
+		t.Fatalf("Failed to update Secret: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile after rotation failed: %v", err)
+	}
+
+	if err := fakeClient.Get(ctx, secretKey, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	if string(secret.Data["password"]) != "rotated-pass" {
+		t.Errorf("Expected the rotated SFTP password to be kept, got %s", string(secret.Data["password"]))
+	}
+
+	updated := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Status.SFTPPasswordRotatedAt == nil {
+		t.Error("Expected the rotation time in the status")
+	}
+}
+
+func TestReconcile_SpecChangeRollsOut(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "test-user",
+			SFTPPassword: "test-pass",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+			Version:      "1.20.1",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Initial reconcile failed: %v", err)
+	}
+
+	// Change the spec the way the API does
+	updated := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	updated.Spec.Memory = "4Gi"
+	updated.Spec.Version = "1.21.1"
+	updated.Spec.MaxPlayers = 50
+	updated.Spec.StorageSize = "10Gi"
+	updated.Spec.SFTPPassword = "new-pass"
+	if err := fakeClient.Update(ctx, updated); err != nil {
+		t.Fatalf("Failed to update MinecraftServer: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile after spec change failed: %v", err)
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server", Namespace: "default"}, sts); err != nil {
+		t.Fatalf("Failed to get StatefulSet: %v", err)
+	}
+
+	minecraftContainer := sts.Spec.Template.Spec.Containers[0]
+	memoryLimit := minecraftContainer.Resources.Limits[corev1.ResourceMemory]
+	if memoryLimit.String() != "4Gi" {
+		t.Errorf("Expected memory limit 4Gi, got %s", memoryLimit.String())
+	}
+
+	envMap := make(map[string]string)
+	for _, env := range minecraftContainer.Env {
+		envMap[env.Name] = env.Value
+	}
+	if envMap["VERSION"] != "1.21.1" {
+		t.Errorf("Expected VERSION 1.21.1, got %s", envMap["VERSION"])
+	}
+	if envMap["MEMORY"] != "4G" {
+		t.Errorf("Expected MEMORY 4G, got %s", envMap["MEMORY"])
+	}
+	if envMap["MAX_PLAYERS"] != "50" {
+		t.Errorf("Expected MAX_PLAYERS 50, got %s", envMap["MAX_PLAYERS"])
+	}
+
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-sftp", Namespace: "default"}, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	if string(secret.Data["password"]) != "new-pass" {
+		t.Errorf("Expected secret password 'new-pass', got %s", string(secret.Data["password"]))
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Spec.SFTPPassword != "" {
+		t.Errorf("Expected the SFTP password to be moved out of the spec, got %s", updated.Spec.SFTPPassword)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-data", Namespace: "default"}, pvc); err != nil {
+		t.Fatalf("Failed to get PVC: %v", err)
+	}
+	storageRequest := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if storageRequest.String() != "10Gi" {
+		t.Errorf("Expected PVC to be expanded to 10Gi, got %s", storageRequest.String())
+	}
+}
+
+func TestReconcile_RestoresHandEditedResources(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "test-user",
+			SFTPPassword: "test-pass",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Initial reconcile failed: %v", err)
+	}
+
+	// Hand-edit the StatefulSet
+	sts := &appsv1.StatefulSet{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server", Namespace: "default"}, sts); err != nil {
+		t.Fatalf("Failed to get StatefulSet: %v", err)
+	}
+	replicas := int32(3)
+	sts.Spec.Replicas = &replicas
+	sts.Spec.Template.Spec.Containers[0].Image = "example.com/other:latest"
+	sts.Spec.Template.Spec.Containers[0].Env = nil
+	sts.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
+	sts.Spec.Template.Spec.Containers = sts.Spec.Template.Spec.Containers[:1]
+	privileged := true
+	runAsUser := int64(0)
+	sts.Spec.Template.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+	sts.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsUser: &runAsUser}
+	sts.Spec.Template.Spec.ServiceAccountName = "cluster-admin"
+	sts.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName = "other-server-data"
+	if err := fakeClient.Update(ctx, sts); err != nil {
+		t.Fatalf("Failed to edit StatefulSet: %v", err)
+	}
+
+	// Hand-edit the game Service
+	svc := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-minecraft", Namespace: "default"}, svc); err != nil {
+		t.Fatalf("Failed to get Service: %v", err)
+	}
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.Ports[0].Port = 1234
+	if err := fakeClient.Update(ctx, svc); err != nil {
+		t.Fatalf("Failed to edit Service: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile after manual edit failed: %v", err)
+	}
+
+	restored := &appsv1.StatefulSet{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server", Namespace: "default"}, restored); err != nil {
+		t.Fatalf("Failed to get StatefulSet: %v", err)
+	}
+	if *restored.Spec.Replicas != 1 {
+		t.Errorf("Expected replicas to be restored to 1, got %d", *restored.Spec.Replicas)
+	}
+	if len(restored.Spec.Template.Spec.Containers) != 2 {
+		t.Fatalf("Expected 2 containers after restore, got %d", len(restored.Spec.Template.Spec.Containers))
+	}
+	minecraftContainer := restored.Spec.Template.Spec.Containers[0]
+	if minecraftContainer.Image != "itzg/minecraft-server:latest" {
+		t.Errorf("Expected image to be restored, got %s", minecraftContainer.Image)
+	}
+	if len(minecraftContainer.Env) == 0 {
+		t.Error("Expected env vars to be restored")
+	}
+	// Fields the operator does not own are left alone
+	if minecraftContainer.ImagePullPolicy != corev1.PullIfNotPresent {
+		t.Errorf("Expected unowned ImagePullPolicy to be kept, got %s", minecraftContainer.ImagePullPolicy)
+	}
+	if restored.Spec.Template.Spec.Containers[1].Name != "sftp" {
+		t.Errorf("Expected sftp container to be restored, got %s", restored.Spec.Template.Spec.Containers[1].Name)
+	}
+	if minecraftContainer.SecurityContext != nil {
+		t.Errorf("Expected the container security context to be removed, got %+v", minecraftContainer.SecurityContext)
+	}
+	if podSecurity := restored.Spec.Template.Spec.SecurityContext; podSecurity != nil && podSecurity.RunAsUser != nil {
+		t.Errorf("Expected the pod security context to be removed, got %+v", podSecurity)
+	}
+	if restored.Spec.Template.Spec.ServiceAccountName != "" {
+		t.Errorf("Expected the service account to be removed, got %s", restored.Spec.Template.Spec.ServiceAccountName)
+	}
+	if claim := restored.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName; claim != "test-server-data" {
+		t.Errorf("Expected the data volume to be restored to test-server-data, got %s", claim)
+	}
+
+	restoredSvc := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-minecraft", Namespace: "default"}, restoredSvc); err != nil {
+		t.Fatalf("Failed to get Service: %v", err)
+	}
+	if restoredSvc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("Expected service type to be restored to LoadBalancer, got %s", restoredSvc.Spec.Type)
+	}
+	if restoredSvc.Spec.Ports[0].Port != 25565 {
+		t.Errorf("Expected port to be restored to 25565, got %d", restoredSvc.Spec.Ports[0].Port)
+	}
+}
+
+func TestMergeServicePorts_KeepsAllocatedNodePort(t *testing.T) {
+	existing := []corev1.ServicePort{{Name: "minecraft", Port: 25565, NodePort: 31234}}
+	desired := []corev1.ServicePort{{Name: "minecraft", Port: 25565}}
+
+	merged := mergeServicePorts(existing, desired, corev1.ServiceTypeLoadBalancer)
+	if merged[0].NodePort != 31234 {
+		t.Errorf("Expected allocated NodePort 31234 to be kept, got %d", merged[0].NodePort)
+	}
+
+	merged = mergeServicePorts(existing, desired, corev1.ServiceTypeClusterIP)
+	if merged[0].NodePort != 0 {
+		t.Errorf("Expected NodePort to be dropped for ClusterIP, got %d", merged[0].NodePort)
+	}
+}
+
 func TestReconcile_NotFound(t *testing.T) {
 	s := runtime.NewScheme()
 	_ = scheme.AddToScheme(s)
@@ -502,13 +1091,14 @@ func TestUpdateStatus(t *testing.T) {
 		},
 	}
 
-	// Create services with NodePorts
+	// Create services with LoadBalancer IPs
 	minecraftSvc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test-server-minecraft",
 			Namespace: "default",
 		},
 		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
 			Ports: []corev1.ServicePort{
 				{
 					Port:     25565,
@@ -516,6 +1106,11 @@ func TestUpdateStatus(t *testing.T) {
 				},
 			},
 		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "192.168.1.240"}},
+			},
+		},
 	}
 
 	sftpSvc := &corev1.Service{
@@ -524,6 +1119,7 @@ func TestUpdateStatus(t *testing.T) {
 			Namespace: "default",
 		},
 		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
 			Ports: []corev1.ServicePort{
 				{
 					Port:     22,
@@ -531,21 +1127,59 @@ func TestUpdateStatus(t *testing.T) {
 				},
 			},
 		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "192.168.1.241"}},
+			},
+		},
+	}
+
+	// The pod runs and its world is loaded
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server-0",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			PodIP:             "10.42.0.15",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "minecraft", Ready: true}},
+		},
 	}
+	game := slptest.NewServer(slp.Status{
+		Version:     slp.Version{Name: "Paper 1.21.1", Protocol: 767},
+		Players:     slp.Players{Max: 20, Online: 3},
+		Description: slp.Description{Text: "A HomeCraft Server"},
+	})
+	defer game.Close()
 
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(s).
-		WithObjects(minecraftServer, sts, minecraftSvc, sftpSvc).
+		WithObjects(minecraftServer, sts, minecraftSvc, sftpSvc, pod).
 		WithStatusSubresource(minecraftServer).
 		Build()
 
+	var pinged []string
 	reconciler := &MinecraftServerReconciler{
 		Client: fakeClient,
 		Log:    zap.New(zap.UseDevMode(true)),
 		Scheme: s,
+		Ping: func(ctx context.Context, address string) (*slp.Status, error) {
+			pinged = append(pinged, address)
+			return slp.Ping(ctx, game.Addr)
+		},
 	}
 
-	err := reconciler.updateStatus(context.Background(), minecraftServer, sts, minecraftSvc, sftpSvc)
+	// The API rotated the SFTP password
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-server-sftp",
+			Namespace:   "default",
+			Annotations: map[string]string{homecraftv1alpha1.SFTPPasswordRotatedAnnotation: "2026-10-16T12:00:00Z"},
+		},
+	}
+
+	err := reconciler.updateStatus(context.Background(), minecraftServer, secret, sts, minecraftSvc, sftpSvc)
 	if err != nil {
 		t.Fatalf("updateStatus failed: %v", err)
 	}
@@ -554,15 +1188,109 @@ func TestUpdateStatus(t *testing.T) {
 	if minecraftServer.Status.Phase != "Running" {
 		t.Errorf("Expected phase 'Running', got %s", minecraftServer.Status.Phase)
 	}
-	if minecraftServer.Status.Endpoint != "<node-ip>:30000" {
-		t.Errorf("Expected endpoint '<node-ip>:30000', got %s", minecraftServer.Status.Endpoint)
+	if minecraftServer.Status.Endpoint != "192.168.1.240:25565" {
+		t.Errorf("Expected endpoint '192.168.1.240:25565', got %s", minecraftServer.Status.Endpoint)
 	}
-	if minecraftServer.Status.SFTPEndpoint != "<node-ip>:30001" {
-		t.Errorf("Expected SFTP endpoint '<node-ip>:30001', got %s", minecraftServer.Status.SFTPEndpoint)
+	if minecraftServer.Status.SFTPEndpoint != "192.168.1.241:22" {
+		t.Errorf("Expected SFTP endpoint '192.168.1.241:22', got %s", minecraftServer.Status.SFTPEndpoint)
 	}
 	if minecraftServer.Status.AllocatedMemory != "2Gi" {
 		t.Errorf("Expected allocated memory '2Gi', got %s", minecraftServer.Status.AllocatedMemory)
 	}
+	rotatedAt := minecraftServer.Status.SFTPPasswordRotatedAt
+	if rotatedAt == nil || !rotatedAt.Equal(&metav1.Time{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}) {
+		t.Errorf("Expected SFTP password rotation time 2026-10-16T12:00:00Z, got %v", rotatedAt)
+	}
+	if len(pinged) != 1 || pinged[0] != "10.42.0.15:25565" {
+		t.Errorf("Expected the game port of the pod to be pinged, pinged %v", pinged)
+	}
+	gameStatus := minecraftServer.Status.Game
+	if gameStatus == nil || gameStatus.Version != "Paper 1.21.1" || gameStatus.PlayersOnline != 3 || gameStatus.PlayersMax != 20 || gameStatus.MOTD != "A HomeCraft Server" {
+		t.Errorf("Expected the game status reported by the server, got %+v", gameStatus)
+	}
+
+	// A server that stops answering while its world reloads is starting again
+	game.Close()
+	if err := reconciler.updateStatus(context.Background(), minecraftServer, secret, sts, minecraftSvc, sftpSvc); err != nil {
+		t.Fatalf("updateStatus failed: %v", err)
+	}
+	if minecraftServer.Status.Phase != "Starting" || minecraftServer.Status.Game != nil {
+		t.Errorf("Expected phase 'Starting' without game status, got %s with %+v", minecraftServer.Status.Phase, minecraftServer.Status.Game)
+	}
+	if condition := meta.FindStatusCondition(minecraftServer.Status.Conditions, homecraftv1alpha1.ServerConditionGameReady); condition == nil || condition.Reason != "Loading" {
+		t.Errorf("Expected GameReady reason Loading, got %+v", condition)
+	}
+}
+
+func TestUpdateStatus_Paused(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	tests := []struct {
+		name          string
+		replicas      int32
+		wantPhase     string
+		wantAllocated string
+	}{
+		{
+			name:          "pod still terminating",
+			replicas:      1,
+			wantPhase:     "Stopping",
+			wantAllocated: "2Gi",
+		},
+		{
+			name:          "scaled down",
+			replicas:      0,
+			wantPhase:     "Stopped",
+			wantAllocated: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minecraftServer := &homecraftv1alpha1.MinecraftServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-server",
+					Namespace: "default",
+				},
+				Spec: homecraftv1alpha1.MinecraftServerSpec{
+					EULA:   true,
+					Memory: "2Gi",
+					Paused: true,
+				},
+			}
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"},
+				Status:     appsv1.StatefulSetStatus{Replicas: tt.replicas},
+			}
+			minecraftSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test-server-minecraft", Namespace: "default"}}
+			sftpSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test-server-sftp", Namespace: "default"}}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(s).
+				WithObjects(minecraftServer, sts, minecraftSvc, sftpSvc).
+				WithStatusSubresource(minecraftServer).
+				Build()
+
+			reconciler := &MinecraftServerReconciler{
+				Client: fakeClient,
+				Log:    zap.New(zap.UseDevMode(true)),
+				Scheme: s,
+			}
+
+			if err := reconciler.updateStatus(context.Background(), minecraftServer, &corev1.Secret{}, sts, minecraftSvc, sftpSvc); err != nil {
+				t.Fatalf("updateStatus failed: %v", err)
+			}
+
+			if minecraftServer.Status.Phase != tt.wantPhase {
+				t.Errorf("Expected phase %q, got %q", tt.wantPhase, minecraftServer.Status.Phase)
+			}
+			if minecraftServer.Status.AllocatedMemory != tt.wantAllocated {
+				t.Errorf("Expected allocated memory %q, got %q", tt.wantAllocated, minecraftServer.Status.AllocatedMemory)
+			}
+		})
+	}
 }
 
 func BenchmarkStatefulSetForMinecraftServer(b *testing.B) {
@@ -595,3 +1323,79 @@ func BenchmarkStatefulSetForMinecraftServer(b *testing.B) {
 		_ = reconciler.statefulSetForMinecraftServer(minecraftServer)
 	}
 }
+
+func TestReconcile_BehindGateway(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:         true,
+			SFTPUsername: "test-user",
+			SFTPPassword: "test-pass",
+			Memory:       "2Gi",
+			StorageSize:  "5Gi",
+		},
+	}
+	// The game service as it was exposed before the gateway was enabled
+	loadBalancerService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server-minecraft",
+			Namespace: "default",
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                  corev1.ServiceTypeLoadBalancer,
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+			HealthCheckNodePort:   31000,
+			Ports: []corev1.ServicePort{
+				{Name: "minecraft", Port: 25565, NodePort: 30565, Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer, loadBalancerService).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client:        fakeClient,
+		Log:           zap.New(zap.UseDevMode(true)),
+		Scheme:        s,
+		GatewayDomain: "mc.home.lan",
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	svc := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-server-minecraft", Namespace: "default"}, svc); err != nil {
+		t.Fatalf("Failed to get game Service: %v", err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("Expected game Service type ClusterIP, got %s", svc.Spec.Type)
+	}
+	if svc.Spec.ExternalTrafficPolicy != "" || svc.Spec.HealthCheckNodePort != 0 {
+		t.Errorf("Expected load balancer settings to be cleared, got %+v", svc.Spec)
+	}
+	if svc.Spec.Ports[0].NodePort != 0 {
+		t.Errorf("Expected the node port to be released, got %d", svc.Spec.Ports[0].NodePort)
+	}
+
+	updated := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Status.Endpoint != "test-server.mc.home.lan" {
+		t.Errorf("Expected endpoint test-server.mc.home.lan, got %s", updated.Status.Endpoint)
+	}
+}
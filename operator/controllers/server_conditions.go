@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// failingContainerReasons are the waiting reasons of a container that won't start without intervention
+var failingContainerReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// serverConditions derives the conditions of a server from the objects running it.
+// pvc and pod are nil when they don't exist, waitForConsumer tells whether the storage class of
+// the claim only provisions a volume once the pod is scheduled, ping is nil when the server was
+// not pinged, endpoint is the address of the game service.
+func serverConditions(m *homecraftv1alpha1.MinecraftServer, pvc *corev1.PersistentVolumeClaim, waitForConsumer bool, pod *corev1.Pod, ping *pingResult, endpoint string) []metav1.Condition {
+	return []metav1.Condition{
+		storageBoundCondition(pvc, waitForConsumer),
+		scheduledCondition(m, pod),
+		gameReadyCondition(pod, ping),
+		containerReadyCondition(homecraftv1alpha1.ServerConditionSFTPReady, pod, "sftp"),
+		endpointAssignedCondition(endpoint),
+	}
+}
+
+// waitsForFirstConsumer tells whether a pending claim gets its volume only once its pod is scheduled
+func (r *MinecraftServerReconciler) waitsForFirstConsumer(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc == nil || pvc.Status.Phase != corev1.ClaimPending || pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, storageClass); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return storageClass.VolumeBindingMode != nil && *storageClass.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer, nil
+}
+
+func storageBoundCondition(pvc *corev1.PersistentVolumeClaim, waitForConsumer bool) metav1.Condition {
+	switch {
+	case pvc == nil:
+		return newCondition(homecraftv1alpha1.ServerConditionStorageBound, false, "NotFound", "The world volume claim does not exist yet")
+	case pvc.Status.Phase == corev1.ClaimBound:
+		return newCondition(homecraftv1alpha1.ServerConditionStorageBound, true, "Bound", fmt.Sprintf("The world is stored on volume %s", pvc.Spec.VolumeName))
+	case pvc.Status.Phase == corev1.ClaimLost:
+		return newCondition(homecraftv1alpha1.ServerConditionStorageBound, false, "Lost", fmt.Sprintf("Volume %s of the world no longer exists", pvc.Spec.VolumeName))
+	case waitForConsumer:
+		return newCondition(homecraftv1alpha1.ServerConditionStorageBound, false, "WaitForFirstConsumer", "The world volume is provisioned once the server pod is scheduled")
+	default:
+		return newCondition(homecraftv1alpha1.ServerConditionStorageBound, false, "Pending", "Waiting for a volume to store the world on")
+	}
+}
+
+func scheduledCondition(m *homecraftv1alpha1.MinecraftServer, pod *corev1.Pod) metav1.Condition {
+	if pod == nil {
+		if m.Spec.Paused || isRestoring(m) {
+			return newCondition(homecraftv1alpha1.ServerConditionScheduled, false, "Stopped", "The server is stopped")
+		}
+		return newCondition(homecraftv1alpha1.ServerConditionScheduled, false, "NoPod", "The server pod has not been created yet")
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type != corev1.PodScheduled {
+			continue
+		}
+		if c.Status == corev1.ConditionTrue {
+			return newCondition(homecraftv1alpha1.ServerConditionScheduled, true, "Scheduled", fmt.Sprintf("The server runs on node %s", pod.Spec.NodeName))
+		}
+		reason := c.Reason
+		if reason == "" {
+			reason = "Pending"
+		}
+		return newCondition(homecraftv1alpha1.ServerConditionScheduled, false, reason, c.Message)
+	}
+	return newCondition(homecraftv1alpha1.ServerConditionScheduled, false, "Pending", "Waiting for the server pod to be scheduled")
+}
+
+// gameReadyCondition reports whether Minecraft accepts players. A running container is not enough,
+// the world has to be loaded, which the server shows by answering status pings.
+func gameReadyCondition(pod *corev1.Pod, ping *pingResult) metav1.Condition {
+	condition := containerReadyCondition(homecraftv1alpha1.ServerConditionGameReady, pod, "minecraft")
+	if condition.Status != metav1.ConditionTrue || ping == nil {
+		return condition
+	}
+	if ping.err != nil {
+		return newCondition(homecraftv1alpha1.ServerConditionGameReady, false, "Loading", fmt.Sprintf("The server does not answer status pings yet: %v", ping.err))
+	}
+	return newCondition(homecraftv1alpha1.ServerConditionGameReady, true, "Ready", "The server answers status pings")
+}
+
+// containerReadyCondition reports whether the container named container of pod is ready
+func containerReadyCondition(conditionType string, pod *corev1.Pod, container string) metav1.Condition {
+	if pod == nil {
+		return newCondition(conditionType, false, "NoPod", "The server pod is not running")
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != container {
+			continue
+		}
+		if status.Ready {
+			return newCondition(conditionType, true, "Ready", fmt.Sprintf("The %s container is ready", container))
+		}
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" {
+			return newCondition(conditionType, false, waiting.Reason, containerWaitingMessage(container, status))
+		}
+		if status.State.Terminated != nil {
+			return newCondition(conditionType, false, "Terminated", fmt.Sprintf("The %s container exited with code %d", container, status.State.Terminated.ExitCode))
+		}
+		return newCondition(conditionType, false, "Starting", fmt.Sprintf("The %s container is starting", container))
+	}
+	return newCondition(conditionType, false, "Starting", fmt.Sprintf("The %s container has not started yet", container))
+}
+
+// containerWaitingMessage explains why a container is waiting to run
+func containerWaitingMessage(container string, status corev1.ContainerStatus) string {
+	waiting := status.State.Waiting
+	switch waiting.Reason {
+	case "CrashLoopBackOff":
+		message := fmt.Sprintf("The %s container keeps crashing, it restarted %d times", container, status.RestartCount)
+		if last := status.LastTerminationState.Terminated; last != nil {
+			message += fmt.Sprintf(", last exit code %d", last.ExitCode)
+			if last.Reason != "" {
+				message += fmt.Sprintf(" (%s)", last.Reason)
+			}
+		}
+		return message
+	case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+		return fmt.Sprintf("The %s container image cannot be pulled: %s", container, waiting.Message)
+	case "CreateContainerConfigError", "CreateContainerError":
+		return fmt.Sprintf("The %s container cannot be created: %s", container, waiting.Message)
+	}
+	if waiting.Message != "" {
+		return fmt.Sprintf("The %s container is waiting: %s", container, waiting.Message)
+	}
+	return fmt.Sprintf("The %s container is waiting: %s", container, waiting.Reason)
+}
+
+func endpointAssignedCondition(endpoint string) metav1.Condition {
+	if endpoint == "" {
+		return newCondition(homecraftv1alpha1.ServerConditionEndpointAssigned, false, "Pending", "Waiting for the load balancer to assign an address")
+	}
+	return newCondition(homecraftv1alpha1.ServerConditionEndpointAssigned, true, "Assigned", fmt.Sprintf("Players connect to %s", endpoint))
+}
+
+// failureMessage returns why a server that should be running can't start without intervention,
+// or "" when it may still come up on its own
+func failureMessage(conditions []metav1.Condition) string {
+	byType := make(map[string]metav1.Condition, len(conditions))
+	for _, c := range conditions {
+		byType[c.Type] = c
+	}
+
+	storage := byType[homecraftv1alpha1.ServerConditionStorageBound]
+	if storage.Reason == "Lost" {
+		return storage.Message
+	}
+	// A pod waiting for its volume is unschedulable until the volume is provisioned, unless the
+	// volume itself waits for the pod or no node has the resources the pod asks for
+	scheduled := byType[homecraftv1alpha1.ServerConditionScheduled]
+	if scheduled.Reason == corev1.PodReasonUnschedulable &&
+		(storage.Status == metav1.ConditionTrue || storage.Reason == "WaitForFirstConsumer" || strings.Contains(scheduled.Message, "Insufficient ")) {
+		return fmt.Sprintf("The server cannot be scheduled: %s", scheduled.Message)
+	}
+	if game := byType[homecraftv1alpha1.ServerConditionGameReady]; failingContainerReasons[game.Reason] {
+		return game.Message
+	}
+	return ""
+}
+
+func newCondition(conditionType string, ok bool, reason, message string) metav1.Condition {
+	status := metav1.ConditionFalse
+	if ok {
+		status = metav1.ConditionTrue
+	}
+	return metav1.Condition{Type: conditionType, Status: status, Reason: reason, Message: message}
+}
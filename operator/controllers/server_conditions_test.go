@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func boundClaim() *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		Spec:   corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	}
+}
+
+func podWithContainers(statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}},
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func TestServerConditions(t *testing.T) {
+	server := &homecraftv1alpha1.MinecraftServer{ObjectMeta: metav1.ObjectMeta{Name: "test-server"}}
+
+	tests := []struct {
+		name            string
+		server          *homecraftv1alpha1.MinecraftServer
+		pvc             *corev1.PersistentVolumeClaim
+		waitForConsumer bool
+		pod             *corev1.Pod
+		ping            *pingResult
+		endpoint        string
+		wantReasons     map[string]string
+		wantFailure     string
+	}{
+		{
+			name:     "running",
+			server:   server,
+			pvc:      boundClaim(),
+			pod:      podWithContainers(corev1.ContainerStatus{Name: "minecraft", Ready: true}, corev1.ContainerStatus{Name: "sftp", Ready: true}),
+			ping:     &pingResult{status: &slp.Status{}},
+			endpoint: "192.168.1.240:25565",
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionStorageBound:     "Bound",
+				homecraftv1alpha1.ServerConditionScheduled:        "Scheduled",
+				homecraftv1alpha1.ServerConditionGameReady:        "Ready",
+				homecraftv1alpha1.ServerConditionSFTPReady:        "Ready",
+				homecraftv1alpha1.ServerConditionEndpointAssigned: "Assigned",
+			},
+		},
+		{
+			name:   "world loading",
+			server: server,
+			pvc:    boundClaim(),
+			pod:    podWithContainers(corev1.ContainerStatus{Name: "minecraft", Ready: true}),
+			ping:   &pingResult{err: errors.New("connection refused")},
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionScheduled: "Scheduled",
+				homecraftv1alpha1.ServerConditionGameReady: "Loading",
+			},
+		},
+		{
+			name:   "crash loop",
+			server: server,
+			pvc:    boundClaim(),
+			pod: podWithContainers(corev1.ContainerStatus{
+				Name:                 "minecraft",
+				RestartCount:         4,
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+			}),
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionGameReady:        "CrashLoopBackOff",
+				homecraftv1alpha1.ServerConditionSFTPReady:        "Starting",
+				homecraftv1alpha1.ServerConditionEndpointAssigned: "Pending",
+			},
+			wantFailure: "The minecraft container keeps crashing, it restarted 4 times, last exit code 137 (OOMKilled)",
+		},
+		{
+			name:   "not enough memory",
+			server: server,
+			pvc:    boundClaim(),
+			pod: &corev1.Pod{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient memory.",
+			}}}},
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionScheduled: corev1.PodReasonUnschedulable,
+				homecraftv1alpha1.ServerConditionGameReady: "Starting",
+			},
+			wantFailure: "The server cannot be scheduled: 0/3 nodes are available: 3 Insufficient memory.",
+		},
+		{
+			name:   "waiting for storage",
+			server: server,
+			pvc:    &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+			pod: &corev1.Pod{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "pod has unbound immediate PersistentVolumeClaims",
+			}}}},
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionStorageBound: "Pending",
+				homecraftv1alpha1.ServerConditionScheduled:    corev1.PodReasonUnschedulable,
+			},
+		},
+		{
+			name:            "not enough memory for a volume waiting for the pod",
+			server:          server,
+			pvc:             &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+			waitForConsumer: true,
+			pod: &corev1.Pod{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 1 node(s) didn't match Pod's node affinity/selector, 2 node(s) had untolerated taint.",
+			}}}},
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionStorageBound: "WaitForFirstConsumer",
+				homecraftv1alpha1.ServerConditionScheduled:    corev1.PodReasonUnschedulable,
+			},
+			wantFailure: "The server cannot be scheduled: 0/3 nodes are available: 1 node(s) didn't match Pod's node affinity/selector, 2 node(s) had untolerated taint.",
+		},
+		{
+			name:   "not enough memory while the volume is pending",
+			server: server,
+			pvc:    &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+			pod: &corev1.Pod{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient memory.",
+			}}}},
+			wantFailure: "The server cannot be scheduled: 0/3 nodes are available: 3 Insufficient memory.",
+		},
+		{
+			name:   "image cannot be pulled",
+			server: server,
+			pvc:    boundClaim(),
+			pod: podWithContainers(corev1.ContainerStatus{
+				Name:  "minecraft",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+			}),
+			wantFailure: "The minecraft container image cannot be pulled: Back-off pulling image",
+		},
+		{
+			name:   "stopped",
+			server: &homecraftv1alpha1.MinecraftServer{Spec: homecraftv1alpha1.MinecraftServerSpec{Paused: true}},
+			pvc:    boundClaim(),
+			wantReasons: map[string]string{
+				homecraftv1alpha1.ServerConditionScheduled: "Stopped",
+				homecraftv1alpha1.ServerConditionGameReady: "NoPod",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := serverConditions(tt.server, tt.pvc, tt.waitForConsumer, tt.pod, tt.ping, tt.endpoint)
+
+			for conditionType, wantReason := range tt.wantReasons {
+				condition := meta.FindStatusCondition(conditions, conditionType)
+				if condition == nil {
+					t.Fatalf("Expected condition %s", conditionType)
+				}
+				if condition.Reason != wantReason {
+					t.Errorf("Condition %s reason = %s, want %s (%s)", conditionType, condition.Reason, wantReason, condition.Message)
+				}
+				if (condition.Status == metav1.ConditionTrue) != (wantReason == "Bound" || wantReason == "Scheduled" || wantReason == "Ready" || wantReason == "Assigned") {
+					t.Errorf("Condition %s status = %s with reason %s", conditionType, condition.Status, condition.Reason)
+				}
+			}
+
+			if got := failureMessage(conditions); got != tt.wantFailure {
+				t.Errorf("failureMessage() = %q, want %q", got, tt.wantFailure)
+			}
+		})
+	}
+}
+
+func TestServerConditions_ReasonsAreValid(t *testing.T) {
+	// The CRD only accepts CamelCase reasons
+	pod := podWithContainers(corev1.ContainerStatus{
+		Name:  "minecraft",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	})
+	for _, condition := range serverConditions(&homecraftv1alpha1.MinecraftServer{}, nil, false, pod, nil, "") {
+		if condition.Reason == "" || strings.ContainsAny(condition.Reason, " -.") {
+			t.Errorf("Condition %s has invalid reason %q", condition.Type, condition.Reason)
+		}
+	}
+}
+
+func TestWaitsForFirstConsumer(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	waitForConsumer, immediate := storagev1.VolumeBindingWaitForFirstConsumer, storagev1.VolumeBindingImmediate
+	reconciler := &MinecraftServerReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+			&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "local-path"}, VolumeBindingMode: &waitForConsumer},
+			&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "nfs"}, VolumeBindingMode: &immediate},
+		).Build(),
+	}
+	pendingClaim := func(storageClass string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			Spec:   corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		}
+	}
+
+	tests := []struct {
+		name string
+		pvc  *corev1.PersistentVolumeClaim
+		want bool
+	}{
+		{name: "no claim"},
+		{name: "bound", pvc: boundClaim()},
+		{name: "wait for first consumer", pvc: pendingClaim("local-path"), want: true},
+		{name: "immediate", pvc: pendingClaim("nfs")},
+		{name: "unknown storage class", pvc: pendingClaim("missing")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reconciler.waitsForFirstConsumer(context.Background(), tt.pvc)
+			if err != nil {
+				t.Fatalf("waitsForFirstConsumer() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("waitsForFirstConsumer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/playit"
+	"github.com/homecraft/backend/pkg/slp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Tunnel is a public address a tunnel provider forwards to a server
+type Tunnel struct {
+	ID string
+	// Address is where players join the server, "" until the provider has assigned one
+	Address string
+}
+
+// TunnelProvider opens tunnels from the internet to the game port of servers
+type TunnelProvider interface {
+	// EnsureTunnel returns the tunnel called name forwarding to ip:port, creating it or moving it
+	// there when needed
+	EnsureTunnel(ctx context.Context, name, ip string, port int) (Tunnel, error)
+	// FindTunnel returns the tunnel called name, or nil when there is none
+	FindTunnel(ctx context.Context, name string) (*Tunnel, error)
+	// DeleteTunnel closes a tunnel, one that no longer exists is not an error
+	DeleteTunnel(ctx context.Context, id string) error
+}
+
+// PlayitTunnels opens tunnels on Playit.gg. They are served by the Playit agent with AgentID, which
+// has to reach the cluster IPs of the game services.
+type PlayitTunnels struct {
+	Client  *playit.Client
+	AgentID string
+}
+
+// EnsureTunnel finds the tunnel by name, so one created before the operator recorded it is reused
+func (p *PlayitTunnels) EnsureTunnel(ctx context.Context, name, ip string, port int) (Tunnel, error) {
+	existing, err := p.findTunnel(ctx, name)
+	if err != nil {
+		return Tunnel{}, err
+	}
+	origin := playit.NewAgentOrigin(p.AgentID, ip, port)
+	if existing != nil {
+		if existing.Origin == origin {
+			return Tunnel{ID: existing.ID, Address: existing.PublicAddress()}, nil
+		}
+		// The game service was recreated with another cluster IP. Playit can't point a tunnel
+		// elsewhere, so it is replaced, which gives the server a new address.
+		if err := p.DeleteTunnel(ctx, existing.ID); err != nil {
+			return Tunnel{}, err
+		}
+	}
+
+	id, err := p.Client.CreateTunnel(ctx, playit.CreateTunnelRequest{
+		Name:       name,
+		TunnelType: playit.TunnelTypeMinecraftJava,
+		PortType:   "tcp",
+		PortCount:  1,
+		Origin:     origin,
+		Enabled:    true,
+	})
+	if err != nil {
+		return Tunnel{}, err
+	}
+	// Playit allocates the address in the background, the next reconcile picks it up
+	return Tunnel{ID: id}, nil
+}
+
+// FindTunnel looks the tunnel up among all tunnels of the account
+func (p *PlayitTunnels) FindTunnel(ctx context.Context, name string) (*Tunnel, error) {
+	tunnel, err := p.findTunnel(ctx, name)
+	if err != nil || tunnel == nil {
+		return nil, err
+	}
+	return &Tunnel{ID: tunnel.ID, Address: tunnel.PublicAddress()}, nil
+}
+
+func (p *PlayitTunnels) findTunnel(ctx context.Context, name string) (*playit.Tunnel, error) {
+	tunnels, err := p.Client.ListTunnels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tunnels {
+		if tunnels[i].Name == name {
+			return &tunnels[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// DeleteTunnel ignores tunnels that were already deleted, e.g. on the Playit dashboard
+func (p *PlayitTunnels) DeleteTunnel(ctx context.Context, id string) error {
+	var apiErr *playit.Error
+	if err := p.Client.DeleteTunnel(ctx, id); err != nil && !(errors.As(err, &apiErr) && apiErr.Message == "TunnelNotFound") {
+		return err
+	}
+	return nil
+}
+
+// tunnelName is the name of the public tunnel of a server at the tunnel provider
+func tunnelName(m *homecraftv1alpha1.MinecraftServer) string {
+	return "homecraft-" + m.Namespace + "-" + m.Name
+}
+
+// reconcileTunnel opens the public tunnel of servers asking for one and closes it for the others,
+// recording it in the status. Provider failures are only reported, they must not keep the rest of
+// the server from being reconciled.
+func (r *MinecraftServerReconciler) reconcileTunnel(ctx context.Context, m *homecraftv1alpha1.MinecraftServer, minecraftSvc *corev1.Service) {
+	if r.Tunnels == nil {
+		return
+	}
+	log := r.Log.WithValues("minecraftserver", m.Name)
+
+	if !m.Spec.PublicTunnel {
+		if m.Status.TunnelID == "" {
+			return
+		}
+		log.Info("Closing public tunnel", "tunnel", m.Status.TunnelID)
+		if err := r.Tunnels.DeleteTunnel(ctx, m.Status.TunnelID); err != nil {
+			r.tunnelFailed(m, err)
+			return
+		}
+		m.Status.TunnelID = ""
+		m.Status.TunnelOrigin = ""
+		m.Status.PublicEndpoint = ""
+		return
+	}
+
+	// The cluster IP is allocated when the service is created
+	ip := minecraftSvc.Spec.ClusterIP
+	if ip == "" || ip == corev1.ClusterIPNone {
+		return
+	}
+	// Once the tunnel has its address, the provider is only asked again when the game service moved
+	origin := net.JoinHostPort(ip, strconv.Itoa(slp.DefaultPort))
+	if m.Status.TunnelID != "" && m.Status.TunnelOrigin == origin && m.Status.PublicEndpoint != "" {
+		return
+	}
+	tunnel, err := r.Tunnels.EnsureTunnel(ctx, tunnelName(m), ip, slp.DefaultPort)
+	if err != nil {
+		r.tunnelFailed(m, err)
+		return
+	}
+	if tunnel.ID != m.Status.TunnelID {
+		log.Info("Opened public tunnel", "tunnel", tunnel.ID)
+	}
+	m.Status.TunnelID = tunnel.ID
+	m.Status.TunnelOrigin = origin
+	m.Status.PublicEndpoint = tunnel.Address
+}
+
+// deleteTunnel closes the public tunnel of a server that is being deleted. A tunnel whose ID was
+// never recorded, e.g. because writing the status failed, is looked up by its name.
+func (r *MinecraftServerReconciler) deleteTunnel(ctx context.Context, m *homecraftv1alpha1.MinecraftServer) error {
+	if r.Tunnels == nil {
+		if m.Status.TunnelID != "" {
+			r.Log.Info("No tunnel provider configured, leaving the public tunnel open", "minecraftserver", m.Name, "tunnel", m.Status.TunnelID)
+			if r.Recorder != nil {
+				r.Recorder.Eventf(m, corev1.EventTypeWarning, "TunnelLeftOpen",
+					"Public tunnel %s was not deleted because no tunnel provider is configured", m.Status.TunnelID)
+			}
+		}
+		return nil
+	}
+
+	id := m.Status.TunnelID
+	if id == "" {
+		tunnel, err := r.Tunnels.FindTunnel(ctx, tunnelName(m))
+		if err != nil || tunnel == nil {
+			return err
+		}
+		id = tunnel.ID
+	}
+	return r.Tunnels.DeleteTunnel(ctx, id)
+}
+
+func (r *MinecraftServerReconciler) tunnelFailed(m *homecraftv1alpha1.MinecraftServer, err error) {
+	r.Log.Error(err, "Failed to reconcile public tunnel", "minecraftserver", m.Name)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(m, corev1.EventTypeWarning, "TunnelFailed", "Failed to reconcile the public tunnel: %v", err)
+	}
+}
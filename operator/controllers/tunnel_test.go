@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/playit"
+	"github.com/homecraft/backend/pkg/playit/playittest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestPlayitTunnels(t *testing.T) {
+	api := playittest.NewServer("agent-secret")
+	defer api.Close()
+	tunnels := &PlayitTunnels{
+		Client:  &playit.Client{BaseURL: api.URL, SecretKey: "agent-secret"},
+		AgentID: "agent-1",
+	}
+	ctx := context.Background()
+
+	created, err := tunnels.EnsureTunnel(ctx, "homecraft-minecraft-servers-survival", "10.43.0.10", 25565)
+	if err != nil {
+		t.Fatalf("EnsureTunnel() error = %v", err)
+	}
+	existing, err := tunnels.EnsureTunnel(ctx, "homecraft-minecraft-servers-survival", "10.43.0.10", 25565)
+	if err != nil {
+		t.Fatalf("EnsureTunnel() error = %v", err)
+	}
+	if existing.ID != created.ID || existing.Address != "homecraft-minecraft-servers-survival.joinmc.link" {
+		t.Errorf("EnsureTunnel() = %+v, want the tunnel %s with its address", existing, created.ID)
+	}
+
+	// A new cluster IP replaces the tunnel
+	moved, err := tunnels.EnsureTunnel(ctx, "homecraft-minecraft-servers-survival", "10.43.0.20", 25565)
+	if err != nil {
+		t.Fatalf("EnsureTunnel() error = %v", err)
+	}
+	if remaining := api.Tunnels(); len(remaining) != 1 || remaining[0].ID != moved.ID || remaining[0].Origin.Data.LocalIP != "10.43.0.20" {
+		t.Errorf("tunnels = %+v, want only one to 10.43.0.20", remaining)
+	}
+
+	if err := tunnels.DeleteTunnel(ctx, moved.ID); err != nil {
+		t.Fatalf("DeleteTunnel() error = %v", err)
+	}
+	if err := tunnels.DeleteTunnel(ctx, moved.ID); err != nil {
+		t.Errorf("deleting a deleted tunnel error = %v, want none", err)
+	}
+}
+
+func TestReconcile_PublicTunnel(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	api := playittest.NewServer("agent-secret")
+	defer api.Close()
+
+	minecraftServer := &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-server",
+			Namespace: "default",
+		},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			EULA:           true,
+			SFTPUsername:   "test-user",
+			SFTPPassword:   "test-pass",
+			Memory:         "2Gi",
+			StorageSize:    "5Gi",
+			PublicEndpoint: "manual.example.com",
+			PublicTunnel:   true,
+		},
+	}
+	// The fake client doesn't allocate cluster IPs
+	gameService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-minecraft", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "10.43.0.10",
+			Ports:     []corev1.ServicePort{{Name: "minecraft", Port: 25565}},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(minecraftServer, gameService).
+		WithStatusSubresource(minecraftServer).
+		Build()
+
+	reconciler := &MinecraftServerReconciler{
+		Client: fakeClient,
+		Log:    zap.New(zap.UseDevMode(true)),
+		Scheme: s,
+		Tunnels: &PlayitTunnels{
+			Client:  &playit.Client{BaseURL: api.URL, SecretKey: "agent-secret"},
+			AgentID: "agent-1",
+		},
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-server", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	tunnels := api.Tunnels()
+	if len(tunnels) != 1 || tunnels[0].Name != "homecraft-default-test-server" {
+		t.Fatalf("Expected a tunnel for the server, got %+v", tunnels)
+	}
+	if origin := tunnels[0].Origin.Data; origin.AgentID != "agent-1" || origin.LocalIP != "10.43.0.10" || origin.LocalPort != 25565 {
+		t.Errorf("Expected the tunnel to forward to the game service, got %+v", origin)
+	}
+	updated := &homecraftv1alpha1.MinecraftServer{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Status.TunnelID != tunnels[0].ID {
+		t.Errorf("Expected tunnel ID %s in the status, got %s", tunnels[0].ID, updated.Status.TunnelID)
+	}
+
+	// The address is picked up once Playit has allocated it
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Status.PublicEndpoint != "homecraft-default-test-server.joinmc.link" {
+		t.Errorf("Expected the tunnel address as public endpoint, got %s", updated.Status.PublicEndpoint)
+	}
+	if tunnels := api.Tunnels(); len(tunnels) != 1 {
+		t.Errorf("Expected the tunnel to be reused, got %+v", tunnels)
+	}
+
+	// With its address known, the tunnel is left alone while the game service stays put
+	lists := api.Requests("/tunnels/list")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if got := api.Requests("/tunnels/list"); got != lists {
+		t.Errorf("Expected no tunnel lookups once the tunnel is set up, got %d", got-lists)
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+
+	// Turning the tunnel off closes it and restores the manual endpoint
+	updated.Spec.PublicTunnel = false
+	if err := fakeClient.Update(ctx, updated); err != nil {
+		t.Fatalf("Failed to update MinecraftServer: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if tunnels := api.Tunnels(); len(tunnels) != 0 {
+		t.Errorf("Expected the tunnel to be deleted, got %+v", tunnels)
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, updated); err != nil {
+		t.Fatalf("Failed to get MinecraftServer: %v", err)
+	}
+	if updated.Status.TunnelID != "" || updated.Status.PublicEndpoint != "manual.example.com" {
+		t.Errorf("Expected the tunnel to be removed from the status, got %s at %s", updated.Status.TunnelID, updated.Status.PublicEndpoint)
+	}
+
+	// Deleting the server closes its tunnel
+	updated.Spec.PublicTunnel = true
+	if err := fakeClient.Update(ctx, updated); err != nil {
+		t.Fatalf("Failed to update MinecraftServer: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if err := fakeClient.Delete(ctx, updated); err != nil {
+		t.Fatalf("Failed to delete MinecraftServer: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if tunnels := api.Tunnels(); len(tunnels) != 0 {
+		t.Errorf("Expected the tunnel to be deleted with the server, got %+v", tunnels)
+	}
+}
+
+func TestDeleteTunnel_UnrecordedTunnel(t *testing.T) {
+	api := playittest.NewServer("agent-secret")
+	defer api.Close()
+	tunnels := &PlayitTunnels{
+		Client:  &playit.Client{BaseURL: api.URL, SecretKey: "agent-secret"},
+		AgentID: "agent-1",
+	}
+	reconciler := &MinecraftServerReconciler{Log: zap.New(zap.UseDevMode(true)), Tunnels: tunnels}
+	ctx := context.Background()
+
+	// The tunnel was opened, but writing its ID to the status failed
+	server := &homecraftv1alpha1.MinecraftServer{ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"}}
+	if _, err := tunnels.EnsureTunnel(ctx, tunnelName(server), "10.43.0.10", 25565); err != nil {
+		t.Fatalf("EnsureTunnel() error = %v", err)
+	}
+	if _, err := tunnels.EnsureTunnel(ctx, "homecraft-default-other-server", "10.43.0.11", 25565); err != nil {
+		t.Fatalf("EnsureTunnel() error = %v", err)
+	}
+
+	if err := reconciler.deleteTunnel(ctx, server); err != nil {
+		t.Fatalf("deleteTunnel() error = %v", err)
+	}
+	if remaining := api.Tunnels(); len(remaining) != 1 || remaining[0].Name != "homecraft-default-other-server" {
+		t.Errorf("Expected only the tunnel of the other server to remain, got %+v", remaining)
+	}
+	// Nothing to find is not an error
+	if err := reconciler.deleteTunnel(ctx, server); err != nil {
+		t.Errorf("deleteTunnel() without a tunnel error = %v", err)
+	}
+}
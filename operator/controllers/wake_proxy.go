@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	"github.com/homecraft/backend/pkg/slp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DefaultWakeProxyServiceAccount is the service account the wake proxies run as, it may start servers
+const DefaultWakeProxyServiceAccount = "homecraft-wake-proxy"
+
+// wakeProxyLabels select the wake proxy pod of a server. They differ from the server pod labels,
+// so the game service points at either one but never both.
+func wakeProxyLabels(m *homecraftv1alpha1.MinecraftServer) map[string]string {
+	return map[string]string{
+		"app":             "minecraft-wake",
+		"minecraftserver": m.Name,
+	}
+}
+
+// wakeProxyEnabled reports whether a server gets a wake proxy. It keeps running while the server is
+// up, so connections it handed over to the server are not cut when the game service switches back.
+func (r *MinecraftServerReconciler) wakeProxyEnabled(m *homecraftv1alpha1.MinecraftServer) bool {
+	return r.WakeProxyImage != "" && m.Spec.IdleShutdown != nil && m.Spec.IdleShutdown.WakeOnConnect
+}
+
+// wakeProxyServing reports whether the game service of a server points at its wake proxy: while the
+// idle shutdown policy keeps the server stopped, and while it starts until it has loaded its world.
+func (r *MinecraftServerReconciler) wakeProxyServing(m *homecraftv1alpha1.MinecraftServer) bool {
+	if !r.wakeProxyEnabled(m) || isRestoring(m) {
+		return false
+	}
+	if m.Spec.Paused {
+		return m.Status.StopReason == homecraftv1alpha1.StopReasonIdle
+	}
+	return !meta.IsStatusConditionTrue(m.Status.Conditions, homecraftv1alpha1.ServerConditionGameReady)
+}
+
+// reconcileWakeProxy runs the wake proxy of a server that has one and removes it from servers that don't
+func (r *MinecraftServerReconciler) reconcileWakeProxy(ctx context.Context, m *homecraftv1alpha1.MinecraftServer) error {
+	if r.wakeProxyEnabled(m) {
+		return r.createOrUpdateResource(ctx, r.deploymentForWakeProxy(m), m)
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: m.Name + "-wake", Namespace: m.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	r.Log.Info("Removing wake proxy", "minecraftserver", m.Name)
+	if err := r.Delete(ctx, deployment); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *MinecraftServerReconciler) deploymentForWakeProxy(m *homecraftv1alpha1.MinecraftServer) *appsv1.Deployment {
+	labels := wakeProxyLabels(m)
+	podLabels := mergeStringMaps(labels, map[string]string{
+		"app.kubernetes.io/name":       "minecraft-wake-proxy",
+		"app.kubernetes.io/instance":   m.Name,
+		"app.kubernetes.io/managed-by": "homecraft-operator",
+	})
+	replicas := int32(1)
+	serviceAccount := r.WakeProxyServiceAccount
+	if serviceAccount == "" {
+		serviceAccount = DefaultWakeProxyServiceAccount
+	}
+	runAsNonRoot := true
+	runAsUser := int64(65532)
+	allowPrivilegeEscalation := false
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.Name + "-wake",
+			Namespace: m.Namespace,
+			Labels:    podLabels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: &runAsNonRoot,
+						RunAsUser:    &runAsUser,
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "wake-proxy",
+							Image:   r.WakeProxyImage,
+							Command: []string{"/wake-proxy"},
+							Env: []corev1.EnvVar{
+								{Name: "SERVER_NAME", Value: m.Name},
+								{Name: "SERVER_NAMESPACE", Value: m.Namespace},
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "minecraft",
+									ContainerPort: slp.DefaultPort,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(slp.DefaultPort)},
+								},
+								PeriodSeconds: 10,
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("10m"),
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+							},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+								Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
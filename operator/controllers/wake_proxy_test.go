@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	homecraftv1alpha1 "github.com/homecraft/backend/pkg/apis/homecraft/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// wakingServer returns a server stopped by its idle shutdown policy that players can wake
+func wakingServer() *homecraftv1alpha1.MinecraftServer {
+	return &homecraftv1alpha1.MinecraftServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: "default"},
+		Spec: homecraftv1alpha1.MinecraftServerSpec{
+			Memory: "2Gi",
+			Paused: true,
+			IdleShutdown: &homecraftv1alpha1.IdleShutdownPolicy{
+				TimeoutMinutes: 15,
+				WakeOnConnect:  true,
+			},
+		},
+		Status: homecraftv1alpha1.MinecraftServerStatus{StopReason: homecraftv1alpha1.StopReasonIdle},
+	}
+}
+
+func TestWakeProxyServing(t *testing.T) {
+	gameReady := func(status metav1.ConditionStatus) []metav1.Condition {
+		return []metav1.Condition{{Type: homecraftv1alpha1.ServerConditionGameReady, Status: status}}
+	}
+
+	tests := []struct {
+		name   string
+		image  string
+		mutate func(*homecraftv1alpha1.MinecraftServer)
+		want   bool
+	}{
+		{
+			name:   "stopped for being idle",
+			image:  "homecraft-backend:latest",
+			mutate: func(*homecraftv1alpha1.MinecraftServer) {},
+			want:   true,
+		},
+		{
+			name:   "without a wake proxy image",
+			mutate: func(*homecraftv1alpha1.MinecraftServer) {},
+			want:   false,
+		},
+		{
+			name:   "without wakeOnConnect",
+			image:  "homecraft-backend:latest",
+			mutate: func(m *homecraftv1alpha1.MinecraftServer) { m.Spec.IdleShutdown.WakeOnConnect = false },
+			want:   false,
+		},
+		{
+			name:   "stopped by a user",
+			image:  "homecraft-backend:latest",
+			mutate: func(m *homecraftv1alpha1.MinecraftServer) { m.Status.StopReason = "" },
+			want:   false,
+		},
+		{
+			name:  "starting",
+			image: "homecraft-backend:latest",
+			mutate: func(m *homecraftv1alpha1.MinecraftServer) {
+				m.Spec.Paused = false
+				m.Status.Conditions = gameReady(metav1.ConditionFalse)
+			},
+			want: true,
+		},
+		{
+			name:  "running",
+			image: "homecraft-backend:latest",
+			mutate: func(m *homecraftv1alpha1.MinecraftServer) {
+				m.Spec.Paused = false
+				m.Status.Conditions = gameReady(metav1.ConditionTrue)
+			},
+			want: false,
+		},
+		{
+			name:  "restoring",
+			image: "homecraft-backend:latest",
+			mutate: func(m *homecraftv1alpha1.MinecraftServer) {
+				m.Annotations = map[string]string{homecraftv1alpha1.RestoreAnnotation: "test-restore"}
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := wakingServer()
+			tt.mutate(server)
+			reconciler := &MinecraftServerReconciler{WakeProxyImage: tt.image}
+
+			if got := reconciler.wakeProxyServing(server); got != tt.want {
+				t.Errorf("wakeProxyServing() = %v, want %v", got, tt.want)
+			}
+
+			wantSelector := map[string]string{"app": "minecraft", "minecraftserver": "test-server"}
+			if tt.want {
+				wantSelector = wakeProxyLabels(server)
+			}
+			if svc := reconciler.serviceForMinecraft(server); !reflect.DeepEqual(svc.Spec.Selector, wantSelector) {
+				t.Errorf("game service selector = %v, want %v", svc.Spec.Selector, wantSelector)
+			}
+		})
+	}
+}
+
+func TestReconcileWakeProxy(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = homecraftv1alpha1.AddToScheme(s)
+
+	server := wakingServer()
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(server).Build()
+	reconciler := &MinecraftServerReconciler{
+		Client:         fakeClient,
+		Log:            zap.New(zap.UseDevMode(true)),
+		Scheme:         s,
+		WakeProxyImage: "homecraft-backend:latest",
+	}
+
+	if err := reconciler.reconcileWakeProxy(context.Background(), server); err != nil {
+		t.Fatalf("reconcileWakeProxy() error = %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: "test-server-wake", Namespace: "default"}
+	if err := fakeClient.Get(context.Background(), key, deployment); err != nil {
+		t.Fatalf("Expected the wake proxy deployment: %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != "homecraft-backend:latest" {
+		t.Errorf("Expected image homecraft-backend:latest, got %s", container.Image)
+	}
+	if deployment.Spec.Template.Spec.ServiceAccountName != DefaultWakeProxyServiceAccount {
+		t.Errorf("Expected service account %s, got %s", DefaultWakeProxyServiceAccount, deployment.Spec.Template.Spec.ServiceAccountName)
+	}
+	if !reflect.DeepEqual(deployment.Spec.Selector.MatchLabels, wakeProxyLabels(server)) {
+		t.Errorf("Expected the deployment to select %v, got %v", wakeProxyLabels(server), deployment.Spec.Selector.MatchLabels)
+	}
+
+	// Hand edits of the pod template are reverted
+	runAsNonRoot := false
+	deployment.Spec.Template.Spec.ServiceAccountName = "default"
+	deployment.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsNonRoot: &runAsNonRoot}
+	deployment.Spec.Template.Spec.Containers[0].SecurityContext = nil
+	deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{
+		Name:         "host",
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+	}}
+	if err := fakeClient.Update(context.Background(), deployment); err != nil {
+		t.Fatalf("Failed to edit the deployment: %v", err)
+	}
+	if err := reconciler.reconcileWakeProxy(context.Background(), server); err != nil {
+		t.Fatalf("reconcileWakeProxy() error = %v", err)
+	}
+	if err := fakeClient.Get(context.Background(), key, deployment); err != nil {
+		t.Fatalf("Failed to get the deployment: %v", err)
+	}
+	podSpec := deployment.Spec.Template.Spec
+	if podSpec.ServiceAccountName != DefaultWakeProxyServiceAccount {
+		t.Errorf("Expected service account %s to be restored, got %s", DefaultWakeProxyServiceAccount, podSpec.ServiceAccountName)
+	}
+	if podSpec.SecurityContext == nil || podSpec.SecurityContext.RunAsNonRoot == nil || !*podSpec.SecurityContext.RunAsNonRoot {
+		t.Errorf("Expected the pod to run as non-root again, got %+v", podSpec.SecurityContext)
+	}
+	if podSpec.Containers[0].SecurityContext == nil {
+		t.Error("Expected the container security context to be restored")
+	}
+	if len(podSpec.Volumes) != 0 {
+		t.Errorf("Expected the added volume to be removed, got %+v", podSpec.Volumes)
+	}
+
+	// Turning wakeOnConnect off removes the proxy
+	server.Spec.IdleShutdown.WakeOnConnect = false
+	if err := reconciler.reconcileWakeProxy(context.Background(), server); err != nil {
+		t.Fatalf("reconcileWakeProxy() error = %v", err)
+	}
+	if err := fakeClient.Get(context.Background(), key, deployment); !errors.IsNotFound(err) {
+		t.Errorf("Expected the wake proxy deployment to be deleted, got %v", err)
+	}
+}